@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/mcp/pkg/service"
+)
+
+type command struct {
+	description string
+	flags       func(fs *flag.FlagSet, c *Config)
+	run         func(ctx context.Context, c Config) error
+}
+
+var commands = map[string]command{
+	"serve": {
+		description: "Start the MCP server over HTTP or stdio (default command).",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			hugrFlags(fs, c)
+			indexerFlags(fs, c)
+			fs.StringVar(&c.Bind, "bind", c.Bind, "address to listen on (env BIND)")
+			fs.StringVar(&c.Transport, "transport", c.Transport, "MCP transport: http or stdio (env MCP_TRANSPORT)")
+			fs.DurationVar(&c.Indexer.Watch.Interval, "watch-interval", c.Indexer.Watch.Interval, "schema changes check interval, 0 disables the watcher (env SCHEMA_WATCH_INTERVAL)")
+		},
+		run: serve,
+	},
+	"init-db": {
+		description: "Create the MCP index database (DuckDB file or PostgreSQL).",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			fs.StringVar(&c.Indexer.Path, "path", c.Indexer.Path, "index database path or postgres DSN (env INDEXER_DATA_SOURCE_PATH)")
+			fs.IntVar(&c.Indexer.VectorSize, "vector-size", c.Indexer.VectorSize, "embeddings vector size (env INDEXER_VECTOR_SIZE)")
+		},
+		run: initDB,
+	},
+	"migrate": {
+		description: "Upgrade the existing MCP index database to the current version (detach the DuckDB file from hugr first).",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			fs.StringVar(&c.Indexer.Path, "path", c.Indexer.Path, "index database path or postgres DSN (env INDEXER_DATA_SOURCE_PATH)")
+			fs.IntVar(&c.Indexer.VectorSize, "vector-size", c.Indexer.VectorSize, "embeddings vector size (env INDEXER_VECTOR_SIZE)")
+		},
+		run: migrate,
+	},
+	"load": {
+		description: "Register the MCP data source in hugr and fill the index with the hugr schema (replaces the index content).",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			hugrFlags(fs, c)
+			indexerFlags(fs, c)
+		},
+		run: load,
+	},
+	"summarize": {
+		description: "Summarize not summarized data objects, functions, data sources and modules with the LLM.",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			hugrFlags(fs, c)
+			indexerFlags(fs, c)
+			fs.IntVar(&c.Indexer.Summarize.MaxConnections, "max-connections", c.Indexer.Summarize.MaxConnections, "concurrent LLM requests (env SUMMARIZE_MAX_CONNECTIONS)")
+		},
+		run: summarize,
+	},
+	"index": {
+		description: "Build the embeddings for the data sources, modules, types and fields.",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			hugrFlags(fs, c)
+			indexerFlags(fs, c)
+			fs.BoolVar(&c.SummarizedOnly, "summarized", c.SummarizedOnly, "index only summarized items")
+		},
+		run: index,
+	},
+	"diff": {
+		description: "Compare the hugr schema with the index and print the differences as JSON.",
+		flags: func(fs *flag.FlagSet, c *Config) {
+			hugrFlags(fs, c)
+			indexerFlags(fs, c)
+			fs.BoolVar(&c.ApplyDiff, "apply", c.ApplyDiff, "apply the differences to the index")
+			fs.BoolVar(&c.SummarizeDiff, "summarize", c.SummarizeDiff, "summarize the changed items, requires -apply")
+			fs.IntVar(&c.Indexer.Summarize.MaxConnections, "max-connections", c.Indexer.Summarize.MaxConnections, "concurrent LLM requests (env SUMMARIZE_MAX_CONNECTIONS)")
+		},
+		run: diff,
+	},
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the command flags.\n", os.Args[0])
+}
+
+func hugrFlags(fs *flag.FlagSet, c *Config) {
+	fs.StringVar(&c.URL, "hugr-url", c.URL, "hugr IPC url (env HUGR_IPC_URL)")
+	fs.StringVar(&c.Secret, "hugr-secret", c.Secret, "hugr api key (env HUGR_SECRET)")
+	fs.StringVar(&c.Admin.URL, "hugr-admin-url", c.Admin.URL, "hugr IPC url for the index access (env HUGR_ADMIN_IPC_URL)")
+	fs.StringVar(&c.Admin.Secret, "hugr-admin-secret", c.Admin.Secret, "hugr api key for the index access (env HUGR_ADMIN_SECRET)")
+}
+
+func indexerFlags(fs *flag.FlagSet, c *Config) {
+	fs.StringVar(&c.Indexer.Path, "path", c.Indexer.Path, "index database path or postgres DSN (env INDEXER_DATA_SOURCE_PATH)")
+	fs.BoolVar(&c.Indexer.EmbeddingsEnabled, "embeddings", c.Indexer.EmbeddingsEnabled, "enable embeddings (env EMBEDDINGS_ENABLED)")
+}
+
+func initDB(ctx context.Context, c Config) error {
+	if c.Indexer.Path == "" {
+		return fmt.Errorf("index database path is required")
+	}
+	log.Printf("creating index database %s", c.Indexer.Path)
+	return indexer.InitDB(ctx, c.Indexer.Path, c.Indexer.VectorSize)
+}
+
+func migrate(ctx context.Context, c Config) error {
+	if c.Indexer.Path == "" {
+		return fmt.Errorf("index database path is required")
+	}
+	if c.Indexer.VectorSize <= 0 {
+		return fmt.Errorf("embeddings vector size is required (-vector-size or INDEXER_VECTOR_SIZE)")
+	}
+	log.Printf("migrating index database %s", c.Indexer.Path)
+	applied, err := indexer.Migrate(ctx, c.Indexer.Path, c.Indexer.VectorSize)
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		log.Println("index database is up to date")
+		return nil
+	}
+	log.Printf("applied migrations: %s", strings.Join(applied, ", "))
+	return nil
+}
+
+// indexerService initializes the indexer, the MCP data source is registered in hugr if it is needed.
+func indexerService(ctx context.Context, c Config) (*indexer.Service, error) {
+	if c.URL == "" && c.Admin.URL == "" {
+		return nil, fmt.Errorf("hugr url is required")
+	}
+	s := service.New(c.Config).Indexer()
+	log.Println("initializing index data source")
+	if err := s.Init(ctx); err != nil {
+		return nil, fmt.Errorf("initialize indexer: %w", err)
+	}
+	return s, nil
+}
+
+func load(ctx context.Context, c Config) error {
+	s, err := indexerService(ctx, c)
+	if err != nil {
+		return err
+	}
+	log.Println("loading hugr schema")
+	return s.LoadSchema(ctx)
+}
+
+func summarize(ctx context.Context, c Config) error {
+	if c.Indexer.Summarize.MaxConnections <= 0 {
+		c.Indexer.Summarize.MaxConnections = 1
+	}
+	s, err := indexerService(ctx, c)
+	if err != nil {
+		return err
+	}
+	log.Println("summarizing schema")
+	return s.Summarize(ctx)
+}
+
+func index(ctx context.Context, c Config) error {
+	s, err := indexerService(ctx, c)
+	if err != nil {
+		return err
+	}
+	log.Println("building embeddings")
+	return s.Index(ctx, c.SummarizedOnly)
+}
+
+func diff(ctx context.Context, c Config) error {
+	if c.SummarizeDiff && !c.ApplyDiff {
+		return errors.New("-summarize requires -apply")
+	}
+	if c.Indexer.Summarize.MaxConnections <= 0 {
+		c.Indexer.Summarize.MaxConnections = 1
+	}
+	s, err := indexerService(ctx, c)
+	if err != nil {
+		return err
+	}
+	var d *indexer.SchemaDiff
+	if c.ApplyDiff {
+		log.Println("synchronizing index with hugr schema")
+		d, err = s.SyncSchema(ctx, c.SummarizeDiff)
+	} else {
+		log.Println("comparing hugr schema with index")
+		d, err = s.Diff(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/artifacts"
+	"github.com/hugr-lab/mcp/pkg/auth"
 	"github.com/hugr-lab/mcp/pkg/indexer"
 	"github.com/hugr-lab/mcp/pkg/pool"
 	"github.com/hugr-lab/mcp/pkg/service"
-	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
@@ -13,16 +16,27 @@ func init() {
 }
 
 func initEnvs() {
-	// Initialize environment variables
-	_ = godotenv.Load()
+	// Initialize environment variables, the .env (or -config) file is loaded by run
 	viper.SetDefault("HUGR_IPC_URL", "")
 	viper.SetDefault("BIND", ":14000")
+	viper.SetDefault("MCP_TRANSPORT", "http")
+	viper.SetDefault("SCHEMA_WATCH_JITTER", 0.1)
 	viper.AutomaticEnv()
 }
 
 type Config struct {
 	service.Config
 	Bind string
+	// Transport is the serve command transport: http or stdio
+	Transport string
+	// LogFile redirects the logs from stderr to the file
+	LogFile string
+	// SummarizedOnly limits the index command to the summarized items
+	SummarizedOnly bool
+	// ApplyDiff applies the schema differences to the index in the diff command
+	ApplyDiff bool
+	// SummarizeDiff summarizes the changed items after the diff is applied
+	SummarizeDiff bool
 }
 
 func config() Config {
@@ -31,7 +45,32 @@ func config() Config {
 			URL:          viper.GetString("HUGR_IPC_URL"),
 			Secret:       viper.GetString("HUGR_SECRET"),
 			SecretHeader: viper.GetString("HUGR_SECRET_HEADER"),
-			TTL:          viper.GetDuration("HUGR_CACHE_TTL"),
+			Headers:      envMap("HUGR_HEADERS"),
+			Admin: service.ClientConfig{
+				URL:          viper.GetString("HUGR_ADMIN_IPC_URL"),
+				Secret:       viper.GetString("HUGR_ADMIN_SECRET"),
+				SecretHeader: viper.GetString("HUGR_ADMIN_SECRET_HEADER"),
+				Headers:      envMap("HUGR_ADMIN_HEADERS"),
+			},
+			TTL: viper.GetDuration("HUGR_CACHE_TTL"),
+			Auth: auth.OIDCConfig{
+				Issuer:   viper.GetString("OIDC_ISSUER"),
+				Audience: viper.GetString("OIDC_AUDIENCE"),
+				JWKSURL:  viper.GetString("OIDC_JWKS_URL"),
+				JWKSFile: viper.GetString("OIDC_JWKS_FILE"),
+				CacheTTL: viper.GetDuration("OIDC_JWKS_CACHE_TTL"),
+				Leeway:   viper.GetDuration("OIDC_LEEWAY"),
+				Claims: auth.ClaimsConfig{
+					Role:     viper.GetString("OIDC_ROLE_CLAIM"),
+					UserID:   viper.GetString("OIDC_USER_ID_CLAIM"),
+					UserName: viper.GetString("OIDC_USER_NAME_CLAIM"),
+				},
+				AllowedRoles: envList("OIDC_ALLOWED_ROLES"),
+			},
+			CORSOrigins:       envList("CORS_ALLOWED_ORIGINS"),
+			AdminSecret:       viper.GetString("MCP_ADMIN_SECRET"),
+			ReadOnly:          viper.GetBool("MCP_READ_ONLY"),
+			AllowedQueryRoots: envList("MCP_ALLOWED_QUERY_ROOTS"),
 			Indexer: indexer.Config{
 				Path:       viper.GetString("INDEXER_DATA_SOURCE_PATH"),
 				VectorSize: viper.GetInt("INDEXER_VECTOR_SIZE"),
@@ -50,8 +89,59 @@ func config() Config {
 					ApiKey:         viper.GetString("SUMMARIZE_API_KEY"),
 				},
 				CacheTTL: viper.GetDuration("INDEXER_CACHE_TTL"),
+				// Schema watcher
+				Watch: indexer.WatchConfig{
+					Interval:   viper.GetDuration("SCHEMA_WATCH_INTERVAL"),
+					MaxBackoff: viper.GetDuration("SCHEMA_WATCH_MAX_BACKOFF"),
+					Jitter:     viper.GetFloat64("SCHEMA_WATCH_JITTER"),
+					Summarize:  viper.GetBool("SCHEMA_WATCH_SUMMARIZE"),
+				},
+			},
+			Artifacts: artifacts.Config{
+				Path: viper.GetString("ARTIFACTS_PATH"),
+				TTL:  viper.GetDuration("ARTIFACTS_TTL"),
+				S3: artifacts.S3Config{
+					Endpoint:  viper.GetString("ARTIFACTS_S3_ENDPOINT"),
+					Region:    viper.GetString("ARTIFACTS_S3_REGION"),
+					Bucket:    viper.GetString("ARTIFACTS_S3_BUCKET"),
+					Prefix:    viper.GetString("ARTIFACTS_S3_PREFIX"),
+					AccessKey: viper.GetString("ARTIFACTS_S3_ACCESS_KEY"),
+					SecretKey: viper.GetString("ARTIFACTS_S3_SECRET_KEY"),
+				},
+			},
+			Sessions: service.SessionsConfig{
+				Enabled: viper.GetBool("MCP_SESSIONS_ENABLED"),
+				TTL:     viper.GetDuration("MCP_SESSION_TTL"),
+				Store:   viper.GetString("MCP_SESSION_STORE"),
 			},
 		},
-		Bind: viper.GetString("BIND"),
+		Bind:      viper.GetString("BIND"),
+		Transport: viper.GetString("MCP_TRANSPORT"),
+		LogFile:   viper.GetString("LOG_FILE"),
+	}
+}
+
+// envList returns the comma separated list from the environment variable.
+func envList(key string) []string {
+	var list []string
+	for v := range strings.SplitSeq(viper.GetString(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// envMap returns the map from the comma separated list of key=value pairs from the environment variable.
+func envMap(key string) map[string]string {
+	list := envList(key)
+	if len(list) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(list))
+	for _, kv := range list {
+		k, v, _ := strings.Cut(kv, "=")
+		m[strings.TrimSpace(k)] = strings.TrimSpace(v)
 	}
+	return m
 }
@@ -3,47 +3,149 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/hugr-lab/mcp/pkg/service"
+	"github.com/joho/godotenv"
 )
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command and returns the exit code, the signal context is released before the exit.
+func run(args []string) int {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	defer cancel()
 
+	name := "serve"
+	if len(args) != 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return 0
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		return 2
+	}
+
+	// the config file is loaded before the flags defaults are set from the environment
+	configFile := flagValue(args, "config")
+	if configFile == "" {
+		_ = godotenv.Load()
+	} else if err := godotenv.Load(configFile); err != nil {
+		fmt.Fprintf(os.Stderr, "load config file: %v\n", err)
+		return 2
+	}
+
 	c := config()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags]\n\n%s\n\nFlags:\n", os.Args[0], name, cmd.description)
+		fs.PrintDefaults()
+	}
+	fs.String("config", configFile, "env file with the configuration, the environment variables take precedence (default .env)")
+	fs.StringVar(&c.LogFile, "log-file", c.LogFile, "write the logs to the file instead of stderr (env LOG_FILE)")
+	cmd.flags(fs, &c)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if c.LogFile != "" {
+		f, err := os.OpenFile(c.LogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "open log file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		log.SetOutput(f)
+	}
+
+	start := time.Now()
+	if err := cmd.run(ctx, c); err != nil {
+		log.Printf("%s failed: %v", name, err)
+		return 1
+	}
+	if name != "serve" {
+		log.Printf("%s completed in %s", name, time.Since(start).Round(time.Millisecond))
+	}
+	return 0
+}
+
+// flagValue returns the flag value from the command line arguments before the flags are parsed.
+func flagValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		arg = strings.TrimLeft(arg, "-")
+		if v, ok := strings.CutPrefix(arg, name+"="); ok {
+			return v
+		}
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func serve(ctx context.Context, c Config) error {
+	if c.Transport != "http" && c.Transport != "stdio" {
+		return fmt.Errorf("unknown transport %q, expected http or stdio", c.Transport)
+	}
 	log.Println("MCP Service configured to", c.URL)
 
 	s := service.New(c.Config)
 
 	err := s.Init(ctx)
 	if err != nil {
-		log.Println("Initialization error:", err)
-		os.Exit(1)
+		return fmt.Errorf("initialization: %w", err)
 	}
 
 	log.Println("Initialization complete")
 
+	if c.Transport == "stdio" {
+		// stdout is the protocol stream, the logs are written to stderr or the log file
+		log.Println("Serving MCP over stdio")
+		err = s.ServeStdio(ctx, os.Stdin, os.Stdout)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("stdio server: %w", err)
+		}
+		return nil
+	}
+
 	srv := &http.Server{
 		Addr:    c.Bind,
 		Handler: s,
 	}
 
+	errCh := make(chan error, 1)
 	go func() {
 		log.Println("Starting server on http://localhost", c.Bind)
-		err := srv.ListenAndServe()
-		if errors.Is(err, http.ErrServerClosed) {
-			log.Println("Server closed")
-			return
-		}
-		if err != nil {
-			log.Println("Server error:", err)
-		}
+		errCh <- srv.ListenAndServe()
 	}()
 
-	<-ctx.Done()
+	select {
+	case <-ctx.Done():
+		sctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(sctx)
+		log.Println("Server closed")
+		return nil
+	case err := <-errCh:
+		return fmt.Errorf("server: %w", err)
+	}
 }
@@ -0,0 +1,318 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSONL   Format = "jsonl"
+	FormatParquet Format = "parquet"
+	FormatGeoJSON Format = "geojson"
+)
+
+var ErrNotFound = errors.New("artifact not found")
+
+const defaultTTL = 24 * time.Hour
+
+// Config is the artifact store configuration, the local directory is used if the S3 bucket is not set.
+type Config struct {
+	// Path is the local directory to store the artifacts (default hugr-mcp-artifacts in the temp directory)
+	Path string
+	// TTL is the artifact lifetime (default 24h)
+	TTL time.Duration
+	S3  S3Config
+}
+
+// Artifact is the query result file metadata.
+type Artifact struct {
+	ID            string    `json:"id" jsonschema_description:"The artifact id"`
+	Format        Format    `json:"format" jsonschema_description:"The file format: csv, jsonl, parquet or geojson"`
+	ContentType   string    `json:"content_type" jsonschema_description:"The MIME type of the file"`
+	FileName      string    `json:"file_name" jsonschema_description:"The file name"`
+	Rows          int64     `json:"rows" jsonschema_description:"The number of rows (features) in the file"`
+	Size          int64     `json:"size" jsonschema_description:"The file size in bytes"`
+	Columns       []Column  `json:"columns,omitempty" jsonschema_description:"The result schema"`
+	GeometryField string    `json:"geometry_field,omitempty" jsonschema_description:"The geometry field of the GeoJSON features"`
+	Query         string    `json:"query,omitempty" jsonschema_description:"The GraphQL query of the result"`
+	Path          string    `json:"path,omitempty" jsonschema_description:"The data path of the result in the query response"`
+	UserID        string    `json:"user_id,omitempty" jsonschema_description:"The user that created the artifact"`
+	CreatedAt     time.Time `json:"created_at" jsonschema_description:"The artifact creation time"`
+	ExpiresAt     time.Time `json:"expires_at" jsonschema_description:"The artifact expiration time"`
+}
+
+type Column struct {
+	Name     string `json:"name" jsonschema_description:"The column name"`
+	Type     string `json:"type" jsonschema_description:"The column (arrow) data type"`
+	Nullable bool   `json:"nullable,omitempty" jsonschema_description:"Whether the column can contain nulls"`
+}
+
+// CreateRequest describes the artifact to write from the query result records.
+type CreateRequest struct {
+	Format        Format
+	GeometryField string
+	Query         string
+	Path          string
+	// UserID is the owner of the artifact, only the owner can read it
+	UserID string
+}
+
+// Service writes the query results to the artifact store.
+// The artifacts expire after the TTL, the expired artifacts are not returned and are deleted by the cleanup.
+type Service struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu      sync.Mutex
+	created map[string]time.Time // the expiration time of the artifacts created by the service
+}
+
+func New(c Config) (*Service, error) {
+	if c.S3.Bucket != "" {
+		store, err := NewS3Store(c.S3)
+		if err != nil {
+			return nil, err
+		}
+		return newService(store, c.TTL), nil
+	}
+	if c.Path == "" {
+		c.Path = filepath.Join(os.TempDir(), "hugr-mcp-artifacts")
+	}
+	return newService(NewLocalStore(c.Path), c.TTL), nil
+}
+
+// NewWithStore returns the service that keeps the artifacts in the store with the default TTL.
+func NewWithStore(store Store) *Service {
+	return newService(store, 0)
+}
+
+func newService(store Store, ttl time.Duration) *Service {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	return &Service{store: store, ttl: ttl, now: time.Now, created: map[string]time.Time{}}
+}
+
+// Create writes the records in the requested format and stores the file with its metadata.
+func (s *Service) Create(ctx context.Context, schema *arrow.Schema, records []arrow.RecordBatch, req CreateRequest) (*Artifact, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	a := &Artifact{
+		ID:        id,
+		Format:    req.Format,
+		FileName:  "data." + string(req.Format),
+		Query:     req.Query,
+		Path:      req.Path,
+		UserID:    req.UserID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	for _, f := range schema.Fields() {
+		a.Columns = append(a.Columns, Column{Name: f.Name, Type: f.Type.String(), Nullable: f.Nullable})
+	}
+	for _, rec := range records {
+		a.Rows += rec.NumRows()
+	}
+
+	f, err := os.CreateTemp("", "hugr-artifact-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	switch req.Format {
+	case FormatCSV:
+		a.ContentType = "text/csv"
+		err = writeCSV(f, schema, records)
+	case FormatJSONL:
+		a.ContentType = "application/x-ndjson"
+		err = writeJSONL(f, schema, records)
+	case FormatParquet:
+		a.ContentType = "application/vnd.apache.parquet"
+		err = writeParquet(f, schema, records)
+	case FormatGeoJSON:
+		a.ContentType = "application/geo+json"
+		a.GeometryField, err = writeGeoJSON(f, schema, records, req.GeometryField)
+	default:
+		return nil, fmt.Errorf("unsupported artifact format %q", req.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write %s: %w", req.Format, err)
+	}
+	if a.Size, err = f.Seek(0, io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Put(ctx, dataKey(a), f, a.Size, a.ContentType); err != nil {
+		return nil, fmt.Errorf("store artifact: %w", err)
+	}
+	meta, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, metaKey(a.ID), bytes.NewReader(meta), int64(len(meta)), "application/json"); err != nil {
+		return nil, fmt.Errorf("store artifact metadata: %w", err)
+	}
+	s.mu.Lock()
+	s.created[a.ID] = a.ExpiresAt
+	s.mu.Unlock()
+	return a, nil
+}
+
+// Info returns the artifact metadata, it returns ErrNotFound if the artifact does not exist or is expired.
+func (s *Service) Info(ctx context.Context, id string) (*Artifact, error) {
+	a, err := s.info(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !a.ExpiresAt.IsZero() && s.now().After(a.ExpiresAt) {
+		if err := s.delete(ctx, a); err != nil {
+			log.Printf("artifacts: failed to delete expired artifact %s: %v", a.ID, err)
+		}
+		return nil, ErrNotFound
+	}
+	return a, nil
+}
+
+func (s *Service) info(ctx context.Context, id string) (*Artifact, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+	r, err := s.store.Get(ctx, metaKey(id))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var a Artifact
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("decode artifact metadata: %w", err)
+	}
+	return &a, nil
+}
+
+// Open returns the artifact metadata and the file reader, the reader should be closed by the caller.
+func (s *Service) Open(ctx context.Context, id string) (*Artifact, io.ReadCloser, error) {
+	a, err := s.Info(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := s.store.Get(ctx, dataKey(a))
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, r, nil
+}
+
+// Delete removes the artifact file and metadata.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	a, err := s.info(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, a)
+}
+
+func (s *Service) delete(ctx context.Context, a *Artifact) error {
+	if err := s.store.Delete(ctx, dataKey(a)); err != nil {
+		return err
+	}
+	if err := s.store.Delete(ctx, metaKey(a.ID)); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.created, a.ID)
+	s.mu.Unlock()
+	return nil
+}
+
+// DeleteExpired removes the expired artifacts created by the service.
+func (s *Service) DeleteExpired(ctx context.Context) error {
+	now := s.now()
+	var expired []string
+	s.mu.Lock()
+	for id, exp := range s.created {
+		if now.After(exp) {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.Unlock()
+	var errs []error
+	for _, id := range expired {
+		err := s.Delete(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			s.mu.Lock()
+			delete(s.created, id)
+			s.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("delete artifact %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Cleanup removes the expired artifacts periodically until the context is canceled.
+func (s *Service) Cleanup(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = s.ttl
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.DeleteExpired(ctx); err != nil {
+				log.Printf("artifacts: failed to delete expired artifacts: %v", err)
+			}
+		}
+	}
+}
+
+func dataKey(a *Artifact) string {
+	return a.ID + "/" + a.FileName
+}
+
+func metaKey(id string) string {
+	return id + "/meta.json"
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate artifact id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// validID checks the id is generated by newID, it prevents the path traversal in the store keys.
+func validID(id string) bool {
+	b, err := hex.DecodeString(id)
+	return err == nil && len(b) == 16
+}
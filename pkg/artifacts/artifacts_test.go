@@ -0,0 +1,196 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+)
+
+func testRecords(t *testing.T) (*arrow.Schema, []arrow.RecordBatch) {
+	t.Helper()
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "geom", Type: arrow.BinaryTypes.Binary, Nullable: true},
+	}, nil)
+	b := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer b.Release()
+	point, err := wkb.Marshal(orb.Point{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2}, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues([]string{"a,b", ""}, []bool{true, false})
+	b.Field(2).(*array.BinaryBuilder).AppendValues([][]byte{point, nil}, []bool{true, false})
+	rec := b.NewRecordBatch()
+	t.Cleanup(rec.Release)
+	return schema, []arrow.RecordBatch{rec}
+}
+
+func TestCreate(t *testing.T) {
+	schema, records := testRecords(t)
+	s := NewWithStore(NewLocalStore(t.TempDir()))
+	ctx := context.Background()
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{format: FormatCSV, want: "id,name,geom\n1,\"a,b\",0101000000000000000000f03f0000000000000040\n2,,\n"},
+		{format: FormatJSONL, want: `{"id":1,"name":"a,b","geom":"AQEAAAAAAAAAAADwPwAAAAAAAABA"}` + "\n" + `{"id":2,"name":null,"geom":null}` + "\n"},
+		{format: FormatGeoJSON, want: `{"type":"FeatureCollection","features":[` +
+			`{"type":"Feature","geometry":{"type":"Point","coordinates":[1,2]},"properties":{"id":1,"name":"a,b"}},` +
+			`{"type":"Feature","geometry":null,"properties":{"id":2,"name":null}}]}`},
+		{format: FormatParquet, want: "PAR1"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			a, err := s.Create(ctx, schema, records, CreateRequest{Format: tt.format, Query: "{ q }"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Rows != 2 || len(a.Columns) != 3 || a.Columns[1].Type != "utf8" {
+				t.Errorf("unexpected artifact: %+v", a)
+			}
+			info, r, err := s.Open(ctx, a.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Size != int64(len(data)) || info.Query != "{ q }" {
+				t.Errorf("unexpected metadata: %+v", info)
+			}
+			if tt.format == FormatParquet {
+				if !bytes.HasPrefix(data, []byte(tt.want)) || !bytes.HasSuffix(data, []byte(tt.want)) {
+					t.Error("not a parquet file")
+				}
+				return
+			}
+			if string(data) != tt.want {
+				t.Errorf("unexpected content:\n%s", data)
+			}
+		})
+	}
+
+	t.Run("geometry field", func(t *testing.T) {
+		_, err := s.Create(ctx, schema, records, CreateRequest{Format: FormatGeoJSON, GeometryField: "name"})
+		if err == nil {
+			t.Error("expected error for the non-geometry field")
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		if _, err := s.Info(ctx, "../meta"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+		if _, err := s.Info(ctx, strings.Repeat("0", 32)); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+	})
+}
+
+func TestExpiration(t *testing.T) {
+	schema, records := testRecords(t)
+	s := NewWithStore(NewLocalStore(t.TempDir()))
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	a, err := s.Create(ctx, schema, records, CreateRequest{Format: FormatCSV, UserID: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := s.Create(ctx, schema, records, CreateRequest{Format: FormatCSV})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := s.Info(ctx, a.ID); err != nil || info.UserID != "u1" || !info.ExpiresAt.Equal(a.ExpiresAt) {
+		t.Fatalf("unexpected artifact %+v: %v", info, err)
+	}
+
+	now = now.Add(defaultTTL + time.Minute)
+	if _, err := s.Info(ctx, a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired artifact, got %v", err)
+	}
+	if err := s.DeleteExpired(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.created) != 0 {
+		t.Errorf("the expired artifacts are not removed: %v", s.created)
+	}
+	if _, err := s.store.Get(ctx, metaKey(b.ID)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("the expired artifact is not deleted from the store: %v", err)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=key/") || r.Header.Get("x-amz-date") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = b
+		case http.MethodGet:
+			b, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(b)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	store, err := NewS3Store(S3Config{Endpoint: srv.URL, Bucket: "bucket", Prefix: "/mcp/", AccessKey: "key", SecretKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewWithStore(store)
+	ctx := context.Background()
+	schema, records := testRecords(t)
+	a, err := s.Create(ctx, schema, records, CreateRequest{Format: FormatCSV})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := objects["/bucket/mcp/"+a.ID+"/data.csv"]; !ok {
+		t.Errorf("object not stored, keys: %v", objects)
+	}
+	if _, r, err := s.Open(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	} else {
+		r.Close()
+	}
+	if err := s.Delete(ctx, a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Info(ctx, a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package artifacts
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// writeCSV writes the header and the rows, the nested values are written as JSON and the binary values as hex.
+func writeCSV(w io.Writer, schema *arrow.Schema, records []arrow.RecordBatch) error {
+	cw := csv.NewWriter(w)
+	header := make([]string, schema.NumFields())
+	for i, f := range schema.Fields() {
+		header[i] = f.Name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	row := make([]string, schema.NumFields())
+	for _, rec := range records {
+		for i := range int(rec.NumRows()) {
+			for j, col := range rec.Columns() {
+				v, err := csvValue(col.GetOneForMarshal(i))
+				if err != nil {
+					return fmt.Errorf("column %s: %w", schema.Field(j).Name, err)
+				}
+				row[j] = v
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.RawMessage:
+		return string(v), nil
+	case []byte:
+		return hex.EncodeToString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64, int, uint:
+		return fmt.Sprint(v), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// writeJSONL writes a JSON object per row, the fields keep the schema order.
+func writeJSONL(w io.Writer, schema *arrow.Schema, records []arrow.RecordBatch) error {
+	bw := bufio.NewWriter(w)
+	for _, rec := range records {
+		for i := range int(rec.NumRows()) {
+			if err := writeJSONObject(bw, schema, rec, i, -1); err != nil {
+				return err
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// writeJSONObject writes the row as the JSON object, the column skip is omitted.
+func writeJSONObject(w *bufio.Writer, schema *arrow.Schema, rec arrow.RecordBatch, i, skip int) error {
+	w.WriteByte('{')
+	first := true
+	for j, col := range rec.Columns() {
+		if j == skip {
+			continue
+		}
+		if !first {
+			w.WriteByte(',')
+		}
+		first = false
+		name, _ := json.Marshal(schema.Field(j).Name)
+		w.Write(name)
+		w.WriteByte(':')
+		b, err := json.Marshal(col.GetOneForMarshal(i))
+		if err != nil {
+			return fmt.Errorf("column %s: %w", schema.Field(j).Name, err)
+		}
+		w.Write(b)
+	}
+	w.WriteByte('}')
+	return nil
+}
+
+func writeParquet(w io.Writer, schema *arrow.Schema, records []arrow.RecordBatch) error {
+	// the parquet writer closes the sink if it is a closer, the caller owns the file
+	fw, err := pqarrow.NewFileWriter(schema, struct{ io.Writer }{w},
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy)),
+		pqarrow.DefaultWriterProps(),
+	)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if err := fw.Write(rec); err != nil {
+			fw.Close()
+			return err
+		}
+	}
+	return fw.Close()
+}
+
+// writeGeoJSON writes the feature collection, the geometry field values (WKB, WKT or GeoJSON)
+// are the feature geometries and the other fields are the feature properties.
+// It returns the geometry field name, the field is detected if it is not set.
+func writeGeoJSON(w io.Writer, schema *arrow.Schema, records []arrow.RecordBatch, field string) (string, error) {
+	gi, err := geometryField(schema, records, field)
+	if err != nil {
+		return "", err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{"type":"FeatureCollection","features":[`)
+	first := true
+	for _, rec := range records {
+		col := rec.Column(gi)
+		for i := range int(rec.NumRows()) {
+			geom, err := geoJSONGeometry(col.GetOneForMarshal(i))
+			if err != nil {
+				return "", fmt.Errorf("geometry field %s: %w", schema.Field(gi).Name, err)
+			}
+			if !first {
+				bw.WriteByte(',')
+			}
+			first = false
+			bw.WriteString(`{"type":"Feature","geometry":`)
+			bw.Write(geom)
+			bw.WriteString(`,"properties":`)
+			if err := writeJSONObject(bw, schema, rec, i, gi); err != nil {
+				return "", err
+			}
+			bw.WriteByte('}')
+		}
+	}
+	bw.WriteString("]}")
+	return schema.Field(gi).Name, bw.Flush()
+}
+
+// geometryField returns the index of the geometry field: the named field, the geoarrow extension field,
+// the first binary field or the first string field with the geometry values.
+func geometryField(schema *arrow.Schema, records []arrow.RecordBatch, name string) (int, error) {
+	if name != "" {
+		idx := schema.FieldIndices(name)
+		if len(idx) == 0 {
+			return 0, fmt.Errorf("geometry field %q not found", name)
+		}
+		return idx[0], nil
+	}
+	for i, f := range schema.Fields() {
+		if ext, ok := f.Metadata.GetValue("ARROW:extension:name"); ok && strings.HasPrefix(ext, "geoarrow.") {
+			return i, nil
+		}
+	}
+	for i, f := range schema.Fields() {
+		if f.Type.ID() == arrow.BINARY || f.Type.ID() == arrow.LARGE_BINARY {
+			return i, nil
+		}
+	}
+	for i, f := range schema.Fields() {
+		if f.Type.ID() != arrow.STRING && f.Type.ID() != arrow.LARGE_STRING {
+			continue
+		}
+		for _, rec := range records {
+			if v := firstValue(rec.Column(i)); v != nil {
+				if _, err := geoJSONGeometry(v); err == nil {
+					return i, nil
+				}
+				break
+			}
+		}
+	}
+	return 0, fmt.Errorf("geometry field is not found, set it explicitly")
+}
+
+func firstValue(col arrow.Array) any {
+	for i := range col.Len() {
+		if col.IsValid(i) {
+			return col.GetOneForMarshal(i)
+		}
+	}
+	return nil
+}
+
+func geoJSONGeometry(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return []byte("null"), nil
+	case json.RawMessage:
+		return checkGeoJSON(v)
+	case []byte:
+		g, err := wkb.Unmarshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(geojson.NewGeometry(g))
+	case string:
+		if strings.HasPrefix(strings.TrimSpace(v), "{") {
+			return checkGeoJSON([]byte(v))
+		}
+		g, err := wkt.Unmarshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(geojson.NewGeometry(g))
+	}
+	return nil, fmt.Errorf("unsupported geometry value type %T", v)
+}
+
+func checkGeoJSON(b []byte) ([]byte, error) {
+	if _, err := geojson.UnmarshalGeometry(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
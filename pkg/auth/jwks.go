@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrKeyNotFound = errors.New("signing key not found")
+	// ErrKeysUnavailable is returned if the key set can't be loaded, the token can't be validated
+	ErrKeysUnavailable = errors.New("signing keys unavailable")
+)
+
+const (
+	// minimal interval between JWKS refreshes triggered by unknown key ids or failed refreshes
+	jwksMinRefreshInterval = 30 * time.Second
+	// the key set is loaded in the background of the requests that wait for it
+	jwksFetchTimeout = 30 * time.Second
+)
+
+// JWKS is a cached JSON Web Key Set, loaded from a URL or a local file.
+// The key set is loaded once for the concurrent requests (outside of the lock),
+// the last loaded key set is kept if the refresh fails.
+type JWKS struct {
+	url  string
+	file string
+	ttl  time.Duration
+	c    *http.Client
+
+	sf        singleflight.Group
+	mu        sync.RWMutex
+	keys      map[string]any
+	fetchedAt time.Time // the last successful load
+	triedAt   time.Time // the last load attempt
+}
+
+func NewJWKS(url, file string, ttl time.Duration, c *http.Client) *JWKS {
+	if c == nil {
+		c = http.DefaultClient
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &JWKS{
+		url:  url,
+		file: file,
+		ttl:  ttl,
+		c:    c,
+	}
+}
+
+// Key returns the public key by the key id, the key set is reloaded if it is expired or the key is unknown.
+func (k *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	k.mu.RLock()
+	loaded, expired := k.keys != nil, time.Since(k.fetchedAt) > k.ttl
+	k.mu.RUnlock()
+
+	if !loaded || expired && k.canRefresh() {
+		// the expired keys are used until the new key set is loaded
+		if err := k.refresh(ctx); err != nil && !loaded {
+			return nil, err
+		}
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	// keys rotation
+	if !k.canRefresh() {
+		return nil, ErrKeyNotFound
+	}
+	if err := k.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrKeyNotFound
+}
+
+// canRefresh limits the refreshes of the loaded key set.
+func (k *JWKS) canRefresh() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return time.Since(k.triedAt) >= jwksMinRefreshInterval
+}
+
+func (k *JWKS) lookup(kid string) (any, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if kid != "" {
+		key, ok := k.keys[kid]
+		return key, ok
+	}
+	// tokens without kid are allowed only for the single key set
+	if len(k.keys) != 1 {
+		return nil, false
+	}
+	for _, key := range k.keys {
+		return key, true
+	}
+	return nil, false
+}
+
+// refresh loads the key set, the concurrent calls wait for the same load.
+func (k *JWKS) refresh(ctx context.Context) error {
+	ch := k.sf.DoChan("keys", func() (any, error) {
+		k.mu.Lock()
+		k.triedAt = time.Now()
+		k.mu.Unlock()
+		// the load is not canceled with the request that started it
+		lctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jwksFetchTimeout)
+		defer cancel()
+		keys, err := k.load(lctx)
+		if err != nil {
+			return nil, err
+		}
+		k.mu.Lock()
+		k.keys = keys
+		k.fetchedAt = time.Now()
+		k.mu.Unlock()
+		return nil, nil
+	})
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", ErrKeysUnavailable, ctx.Err())
+	case res := <-ch:
+		return res.Err
+	}
+}
+
+func (k *JWKS) load(ctx context.Context) (map[string]any, error) {
+	var data []byte
+	var err error
+	switch {
+	case k.file != "":
+		data, err = os.ReadFile(k.file)
+	case k.url != "":
+		data, err = k.fetch(ctx)
+	default:
+		return nil, fmt.Errorf("%w: jwks source is not configured", ErrKeysUnavailable)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: load jwks: %w", ErrKeysUnavailable, err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKeysUnavailable, err)
+	}
+	return keys, nil
+}
+
+func (k *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	var data json.RawMessage
+	if err := getJSON(ctx, k.c, k.url, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS parses JSON Web Key Set and returns public signing keys by their key id.
+func ParseJWKS(data []byte) (map[string]any, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("parse jwk %q: %w", jwk.Kid, err)
+		}
+		if key == nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (jwk *jsonWebKey) publicKey() (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBase64URL(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBase64URL(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBase64URL(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		// skip unsupported key types (e.g. symmetric keys)
+		return nil, nil
+	}
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+func getJSON(ctx context.Context, c *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("get %s: unexpected status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
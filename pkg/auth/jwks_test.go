@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestJWKSRefresh(t *testing.T) {
+	iss := newTestIssuer(t)
+	var requests atomic.Int32
+	var failing atomic.Bool
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write(iss.jwks())
+	}))
+	t.Cleanup(srv.Close)
+	jwks := NewJWKS(srv.URL, "", time.Hour, nil)
+	ctx := context.Background()
+
+	// the concurrent requests wait for the single load
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := jwks.Key(ctx, iss.kid)
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("key: %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("the key set is loaded %d times, want 1", n)
+	}
+
+	// the expired key set is kept if the refresh fails
+	failing.Store(true)
+	jwks.mu.Lock()
+	jwks.fetchedAt = time.Now().Add(-2 * time.Hour)
+	jwks.triedAt = jwks.fetchedAt
+	jwks.mu.Unlock()
+	if _, err := jwks.Key(ctx, iss.kid); err != nil {
+		t.Errorf("the last key set is not used: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("the key set is loaded %d times, want 2", n)
+	}
+	// the failed refresh is not repeated for each request
+	if _, err := jwks.Key(ctx, iss.kid); err != nil || requests.Load() != 2 {
+		t.Errorf("unexpected refresh: %v", err)
+	}
+}
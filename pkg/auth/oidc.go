@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoToken      = errors.New("authentication required")
+	ErrInvalidToken = errors.New("invalid token")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// OIDCConfig configures JWT validation of the incoming requests.
+type OIDCConfig struct {
+	Issuer   string
+	Audience string
+	// JWKSURL overrides the jwks_uri from the issuer discovery document
+	JWKSURL string
+	// JWKSFile is a local JWKS file (used instead of the issuer keys)
+	JWKSFile string
+	CacheTTL time.Duration
+	Leeway   time.Duration
+	Timeout  time.Duration
+
+	// Claims that contain user info, the same as hugr auth config (see lde/auth-config.yml)
+	Claims ClaimsConfig
+	// AllowedRoles restricts access to the users with these roles (empty - any role)
+	AllowedRoles []string
+}
+
+type ClaimsConfig struct {
+	Role     string
+	UserID   string
+	UserName string
+}
+
+func (c *OIDCConfig) Enabled() bool {
+	return c.Issuer != "" || c.JWKSURL != "" || c.JWKSFile != ""
+}
+
+// UserInfo is the authenticated user of the request.
+type UserInfo struct {
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Token    string `json:"-"`
+}
+
+type userInfoCtxKey string
+
+const userInfoCtxKeyName userInfoCtxKey = "hugr-user-info"
+
+func CtxWithUserInfo(ctx context.Context, info *UserInfo) context.Context {
+	return context.WithValue(ctx, userInfoCtxKeyName, info)
+}
+
+func UserInfoFromCtx(ctx context.Context) *UserInfo {
+	info, _ := ctx.Value(userInfoCtxKeyName).(*UserInfo)
+	return info
+}
+
+// OIDC validates bearer tokens issued by the OIDC provider.
+type OIDC struct {
+	c      OIDCConfig
+	client *http.Client
+	parser *jwt.Parser
+
+	mu   sync.Mutex
+	jwks *JWKS
+}
+
+func NewOIDC(config OIDCConfig) (*OIDC, error) {
+	if !config.Enabled() {
+		return nil, errors.New("oidc: issuer or jwks source is required")
+	}
+	if config.Claims.Role == "" {
+		config.Claims.Role = "x-hugr-role"
+	}
+	if config.Claims.UserID == "" {
+		config.Claims.UserID = "sub"
+	}
+	if config.Claims.UserName == "" {
+		config.Claims.UserName = "name"
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(config.Audience))
+	}
+	return &OIDC{
+		c:      config,
+		client: &http.Client{Timeout: config.Timeout},
+		parser: jwt.NewParser(opts...),
+	}, nil
+}
+
+// keys returns the key set, the issuer discovery document is requested until it succeeds.
+func (o *OIDC) keys(ctx context.Context) (*JWKS, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.jwks != nil {
+		return o.jwks, nil
+	}
+	url := o.c.JWKSURL
+	if url == "" && o.c.JWKSFile == "" {
+		var err error
+		url, err = o.discoverJWKSURL(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	o.jwks = NewJWKS(url, o.c.JWKSFile, o.c.CacheTTL, o.client)
+	return o.jwks, nil
+}
+
+func (o *OIDC) discoverJWKSURL(ctx context.Context) (string, error) {
+	var doc struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	url := strings.TrimSuffix(o.c.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, o.client, url, &doc); err != nil {
+		return "", fmt.Errorf("oidc discovery: %w", err)
+	}
+	if doc.JWKSURI == "" {
+		return "", errors.New("oidc discovery: jwks_uri is not provided")
+	}
+	return doc.JWKSURI, nil
+}
+
+// Authenticate validates the request bearer token and returns the user info.
+func (o *OIDC) Authenticate(r *http.Request) (*UserInfo, error) {
+	raw, ok := TokenFromRequest(r)
+	if !ok {
+		return nil, ErrNoToken
+	}
+	jwks, err := o.keys(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	_, err = o.parser.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return jwks.Key(r.Context(), kid)
+	})
+	if errors.Is(err, ErrKeysUnavailable) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Join(ErrInvalidToken, err)
+	}
+
+	info := &UserInfo{
+		UserID:   claimString(claims, o.c.Claims.UserID),
+		UserName: claimString(claims, o.c.Claims.UserName),
+		Role:     o.claimRole(claims),
+		Token:    raw,
+	}
+	if len(o.c.AllowedRoles) != 0 && !slices.Contains(o.c.AllowedRoles, info.Role) {
+		return nil, ErrForbidden
+	}
+	return info, nil
+}
+
+// claimRole returns the role from the role claim, the claim can be a string or a list of roles.
+func (o *OIDC) claimRole(claims jwt.MapClaims) string {
+	switch v := claimValue(claims, o.c.Claims.Role).(type) {
+	case string:
+		return v
+	case []any:
+		var first string
+		for _, r := range v {
+			role, ok := r.(string)
+			if !ok {
+				continue
+			}
+			if len(o.c.AllowedRoles) == 0 || slices.Contains(o.c.AllowedRoles, role) {
+				return role
+			}
+			if first == "" {
+				first = role
+			}
+		}
+		return first
+	}
+	return ""
+}
+
+// claimValue returns the claim value by the name, nested claims can be addressed by dot (e.g. realm_access.roles).
+func claimValue(claims map[string]any, name string) any {
+	if v, ok := claims[name]; ok {
+		return v
+	}
+	head, tail, ok := strings.Cut(name, ".")
+	if !ok {
+		return nil
+	}
+	nested, ok := claims[head].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return claimValue(nested, tail)
+}
+
+func claimString(claims map[string]any, name string) string {
+	v, _ := claimValue(claims, name).(string)
+	return v
+}
+
+// Middleware rejects unauthenticated requests and puts the user info and token into the request context.
+func (o *OIDC) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, err := o.Authenticate(r)
+		if err != nil {
+			WriteAuthError(w, err)
+			return
+		}
+		ctx := CtxWithUserInfo(r.Context(), info)
+		ctx = CtxWithToken(ctx, info.Token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WriteAuthError writes 401 or 403 response for the authentication error, 503 if the signing keys can't be loaded.
+func WriteAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrForbidden):
+		http.Error(w, "forbidden", http.StatusForbidden)
+	case errors.Is(err, ErrNoToken):
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, ErrInvalidToken):
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	default:
+		// the keys can't be loaded
+		http.Error(w, "authentication unavailable", http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testIssuer struct {
+	key *rsa.PrivateKey
+	kid string
+	srv *httptest.Server
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	iss := &testIssuer{key: key, kid: "test-key"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   iss.srv.URL,
+			"jwks_uri": iss.srv.URL + "/certs",
+		})
+	})
+	mux.HandleFunc("/certs", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(iss.jwks())
+	})
+	iss.srv = httptest.NewServer(mux)
+	t.Cleanup(iss.srv.Close)
+	return iss
+}
+
+func (iss *testIssuer) jwks() []byte {
+	enc := base64.RawURLEncoding
+	data, _ := json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kid": iss.kid,
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   enc.EncodeToString(iss.key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(iss.key.E)).Bytes()),
+		}},
+	})
+	return data
+}
+
+func (iss *testIssuer) token(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = iss.kid
+	s, err := token.SignedString(iss.key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return s
+}
+
+func (iss *testIssuer) claims(role any) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":         iss.srv.URL,
+		"aud":         "hugr-mcp",
+		"sub":         "user-1",
+		"name":        "Test User",
+		"exp":         time.Now().Add(time.Hour).Unix(),
+		"x-hugr-role": role,
+	}
+}
+
+func TestOIDCAuthenticate(t *testing.T) {
+	iss := newTestIssuer(t)
+	oidc, err := NewOIDC(OIDCConfig{
+		Issuer:       iss.srv.URL,
+		Audience:     "hugr-mcp",
+		AllowedRoles: []string{"admin", "analyst"},
+	})
+	if err != nil {
+		t.Fatalf("new oidc: %v", err)
+	}
+
+	expired := iss.claims("analyst")
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	wrongAud := iss.claims("analyst")
+	wrongAud["aud"] = "other"
+	wrongIss := iss.claims("analyst")
+	wrongIss["iss"] = "http://other"
+
+	tests := []struct {
+		name     string
+		header   string
+		wantErr  error
+		wantRole string
+	}{
+		{name: "valid", header: "Bearer " + iss.token(t, iss.claims("analyst")), wantRole: "analyst"},
+		{name: "role list", header: "Bearer " + iss.token(t, iss.claims([]any{"viewer", "admin"})), wantRole: "admin"},
+		{name: "no token", wantErr: ErrNoToken},
+		{name: "malformed", header: "Bearer abc", wantErr: ErrInvalidToken},
+		{name: "expired", header: "Bearer " + iss.token(t, expired), wantErr: ErrInvalidToken},
+		{name: "wrong audience", header: "Bearer " + iss.token(t, wrongAud), wantErr: ErrInvalidToken},
+		{name: "wrong issuer", header: "Bearer " + iss.token(t, wrongIss), wantErr: ErrInvalidToken},
+		{name: "forbidden role", header: "Bearer " + iss.token(t, iss.claims("viewer")), wantErr: ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			info, err := oidc.Authenticate(req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+			if info.Role != tt.wantRole || info.UserID != "user-1" || info.UserName != "Test User" {
+				t.Errorf("user info = %+v", info)
+			}
+		})
+	}
+}
+
+func TestOIDCJWKSFile(t *testing.T) {
+	iss := newTestIssuer(t)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, iss.jwks(), 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+	oidc, err := NewOIDC(OIDCConfig{
+		JWKSFile: file,
+		Claims:   ClaimsConfig{Role: "realm_access.role"},
+	})
+	if err != nil {
+		t.Fatalf("new oidc: %v", err)
+	}
+	claims := iss.claims(nil)
+	claims["realm_access"] = map[string]any{"role": "analyst"}
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+iss.token(t, claims))
+	info, err := oidc.Authenticate(req)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if info.Role != "analyst" {
+		t.Errorf("role = %q, want analyst", info.Role)
+	}
+}
+
+func TestOIDCMiddleware(t *testing.T) {
+	iss := newTestIssuer(t)
+	oidc, err := NewOIDC(OIDCConfig{Issuer: iss.srv.URL, AllowedRoles: []string{"analyst"}})
+	if err != nil {
+		t.Fatalf("new oidc: %v", err)
+	}
+	h := oidc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := UserInfoFromCtx(r.Context())
+		if token, _ := tokenFromCtx(r.Context()); info == nil || token != info.Token {
+			t.Errorf("user info is not passed to the context")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "ok", header: "Bearer " + iss.token(t, iss.claims("analyst")), want: http.StatusOK},
+		{name: "no token", want: http.StatusUnauthorized},
+		{name: "invalid", header: "Bearer abc", want: http.StatusUnauthorized},
+		{name: "forbidden", header: "Bearer " + iss.token(t, iss.claims("viewer")), want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("WWW-Authenticate header is not set")
+			}
+		})
+	}
+}
+
+func TestOIDCKeysUnavailable(t *testing.T) {
+	iss := newTestIssuer(t)
+	certs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusBadGateway)
+	}))
+	t.Cleanup(certs.Close)
+	oidc, err := NewOIDC(OIDCConfig{Issuer: iss.srv.URL, JWKSURL: certs.URL})
+	if err != nil {
+		t.Fatalf("new oidc: %v", err)
+	}
+	h := oidc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the request is passed without the keys")
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{name: "valid", header: "Bearer " + iss.token(t, iss.claims("analyst")), want: http.StatusServiceUnavailable},
+		// the malformed token is rejected before the keys are loaded
+		{name: "malformed", header: "Bearer abc", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			req.Header.Set("Authorization", tt.header)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"net/http"
+	"strings"
 )
 
 type tokenCtxKey string
@@ -12,8 +13,10 @@ const (
 )
 
 type HugrTransport struct {
-	keyHeader string
-	c         http.RoundTripper
+	keyHeader  string
+	headers    map[string]string
+	privileged bool
+	c          http.RoundTripper
 }
 
 type Option func(*HugrTransport)
@@ -24,6 +27,20 @@ func WithSecretHeaderName(name string) Option {
 	}
 }
 
+// WithHeaders adds the static headers to each request.
+func WithHeaders(headers map[string]string) Option {
+	return func(h *HugrTransport) {
+		h.headers = headers
+	}
+}
+
+// WithPrivileged disables the user token propagation, requests are always sent with the api key.
+func WithPrivileged() Option {
+	return func(h *HugrTransport) {
+		h.privileged = true
+	}
+}
+
 func WithBaseTransport(c http.RoundTripper) Option {
 	return func(h *HugrTransport) {
 		h.c = c
@@ -52,6 +69,19 @@ func CtxWithAdmin(ctx context.Context) context.Context {
 	return context.WithValue(ctx, tokenCtxKeyName, nil)
 }
 
+// TokenFromRequest returns the bearer token from the request Authorization header.
+func TokenFromRequest(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(h[7:])
+	if token == "" {
+		return "", false
+	}
+	return token, true
+}
+
 func tokenFromCtx(ctx context.Context) (string, bool) {
 	token := ctx.Value(tokenCtxKeyName)
 	if token == nil {
@@ -64,6 +94,12 @@ func tokenFromCtx(ctx context.Context) (string, bool) {
 }
 
 func (t *HugrTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	if t.privileged {
+		return t.c.RoundTrip(req)
+	}
 	if token, ok := tokenFromCtx(req.Context()); ok {
 		req.Header.Del(t.keyHeader)
 		req.Header.Set("Authorization", "Bearer "+token)
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestHugrTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		ctx       func(ctx context.Context) context.Context
+		wantKey   string
+		wantToken string
+	}{
+		{
+			name:    "no token",
+			ctx:     func(ctx context.Context) context.Context { return ctx },
+			wantKey: "secret",
+		},
+		{
+			name:      "user token",
+			ctx:       func(ctx context.Context) context.Context { return CtxWithToken(ctx, "user-token") },
+			wantToken: "Bearer user-token",
+		},
+		{
+			name: "admin overrides user token",
+			ctx: func(ctx context.Context) context.Context {
+				return CtxWithAdmin(CtxWithToken(ctx, "user-token"))
+			},
+			wantKey: "secret",
+		},
+		{
+			name:    "privileged ignores user token",
+			opts:    []Option{WithPrivileged()},
+			ctx:     func(ctx context.Context) context.Context { return CtxWithToken(ctx, "user-token") },
+			wantKey: "secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			tr := New(append([]Option{
+				WithSecretHeaderName("x-hugr-secret"),
+				WithHeaders(map[string]string{"x-hugr-client": "mcp"}),
+				WithBaseTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					got = req
+					return &http.Response{StatusCode: http.StatusOK}, nil
+				})),
+			}, tt.opts...)...)
+			req := httptest.NewRequestWithContext(tt.ctx(t.Context()), http.MethodPost, "http://hugr/ipc", nil)
+			req.Header.Set("x-hugr-secret", "secret")
+			if _, err := tr.RoundTrip(req); err != nil {
+				t.Fatalf("round trip: %v", err)
+			}
+			if v := got.Header.Get("x-hugr-secret"); v != tt.wantKey {
+				t.Errorf("secret header = %q, want %q", v, tt.wantKey)
+			}
+			if v := got.Header.Get("Authorization"); v != tt.wantToken {
+				t.Errorf("authorization header = %q, want %q", v, tt.wantToken)
+			}
+			if v := got.Header.Get("x-hugr-client"); v != "mcp" {
+				t.Errorf("custom header = %q, want mcp", v)
+			}
+		})
+	}
+}
+
+func TestTokenFromRequest(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+		ok     bool
+	}{
+		{header: "", ok: false},
+		{header: "Basic abc", ok: false},
+		{header: "Bearer ", ok: false},
+		{header: "Bearer abc", want: "abc", ok: true},
+		{header: "bearer abc ", want: "abc", ok: true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		got, ok := TokenFromRequest(req)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("TokenFromRequest(%q) = %q, %v, want %q, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -3,6 +3,10 @@ package indexer
 import (
 	"context"
 	"fmt"
+	"strings"
+
+	"github.com/hugr-lab/query-engine/pkg/compiler/base"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
 )
 
 // DataObjectQueriesInfo information about a data object queries
@@ -117,3 +121,365 @@ func (s *Service) DataObjectFieldType(ctx context.Context, objectName, fieldName
 
 	return info.Type.Fields[0].Type, nil
 }
+
+// TypeFields returns the indexed fields (or input fields) of the type with the field type kind.
+func (s *Service) TypeFields(ctx context.Context, typeName string) ([]Field, error) {
+	var fields []Field
+	err := s.queryOne(ctx, `query ($name: String!, $ttl: Int!) {
+		core {
+			mcp {
+				fields(filter: {type_name: {eq: $name}}) @cache(ttl: $ttl) {
+					name
+					type_name
+					type
+					hugr_type
+					is_list
+					is_non_null
+					is_primary_key
+					mcp_exclude
+					field_type {
+						name
+						kind
+					}
+				}
+			}
+		}
+	}`, map[string]any{
+		"name": typeName,
+		"ttl":  s.c.ttl,
+	}, "core.mcp.fields", &fields)
+	if err != nil {
+		return nil, fmt.Errorf("query type %q fields: %w", typeName, err)
+	}
+	return fields, nil
+}
+
+// DataObjectMutationsInfo information about the data object mutations and the data source write access
+type DataObjectMutationsInfo struct {
+	Name             string              `json:"name"`
+	Module           string              `json:"module"`
+	FilterType       string              `json:"filter_type"`
+	DataSource       string              `json:"data_source"`
+	ReadOnly         bool                `json:"read_only"`
+	AggregationQuery string              `json:"aggregation_query,omitempty"`
+	Insert           *DataObjectMutation `json:"insert,omitempty"`
+	Update           *DataObjectMutation `json:"update,omitempty"`
+	Delete           *DataObjectMutation `json:"delete,omitempty"`
+}
+
+type DataObjectMutation struct {
+	Name       string     `json:"name"`
+	ResultType string     `json:"result_type"`
+	Arguments  []Argument `json:"arguments,omitempty"`
+}
+
+// Argument returns the mutation argument by name.
+func (m *DataObjectMutation) Argument(name string) (Argument, bool) {
+	for _, a := range m.Arguments {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Argument{}, false
+}
+
+// DataObjectMutationsInfo returns the insert, update and delete mutations of the data object from the module mutation root.
+func (s *Service) DataObjectMutationsInfo(ctx context.Context, objectName string) (*DataObjectMutationsInfo, error) {
+	var obj *struct {
+		Name       string `json:"name"`
+		FilterType string `json:"filter_type_name"`
+		Type       struct {
+			Module     string `json:"module"`
+			DataSource *struct {
+				Name     string `json:"name"`
+				ReadOnly bool   `json:"read_only"`
+			} `json:"data_source"`
+		} `json:"type"`
+		Queries []DataObjectQueryInfo `json:"queries"`
+	}
+	err := s.queryOne(ctx, `query ($name: String!, $ttl: Int!) {
+		core {
+			mcp {
+				data_objects_by_pk(name: $name) @cache(ttl: $ttl) {
+					name
+					filter_type_name
+					type {
+						module
+						data_source {
+							name
+							read_only
+						}
+					}
+					queries {
+						name
+						query_type
+					}
+				}
+			}
+		}
+	}`, map[string]any{
+		"name": objectName,
+		"ttl":  s.c.ttl,
+	}, "core.mcp.data_objects_by_pk", &obj)
+	if err != nil {
+		return nil, fmt.Errorf("query data object info: %w", err)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("data object %q not found", objectName)
+	}
+	info := &DataObjectMutationsInfo{
+		Name:       obj.Name,
+		Module:     obj.Type.Module,
+		FilterType: obj.FilterType,
+	}
+	if ds := obj.Type.DataSource; ds != nil {
+		info.DataSource = ds.Name
+		info.ReadOnly = ds.ReadOnly
+	}
+	for _, q := range obj.Queries {
+		if q.Type == string(metainfo.QueryTypeAggregate) {
+			info.AggregationQuery = q.Name
+			break
+		}
+	}
+
+	var module *struct {
+		Mutation *struct {
+			Fields []struct {
+				Name      string     `json:"name"`
+				Type      string     `json:"type"`
+				HugrType  string     `json:"hugr_type"`
+				Arguments []Argument `json:"arguments"`
+			} `json:"fields"`
+		} `json:"mutation"`
+	}
+	err = s.queryOne(ctx, `query ($module: String!, $types: [String!], $ttl: Int!) {
+		core {
+			mcp {
+				modules_by_pk(name: $module) @cache(ttl: $ttl) {
+					mutation {
+						fields(filter: {hugr_type: {in: $types}}) {
+							name
+							type
+							hugr_type
+							arguments {
+								name
+								type
+								is_list
+								is_non_null
+							}
+						}
+					}
+				}
+			}
+		}
+	}`, map[string]any{
+		"module": info.Module,
+		"types": []string{
+			string(HugrFieldTypeMutationIns),
+			string(HugrFieldTypeMutationUpd),
+			string(HugrFieldTypeMutationDel),
+		},
+		"ttl": s.c.ttl,
+	}, "core.mcp.modules_by_pk", &module)
+	if err != nil {
+		return nil, fmt.Errorf("query data object mutations: %w", err)
+	}
+	if module == nil || module.Mutation == nil {
+		return info, nil
+	}
+	for _, f := range module.Mutation.Fields {
+		m := &DataObjectMutation{Name: f.Name, ResultType: f.Type, Arguments: f.Arguments}
+		filter, _ := m.Argument("filter")
+		data, _ := m.Argument("data")
+		switch HugrFieldType(f.HugrType) {
+		case HugrFieldTypeMutationIns:
+			// the insert mutation returns the inserted object
+			if f.Type == info.Name || data.Type == info.Name+"_mut_input_data" {
+				info.Insert = m
+			}
+		case HugrFieldTypeMutationUpd:
+			if info.FilterType != "" && filter.Type == info.FilterType {
+				info.Update = m
+			}
+		case HugrFieldTypeMutationDel:
+			if info.FilterType != "" && filter.Type == info.FilterType {
+				info.Delete = m
+			}
+		}
+	}
+	return info, nil
+}
+
+// DataObjectH3Query returns the field of the H3 data query that aggregates the data object,
+// it is empty if the data object can't be aggregated by the H3 cells.
+func (s *Service) DataObjectH3Query(ctx context.Context, objectName string) (string, error) {
+	var obj *struct {
+		Queries []struct {
+			Name  string `json:"name"`
+			Field *struct {
+				Type string `json:"type"`
+			} `json:"field"`
+		} `json:"queries"`
+	}
+	err := s.queryOne(ctx, `query ($name: String!, $type: String!, $ttl: Int!) {
+		core {
+			mcp {
+				data_objects_by_pk(name: $name) @cache(ttl: $ttl) {
+					queries(filter: {query_type: {eq: $type}}) {
+						name
+						field {
+							type
+						}
+					}
+				}
+			}
+		}
+	}`, map[string]any{
+		"name": objectName,
+		"type": string(metainfo.QueryTypeAggregate),
+		"ttl":  s.c.ttl,
+	}, "core.mcp.data_objects_by_pk", &obj)
+	if err != nil {
+		return "", fmt.Errorf("query data object aggregation: %w", err)
+	}
+	if obj == nil || len(obj.Queries) == 0 || obj.Queries[0].Field == nil {
+		return "", nil
+	}
+	agg := obj.Queries[0]
+
+	// the H3 data queries are the copies of the data object aggregation queries
+	var fields []struct {
+		Name string `json:"name"`
+	}
+	err = s.queryOne(ctx, `query ($type: String!, $ttl: Int!) {
+		core {
+			mcp {
+				fields(filter: {type_name: {eq: "_h3_data_query"}, type: {eq: $type}}) @cache(ttl: $ttl) {
+					name
+				}
+			}
+		}
+	}`, map[string]any{
+		"type": agg.Field.Type,
+		"ttl":  s.c.ttl,
+	}, "core.mcp.fields", &fields)
+	if err != nil {
+		return "", fmt.Errorf("query H3 data queries: %w", err)
+	}
+	for _, f := range fields {
+		if strings.HasSuffix(f.Name, agg.Name) {
+			return f.Name, nil
+		}
+	}
+	if len(fields) != 0 {
+		return fields[0].Name, nil
+	}
+	return "", nil
+}
+
+// DataObjectRelation is the reference or subquery field of the data object that selects the related data object.
+type DataObjectRelation struct {
+	Object        string
+	ObjectModule  string
+	ObjectCatalog string
+	Field         string
+	Target        string
+	TargetModule  string
+	TargetCatalog string
+	IsList        bool
+}
+
+// DataObjectRelations returns the reference, subquery and join fields between the data objects of all modules.
+// The declared join fields (@join) are selected by the field as the references, the join condition is
+// a part of the field definition, so the related objects are not joined by the _join query on the guessed fields.
+func (s *Service) DataObjectRelations(ctx context.Context) ([]DataObjectRelation, error) {
+	var fields []struct {
+		Name     string `json:"name"`
+		TypeName string `json:"type_name"`
+		Type     string `json:"type"`
+		IsList   bool   `json:"is_list"`
+		Exclude  bool   `json:"mcp_exclude"`
+		RootType struct {
+			Module  string `json:"module"`
+			Catalog string `json:"catalog"`
+		} `json:"root_type"`
+		FieldType struct {
+			Module  string `json:"module"`
+			Catalog string `json:"catalog"`
+		} `json:"field_type"`
+	}
+	err := s.queryOne(ctx, `query ($hugrTypes: [String!]!, $objectTypes: [String!]!, $ttl: Int!) {
+		core {
+			mcp {
+				fields(
+					filter: {
+						hugr_type: {in: $hugrTypes}
+						root_type: {hugr_type: {in: $objectTypes}}
+						field_type: {hugr_type: {in: $objectTypes}}
+					}
+					order_by: [{field: "type_name"}, {field: "name"}]
+				) @cache(ttl: $ttl) {
+					name
+					type_name
+					type
+					is_list
+					mcp_exclude
+					root_type { module catalog }
+					field_type { module catalog }
+				}
+			}
+		}
+	}`, map[string]any{
+		"hugrTypes":   []string{string(base.HugrTypeFieldSelect), string(base.HugrTypeFieldSelectOne), string(base.HugrTypeFieldJoin)},
+		"objectTypes": []string{string(base.HugrTypeTable), string(base.HugrTypeView)},
+		"ttl":         s.c.ttl,
+	}, "core.mcp.fields", &fields)
+	if err != nil {
+		return nil, fmt.Errorf("query data object relations: %w", err)
+	}
+	relations := make([]DataObjectRelation, 0, len(fields))
+	for _, f := range fields {
+		if f.Exclude {
+			continue
+		}
+		relations = append(relations, DataObjectRelation{
+			Object:        f.TypeName,
+			ObjectModule:  f.RootType.Module,
+			ObjectCatalog: f.RootType.Catalog,
+			Field:         f.Name,
+			Target:        f.Type,
+			TargetModule:  f.FieldType.Module,
+			TargetCatalog: f.FieldType.Catalog,
+			IsList:        f.IsList,
+		})
+	}
+	return relations, nil
+}
+
+// DataObjectJoinQuery returns the field of the _join query that selects the data object,
+// it is empty if the data object can't be joined.
+func (s *Service) DataObjectJoinQuery(ctx context.Context, objectName string) (string, error) {
+	var fields []struct {
+		Name string `json:"name"`
+	}
+	err := s.queryOne(ctx, `query ($type: String!, $ttl: Int!) {
+		core {
+			mcp {
+				fields(filter: {type_name: {eq: "_join"}, type: {eq: $type}, is_list: {eq: true}}) @cache(ttl: $ttl) {
+					name
+				}
+			}
+		}
+	}`, map[string]any{
+		"type": objectName,
+		"ttl":  s.c.ttl,
+	}, "core.mcp.fields", &fields)
+	if err != nil {
+		return "", fmt.Errorf("query join queries: %w", err)
+	}
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0].Name, nil
+}
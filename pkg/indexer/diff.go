@@ -0,0 +1,764 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+type DiffEntity string
+
+const (
+	DiffEntityType       DiffEntity = "type"
+	DiffEntityField      DiffEntity = "field"
+	DiffEntityArgument   DiffEntity = "argument"
+	DiffEntityModule     DiffEntity = "module"
+	DiffEntityDataSource DiffEntity = "data_source"
+)
+
+type DiffAction string
+
+const (
+	DiffActionAdded   DiffAction = "added"
+	DiffActionRemoved DiffAction = "removed"
+	DiffActionChanged DiffAction = "changed"
+)
+
+// Change is a difference between the hugr schema and the index.
+// The attributes are the changed definition attributes (for the changed entities only).
+type Change struct {
+	Entity     DiffEntity `json:"entity"`
+	Action     DiffAction `json:"action"`
+	Name       string     `json:"name"`
+	TypeName   string     `json:"type_name,omitempty"`
+	FieldName  string     `json:"field_name,omitempty"`
+	Attributes []string   `json:"attributes,omitempty"`
+
+	// the schema definition for the added and changed entities
+	typ        *Type
+	field      *Field
+	argument   *Argument
+	module     *Module
+	dataSource *DataSource
+}
+
+// SchemaDiff is the list of differences between the hugr schema and the index.
+type SchemaDiff struct {
+	Changes []Change                          `json:"changes"`
+	Summary map[DiffEntity]map[DiffAction]int `json:"summary"`
+}
+
+// IsEmpty returns true if the index is in sync with the schema.
+func (d *SchemaDiff) IsEmpty() bool {
+	return len(d.Changes) == 0
+}
+
+func (d *SchemaDiff) add(c Change) {
+	d.Changes = append(d.Changes, c)
+	if d.Summary == nil {
+		d.Summary = map[DiffEntity]map[DiffAction]int{}
+	}
+	if d.Summary[c.Entity] == nil {
+		d.Summary[c.Entity] = map[DiffAction]int{}
+	}
+	d.Summary[c.Entity][c.Action]++
+}
+
+// indexSnapshot is the current index content used to compare with the schema.
+type indexSnapshot struct {
+	Types       []Type       `json:"types"`
+	Fields      []Field      `json:"fields"`
+	Arguments   []Argument   `json:"arguments"`
+	Modules     []Module     `json:"modules"`
+	DataSources []DataSource `json:"data_sources"`
+}
+
+func (s *Service) fetchIndexSnapshot(ctx context.Context) (*indexSnapshot, error) {
+	var snapshot indexSnapshot
+	err := s.queryOne(ctx, `query {
+		core {
+			mcp {
+				types {
+					name
+					description
+					kind
+					hugr_type
+					catalog
+					module
+					is_summarized
+				}
+				fields {
+					name
+					type_name
+					description
+					type
+					hugr_type
+					catalog
+					is_list
+					is_non_null
+					mcp_exclude
+					is_summarized
+				}
+				arguments {
+					name
+					type_name
+					field_name
+					description
+					type
+					is_list
+					is_non_null
+				}
+				modules {
+					name
+					description
+					query_root
+					mutation_root
+					function_root
+					mut_function_root
+					is_summarized
+				}
+				data_sources {
+					name
+					description
+					type
+					prefix
+					as_module
+					read_only
+					is_summarized
+				}
+			}
+		}
+	}`, nil, "core.mcp", &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// Diff compares the hugr schema with the index and returns the differences.
+func (s *Service) Diff(ctx context.Context) (*SchemaDiff, error) {
+	diff, _, _, err := s.diff(ctx)
+	return diff, err
+}
+
+func (s *Service) diff(ctx context.Context) (*SchemaDiff, *SchemaIntro, *metainfo.SchemaInfo, error) {
+	schema, err := s.fetchSchema(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	meta, err := s.fetchSummary(ctx)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch meta summary: %w", err)
+	}
+	snapshot, err := s.fetchIndexSnapshot(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return diffSchema(schema, meta, snapshot), schema, meta, nil
+}
+
+// SyncSchema applies the schema differences to the index: the added and changed entities are merged,
+// the removed are deleted. The changed entities lose their summaries, if summarize is set they are summarized again.
+// Only one synchronization runs at a time, ErrSyncInProgress is returned otherwise.
+func (s *Service) SyncSchema(ctx context.Context, summarize bool) (*SchemaDiff, error) {
+	if !s.syncMu.TryLock() {
+		return nil, ErrSyncInProgress
+	}
+	defer s.syncMu.Unlock()
+	diff, schema, meta, err := s.diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if diff.IsEmpty() {
+		logf(ctx, "schema sync: index is up to date")
+		return diff, nil
+	}
+	err = s.ApplyDiff(ctx, schema, meta, diff)
+	if err != nil {
+		return nil, err
+	}
+	if summarize {
+		err = s.Summarize(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize changes: %w", err)
+		}
+	}
+	return diff, nil
+}
+
+// ApplyDiff applies the differences to the index.
+// The added and changed data sources, modules and functions are reloaded with their data objects and types.
+func (s *Service) ApplyDiff(ctx context.Context, schema *SchemaIntro, meta *metainfo.SchemaInfo, diff *SchemaDiff) error {
+	// data objects that should be reloaded (with changed fields) or removed
+	reload := map[string]struct{}{}
+	var merged, removed []Change
+	for _, c := range diff.Changes {
+		if c.Action == DiffActionRemoved {
+			removed = append(removed, c)
+			continue
+		}
+		merged = append(merged, c)
+	}
+	// the index tables have foreign keys to the types and fields,
+	// so merge data sources, types, modules, fields, arguments and remove in the reverse order
+	slices.SortStableFunc(merged, func(a, b Change) int {
+		return applyOrder[a.Entity] - applyOrder[b.Entity]
+	})
+	slices.SortStableFunc(removed, func(a, b Change) int {
+		return applyOrder[b.Entity] - applyOrder[a.Entity]
+	})
+	for _, c := range merged {
+		var err error
+		switch c.Entity {
+		case DiffEntityDataSource:
+			err = s.mergeDataSource(ctx, *c.dataSource, true)
+		case DiffEntityModule:
+			err = s.mergeModule(ctx, *c.module, true)
+		case DiffEntityType:
+			err = s.mergeType(ctx, *c.typ, true)
+			reload[c.Name] = struct{}{}
+		case DiffEntityField:
+			err = s.mergeField(ctx, *c.field, true)
+			reload[c.TypeName] = struct{}{}
+		case DiffEntityArgument:
+			err = s.mergeArgument(ctx, *c.argument, true)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s %q: %w", c.Action, c.Entity, c.key(), err)
+		}
+	}
+	for _, c := range removed {
+		var err error
+		switch c.Entity {
+		case DiffEntityArgument:
+			err = s.deleteArgument(ctx, c.TypeName, c.FieldName, c.Name)
+		case DiffEntityField:
+			err = s.deleteFieldsAndArguments(ctx,
+				[]map[string]map[string]any{{"name": {"eq": c.Name}, "type_name": {"eq": c.TypeName}}},
+				[]map[string]map[string]any{{"field_name": {"eq": c.Name}, "type_name": {"eq": c.TypeName}}},
+			)
+		case DiffEntityType:
+			err = s.deleteDataObject(ctx, c.Name)
+			if err == nil {
+				err = s.deleteType(ctx, c.Name)
+			}
+		case DiffEntityModule:
+			err = s.deleteModule(ctx, c.Name)
+		case DiffEntityDataSource:
+			err = s.deleteDataSource(ctx, c.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply %s %s %q: %w", c.Action, c.Entity, c.key(), err)
+		}
+	}
+
+	// reload the added and changed data sources, modules and functions
+	reloads := diffReloads(meta, diff)
+	for _, name := range reloads.dataSources {
+		if err := s.loadDataSource(ctx, schema, meta, name); err != nil {
+			return fmt.Errorf("failed to reload data source %q: %w", name, err)
+		}
+	}
+	for _, name := range reloads.modules {
+		if err := s.loadModule(ctx, schema, meta, name); err != nil {
+			return fmt.Errorf("failed to reload module %q: %w", name, err)
+		}
+	}
+	for _, f := range reloads.functions {
+		if err := s.loadFunction(ctx, schema, meta, f.module, f.name, true); err != nil {
+			return fmt.Errorf("failed to reload function %s.%s: %w", f.module, f.name, err)
+		}
+	}
+
+	// the changed data objects are summarized again
+	var changed []string
+	for _, do := range meta.DataObjects() {
+		if _, ok := reload[do.Name]; !ok {
+			continue
+		}
+		changed = append(changed, do.Name)
+		object, err := newDataObject(meta, do)
+		if err != nil {
+			return err
+		}
+		if err := s.addDataObject(ctx, object); err != nil {
+			return fmt.Errorf("failed to add data object %q: %w", do.Name, err)
+		}
+	}
+	err := s.resetTypesSummary(ctx, changed)
+	if err != nil {
+		return err
+	}
+	logf(ctx, "schema sync: applied %d changes", len(diff.Changes))
+	return nil
+}
+
+func (s *Service) resetTypesSummary(ctx context.Context, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	res, err := s.h.Query(ctx, `mutation ($names: [String!]) {
+		core {
+			mcp {
+				update_types(
+					filter: { name: { in: $names } }
+					data: { is_summarized: false }
+				) {
+					success
+				}
+			}
+		}
+	}`, map[string]any{"names": names})
+	if err != nil {
+		return fmt.Errorf("failed to reset types summary: %w", err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return fmt.Errorf("failed to reset types summary: %w", res.Err())
+	}
+	return nil
+}
+
+// schemaReloads is the list of data sources, modules and functions to reload after the diff is applied.
+type schemaReloads struct {
+	dataSources []string
+	modules     []string
+	functions   []functionRef
+}
+
+type functionRef struct {
+	module string
+	name   string
+}
+
+// diffReloads returns the added and changed data sources, modules and functions of the diff.
+// The submodules of the reloaded modules and the functions of the reloaded modules and data sources are skipped,
+// they are reloaded with their parents. The root module is not reloaded (it is the whole schema),
+// its root types are applied by the type and field changes.
+func diffReloads(meta *metainfo.SchemaInfo, diff *SchemaDiff) schemaReloads {
+	var r schemaReloads
+	dataSources := map[string]struct{}{}
+	modules := map[string]struct{}{}
+	// the function root types of the modules
+	roots := map[string]string{}
+	for _, m := range meta.Modules() {
+		for _, t := range []string{m.FunctionType, m.MutationFunctionType} {
+			if t != "" {
+				roots[t] = m.Name
+			}
+		}
+	}
+	for _, c := range diff.Changes {
+		if c.Action == DiffActionRemoved {
+			continue
+		}
+		switch c.Entity {
+		case DiffEntityDataSource:
+			dataSources[c.Name] = struct{}{}
+		case DiffEntityModule:
+			if c.Name != "" {
+				modules[c.Name] = struct{}{}
+			}
+		}
+	}
+	inModules := func(name string) bool {
+		for m := range modules {
+			if name == m || strings.HasPrefix(name, m+".") {
+				return true
+			}
+		}
+		return false
+	}
+	r.dataSources = sortedKeys(dataSources)
+	for _, name := range sortedKeys(modules) {
+		if i := strings.LastIndex(name, "."); i != -1 && inModules(name[:i]) {
+			continue
+		}
+		r.modules = append(r.modules, name)
+	}
+
+	functions := map[functionRef]struct{}{}
+	for _, c := range diff.Changes {
+		if c.Action == DiffActionRemoved || (c.Entity != DiffEntityField && c.Entity != DiffEntityArgument) {
+			continue
+		}
+		module, ok := roots[c.TypeName]
+		if !ok || inModules(module) {
+			continue
+		}
+		name := c.Name
+		if c.Entity == DiffEntityArgument {
+			name = c.FieldName
+		}
+		mi := meta.Module(module)
+		fi := mi.Function(name)
+		if fi == nil {
+			fi = mi.MutationFunction(name)
+		}
+		// the submodule fields of the function root types are not functions
+		if fi == nil {
+			continue
+		}
+		if _, ok := dataSources[fi.DataSource]; ok {
+			continue
+		}
+		ref := functionRef{module: module, name: name}
+		if _, ok := functions[ref]; ok {
+			continue
+		}
+		functions[ref] = struct{}{}
+		r.functions = append(r.functions, ref)
+	}
+	return r
+}
+
+var applyOrder = map[DiffEntity]int{
+	DiffEntityDataSource: 0,
+	DiffEntityType:       1,
+	DiffEntityModule:     2,
+	DiffEntityField:      3,
+	DiffEntityArgument:   4,
+}
+
+func (c Change) key() string {
+	switch c.Entity {
+	case DiffEntityField:
+		return c.TypeName + "." + c.Name
+	case DiffEntityArgument:
+		return c.TypeName + "." + c.FieldName + "(" + c.Name + ")"
+	}
+	return c.Name
+}
+
+// diffSchema compares the schema and meta summary with the index snapshot.
+// The fields and arguments of the removed types are not reported, they are removed with the type.
+func diffSchema(schema *SchemaIntro, meta *metainfo.SchemaInfo, index *indexSnapshot) *SchemaDiff {
+	diff := &SchemaDiff{
+		Changes: []Change{},
+		Summary: map[DiffEntity]map[DiffAction]int{},
+	}
+
+	// data sources
+	dataSources := map[string]DataSource{}
+	for _, ds := range index.DataSources {
+		dataSources[ds.Name] = ds
+	}
+	for _, dsi := range meta.DataSources {
+		ds := newDataSource(dsi)
+		existing, ok := dataSources[ds.Name]
+		delete(dataSources, ds.Name)
+		if !ok {
+			diff.add(Change{Entity: DiffEntityDataSource, Action: DiffActionAdded, Name: ds.Name, dataSource: &ds})
+			continue
+		}
+		if attrs := dataSourceChanges(existing, ds); len(attrs) != 0 {
+			diff.add(Change{Entity: DiffEntityDataSource, Action: DiffActionChanged, Name: ds.Name, Attributes: attrs, dataSource: &ds})
+		}
+	}
+	for _, name := range sortedKeys(dataSources) {
+		diff.add(Change{Entity: DiffEntityDataSource, Action: DiffActionRemoved, Name: name})
+	}
+
+	// modules
+	modules := map[string]Module{}
+	for _, m := range index.Modules {
+		modules[m.Name] = m
+	}
+	for _, mi := range meta.Modules() {
+		m := newModule(mi)
+		existing, ok := modules[m.Name]
+		delete(modules, m.Name)
+		if !ok {
+			diff.add(Change{Entity: DiffEntityModule, Action: DiffActionAdded, Name: m.Name, module: &m})
+			continue
+		}
+		if attrs := moduleChanges(existing, m); len(attrs) != 0 {
+			diff.add(Change{Entity: DiffEntityModule, Action: DiffActionChanged, Name: m.Name, Attributes: attrs, module: &m})
+		}
+	}
+	for _, name := range sortedKeys(modules) {
+		diff.add(Change{Entity: DiffEntityModule, Action: DiffActionRemoved, Name: name})
+	}
+
+	// types, fields and arguments
+	types := map[string]Type{}
+	for _, t := range index.Types {
+		types[t.Name] = t
+	}
+	fields := map[string]Field{}
+	for _, f := range index.Fields {
+		fields[f.TypeName+"."+f.Name] = f
+	}
+	arguments := map[string]Argument{}
+	for _, a := range index.Arguments {
+		arguments[a.TypeName+"."+a.FieldName+"."+a.Name] = a
+	}
+	// the unknown type is added by the indexer
+	delete(types, "Unknown")
+	for _, st := range schema.Types {
+		t := newType(st)
+		existing, ok := types[t.Name]
+		delete(types, t.Name)
+		switch {
+		case !ok:
+			diff.add(Change{Entity: DiffEntityType, Action: DiffActionAdded, Name: t.Name, typ: &t})
+		default:
+			if attrs := typeChanges(existing, t); len(attrs) != 0 {
+				diff.add(Change{Entity: DiffEntityType, Action: DiffActionChanged, Name: t.Name, Attributes: attrs, typ: &t})
+			}
+		}
+		sf := st.Fields
+		if st.Kind == string(ast.InputObject) {
+			sf = st.InputFields
+		}
+		for _, fi := range sf {
+			f := newField(st.Name, fi)
+			key := f.TypeName + "." + f.Name
+			existing, ok := fields[key]
+			delete(fields, key)
+			switch {
+			case !ok:
+				diff.add(Change{Entity: DiffEntityField, Action: DiffActionAdded, Name: f.Name, TypeName: f.TypeName, field: &f})
+			default:
+				if attrs := fieldChanges(existing, f); len(attrs) != 0 {
+					diff.add(Change{Entity: DiffEntityField, Action: DiffActionChanged, Name: f.Name, TypeName: f.TypeName, Attributes: attrs, field: &f})
+				}
+			}
+			for _, ai := range fi.Args {
+				a := newArgument(st.Name, fi.Name, ai)
+				key := a.TypeName + "." + a.FieldName + "." + a.Name
+				existing, ok := arguments[key]
+				delete(arguments, key)
+				switch {
+				case !ok:
+					diff.add(Change{Entity: DiffEntityArgument, Action: DiffActionAdded, Name: a.Name, TypeName: a.TypeName, FieldName: a.FieldName, argument: &a})
+				default:
+					if attrs := argumentChanges(existing, a); len(attrs) != 0 {
+						diff.add(Change{Entity: DiffEntityArgument, Action: DiffActionChanged, Name: a.Name, TypeName: a.TypeName, FieldName: a.FieldName, Attributes: attrs, argument: &a})
+					}
+				}
+			}
+		}
+	}
+	for _, name := range sortedKeys(types) {
+		diff.add(Change{Entity: DiffEntityType, Action: DiffActionRemoved, Name: name})
+	}
+	for _, key := range sortedKeys(fields) {
+		f := fields[key]
+		if _, ok := types[f.TypeName]; ok {
+			continue
+		}
+		diff.add(Change{Entity: DiffEntityField, Action: DiffActionRemoved, Name: f.Name, TypeName: f.TypeName})
+	}
+	for _, key := range sortedKeys(arguments) {
+		a := arguments[key]
+		if _, ok := types[a.TypeName]; ok {
+			continue
+		}
+		if _, ok := fields[a.TypeName+"."+a.FieldName]; ok {
+			continue
+		}
+		diff.add(Change{Entity: DiffEntityArgument, Action: DiffActionRemoved, Name: a.Name, TypeName: a.TypeName, FieldName: a.FieldName})
+	}
+	return diff
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// The changes functions return the changed definition attributes,
+// the description is compared only if the entity is not summarized.
+
+func typeChanges(existing, t Type) []string {
+	var attrs []string
+	if existing.Kind != t.Kind {
+		attrs = append(attrs, "kind")
+	}
+	if existing.HugrType != t.HugrType {
+		attrs = append(attrs, "hugr_type")
+	}
+	if existing.Module != t.Module {
+		attrs = append(attrs, "module")
+	}
+	if existing.Catalog != t.Catalog {
+		attrs = append(attrs, "catalog")
+	}
+	if !existing.IsSummarized && existing.Description != t.Description {
+		attrs = append(attrs, "description")
+	}
+	return attrs
+}
+
+func fieldChanges(existing, f Field) []string {
+	var attrs []string
+	if existing.Type != f.Type {
+		attrs = append(attrs, "type")
+	}
+	if existing.HugrType != f.HugrType {
+		attrs = append(attrs, "hugr_type")
+	}
+	if existing.Catalog != f.Catalog {
+		attrs = append(attrs, "catalog")
+	}
+	if existing.IsList != f.IsList {
+		attrs = append(attrs, "is_list")
+	}
+	if existing.IsNotNull != f.IsNotNull {
+		attrs = append(attrs, "is_non_null")
+	}
+	if existing.Exclude != f.Exclude {
+		attrs = append(attrs, "mcp_exclude")
+	}
+	if !existing.IsSummarized && existing.Description != f.Description {
+		attrs = append(attrs, "description")
+	}
+	return attrs
+}
+
+// argumentChanges doesn't compare the description, arguments are summarized with their fields.
+func argumentChanges(existing, a Argument) []string {
+	var attrs []string
+	if existing.Type != a.Type {
+		attrs = append(attrs, "type")
+	}
+	if existing.IsList != a.IsList {
+		attrs = append(attrs, "is_list")
+	}
+	if existing.IsNotNull != a.IsNotNull {
+		attrs = append(attrs, "is_non_null")
+	}
+	return attrs
+}
+
+func moduleChanges(existing, m Module) []string {
+	var attrs []string
+	if existing.QueryRoot != m.QueryRoot {
+		attrs = append(attrs, "query_root")
+	}
+	if existing.MutationRoot != m.MutationRoot {
+		attrs = append(attrs, "mutation_root")
+	}
+	if existing.FunctionRoot != m.FunctionRoot {
+		attrs = append(attrs, "function_root")
+	}
+	if existing.MutFunctionRoot != m.MutFunctionRoot {
+		attrs = append(attrs, "mut_function_root")
+	}
+	if !existing.IsSummarized && existing.Description != m.Description {
+		attrs = append(attrs, "description")
+	}
+	return attrs
+}
+
+func dataSourceChanges(existing, ds DataSource) []string {
+	var attrs []string
+	if existing.Type != ds.Type {
+		attrs = append(attrs, "type")
+	}
+	if existing.Prefix != ds.Prefix {
+		attrs = append(attrs, "prefix")
+	}
+	if existing.AsModule != ds.AsModule {
+		attrs = append(attrs, "as_module")
+	}
+	if existing.ReadOnly != ds.ReadOnly {
+		attrs = append(attrs, "read_only")
+	}
+	if !existing.IsSummarized && existing.Description != ds.Description {
+		attrs = append(attrs, "description")
+	}
+	return attrs
+}
+
+// The new functions build the index entities from the schema introspection and meta summary.
+
+func newType(st TypeIntro) Type {
+	return Type{
+		Name:        st.Name,
+		Description: st.Description,
+		Kind:        st.Kind,
+		HugrType:    st.HugrType,
+		Module:      st.Module,
+		Catalog:     st.Catalog,
+	}
+}
+
+func newField(typeName string, f FieldIntro) Field {
+	return Field{
+		Name:        f.Name,
+		Description: f.Description,
+		TypeName:    typeName,
+		HugrType:    f.HugrType,
+		Catalog:     f.Catalog,
+		Exclude:     f.Exclude,
+		Type:        f.Type.TypeName(),
+		IsList:      f.Type.IsList(),
+		IsNotNull:   f.Type.IsNotNull(),
+	}
+}
+
+func newArgument(typeName, fieldName string, a ArgIntro) Argument {
+	return Argument{
+		Name:        a.Name,
+		FieldName:   fieldName,
+		TypeName:    typeName,
+		Description: a.Description,
+		Type:        a.Type.TypeName(),
+		IsList:      a.Type.IsList(),
+		IsNotNull:   a.Type.IsNotNull(),
+	}
+}
+
+func newModule(m *metainfo.ModuleInfo) Module {
+	return Module{
+		Name:            m.Name,
+		Description:     m.Description,
+		QueryRoot:       m.QueryType,
+		MutationRoot:    m.MutationType,
+		FunctionRoot:    m.FunctionType,
+		MutFunctionRoot: m.MutationFunctionType,
+	}
+}
+
+func newDataSource(ds metainfo.DataSourceInfo) DataSource {
+	return DataSource{
+		Name:        ds.Name,
+		Description: ds.Description,
+		Type:        ds.Type,
+		Prefix:      ds.Prefix,
+		AsModule:    ds.AsModule,
+		ReadOnly:    ds.ReadOnly,
+	}
+}
+
+func newDataObject(meta *metainfo.SchemaInfo, do *metainfo.DataObjectInfo) (DataObject, error) {
+	m := meta.Module(do.Module)
+	if m == nil || m.QueryType == "" {
+		return DataObject{}, fmt.Errorf("module %q not found for data object %q", do.Module, do.Name)
+	}
+	object := DataObject{
+		Name:           do.Name,
+		FilterTypeName: do.FilterType,
+	}
+	if do.Arguments != nil {
+		object.ArgsTypeName = do.Arguments.Type
+	}
+	for _, q := range do.Queries {
+		object.Queries = append(object.Queries, DataObjectQuery{
+			Name:      q.Name,
+			QueryType: string(q.Type),
+			QueryRoot: m.QueryType,
+		})
+	}
+	return object, nil
+}
@@ -0,0 +1,155 @@
+package indexer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hugr-lab/query-engine/pkg/compiler/base"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+)
+
+func TestDiffSchema(t *testing.T) {
+	schema := &SchemaIntro{
+		Types: []TypeIntro{
+			{Name: "String", Kind: "SCALAR"},
+			{Name: "Int", Kind: "SCALAR"},
+			{Name: "Query", Kind: "OBJECT", HugrType: base.HugrTypeModule, Fields: []FieldIntro{
+				{Name: "users", Type: TypeRefIntro{Kind: "LIST", OfType: &TypeRefIntro{Name: "users", Kind: "OBJECT"}}, Args: []ArgIntro{
+					{Name: "limit", Type: TypeRefIntro{Name: "Int", Kind: "SCALAR"}},
+				}},
+			}},
+			{Name: "users", Kind: "OBJECT", HugrType: base.HugrTypeTable, Catalog: "db", Description: "new", Fields: []FieldIntro{
+				{Name: "id", Type: TypeRefIntro{Kind: "NON_NULL", OfType: &TypeRefIntro{Name: "Int", Kind: "SCALAR"}}},
+				{Name: "email", Type: TypeRefIntro{Name: "String", Kind: "SCALAR"}},
+			}},
+			{Name: "orders", Kind: "OBJECT", HugrType: base.HugrTypeTable, Catalog: "db", Fields: []FieldIntro{
+				{Name: "id", Type: TypeRefIntro{Name: "Int", Kind: "SCALAR"}},
+			}},
+		},
+	}
+	meta := &metainfo.SchemaInfo{
+		DataSources: []metainfo.DataSourceInfo{
+			{Name: "db", Type: "postgres", ReadOnly: true},
+		},
+		RootModule: metainfo.ModuleInfo{QueryType: "Query"},
+	}
+	index := &indexSnapshot{
+		Types: []Type{
+			{Name: "Unknown", Kind: "SCALAR"},
+			{Name: "String", Kind: "SCALAR"},
+			{Name: "Int", Kind: "SCALAR"},
+			{Name: "Query", Kind: "OBJECT", HugrType: base.HugrTypeModule},
+			// summarized, the description is not compared
+			{Name: "users", Kind: "OBJECT", HugrType: base.HugrTypeView, Catalog: "db", Description: "old", IsSummarized: true},
+			{Name: "stale", Kind: "OBJECT"},
+		},
+		Fields: []Field{
+			{Name: "users", TypeName: "Query", Type: "users", IsList: true},
+			{Name: "id", TypeName: "users", Type: "Int"},
+			{Name: "name", TypeName: "users", Type: "String"},
+			{Name: "id", TypeName: "stale", Type: "Int"},
+		},
+		Arguments: []Argument{
+			{Name: "limit", TypeName: "Query", FieldName: "users", Type: "Int"},
+			{Name: "offset", TypeName: "Query", FieldName: "users", Type: "Int"},
+		},
+		Modules: []Module{
+			{Name: "", QueryRoot: "Query"},
+			{Name: "old"},
+		},
+		DataSources: []DataSource{
+			{Name: "db", Type: "postgres"},
+		},
+	}
+
+	diff := diffSchema(schema, meta, index)
+
+	var got []string
+	for _, c := range diff.Changes {
+		got = append(got, string(c.Action)+" "+string(c.Entity)+" "+c.key())
+	}
+	want := []string{
+		"changed data_source db",
+		"removed module old",
+		"changed type users",
+		"changed field users.id",
+		"added field users.email",
+		"added type orders",
+		"added field orders.id",
+		"removed type stale",
+		"removed field users.name",
+		"removed argument Query.users(offset)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("changes:\n got %q\nwant %q", got, want)
+	}
+	if c := diff.Changes[2]; !slices.Equal(c.Attributes, []string{"hugr_type"}) {
+		t.Errorf("type attributes = %v, want [hugr_type]", c.Attributes)
+	}
+	if c := diff.Changes[3]; !slices.Equal(c.Attributes, []string{"is_non_null"}) {
+		t.Errorf("field attributes = %v, want [is_non_null]", c.Attributes)
+	}
+	if n := diff.Summary[DiffEntityField][DiffActionAdded]; n != 2 {
+		t.Errorf("added fields = %d, want 2", n)
+	}
+
+	empty := diffSchema(&SchemaIntro{}, &metainfo.SchemaInfo{}, &indexSnapshot{Modules: []Module{{Name: ""}}})
+	if !empty.IsEmpty() {
+		t.Errorf("diff = %+v, want empty", empty.Changes)
+	}
+}
+
+func TestDiffReloads(t *testing.T) {
+	meta := &metainfo.SchemaInfo{
+		RootModule: metainfo.ModuleInfo{
+			QueryType:    "Query",
+			FunctionType: "Function",
+			Functions: []metainfo.FunctionInfo{
+				{Name: "now", Module: ""},
+				{Name: "weather", Module: "", DataSource: "api"},
+			},
+			SubModules: []metainfo.ModuleInfo{
+				{Name: "shop", FunctionType: "shop_function", SubModules: []metainfo.ModuleInfo{
+					{Name: "shop.orders", FunctionType: "shop_orders_function", Functions: []metainfo.FunctionInfo{
+						{Name: "total", Module: "shop.orders"},
+					}},
+				}},
+				{Name: "crm", MutationFunctionType: "crm_mut_function", MutateFunctions: []metainfo.FunctionInfo{
+					{Name: "notify", Module: "crm"},
+				}},
+			},
+		},
+	}
+	diff := &SchemaDiff{}
+	for _, c := range []Change{
+		{Entity: DiffEntityDataSource, Action: DiffActionChanged, Name: "api"},
+		{Entity: DiffEntityDataSource, Action: DiffActionRemoved, Name: "old"},
+		{Entity: DiffEntityModule, Action: DiffActionChanged, Name: ""},
+		{Entity: DiffEntityModule, Action: DiffActionAdded, Name: "shop.orders"},
+		{Entity: DiffEntityModule, Action: DiffActionChanged, Name: "shop"},
+		// the function of the reloaded data source
+		{Entity: DiffEntityField, Action: DiffActionChanged, Name: "weather", TypeName: "Function"},
+		// the submodule field of the function root type
+		{Entity: DiffEntityField, Action: DiffActionAdded, Name: "shop", TypeName: "Function"},
+		{Entity: DiffEntityField, Action: DiffActionAdded, Name: "now", TypeName: "Function"},
+		{Entity: DiffEntityArgument, Action: DiffActionAdded, Name: "tz", TypeName: "Function", FieldName: "now"},
+		// the function of the reloaded module
+		{Entity: DiffEntityField, Action: DiffActionChanged, Name: "total", TypeName: "shop_orders_function"},
+		{Entity: DiffEntityArgument, Action: DiffActionChanged, Name: "to", TypeName: "crm_mut_function", FieldName: "notify"},
+		{Entity: DiffEntityField, Action: DiffActionRemoved, Name: "old", TypeName: "Function"},
+	} {
+		diff.add(c)
+	}
+
+	r := diffReloads(meta, diff)
+	if want := []string{"api"}; !slices.Equal(r.dataSources, want) {
+		t.Errorf("data sources = %v, want %v", r.dataSources, want)
+	}
+	if want := []string{"shop"}; !slices.Equal(r.modules, want) {
+		t.Errorf("modules = %v, want %v", r.modules, want)
+	}
+	want := []functionRef{{module: "", name: "now"}, {module: "crm", name: "notify"}}
+	if !slices.Equal(r.functions, want) {
+		t.Errorf("functions = %v, want %v", r.functions, want)
+	}
+}
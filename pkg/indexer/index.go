@@ -4,11 +4,30 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
 	"github.com/hugr-lab/query-engine/pkg/types"
 )
 
+var ErrEmbeddingsDisabled = errors.New("embeddings are disabled")
+
+// Index builds the embeddings for the data sources, modules, types and fields.
+// If summarized is true, only the summarized items are indexed.
+func (s *Service) Index(ctx context.Context, summarized bool) error {
+	if !s.c.EmbeddingsEnabled {
+		return ErrEmbeddingsDisabled
+	}
+	if err := s.IndexDataSources(ctx, summarized); err != nil {
+		return err
+	}
+	if err := s.IndexModules(ctx, summarized); err != nil {
+		return err
+	}
+	if err := s.IndexTypes(ctx, summarized); err != nil {
+		return err
+	}
+	return s.IndexFields(ctx, summarized)
+}
+
 func (s *Service) IndexDataSources(ctx context.Context, summarized bool) error {
 	filter := map[string]any{}
 	if summarized {
@@ -57,10 +76,10 @@ func (s *Service) IndexDataSources(ctx context.Context, summarized bool) error {
 			summary = ds.Name
 		}
 		if err := s.IndexDataSource(ctx, ds.Name, summary); err != nil {
-			log.Printf("failed to index data source %s: %v", ds.Name, err)
+			logf(ctx, "failed to index data source %s: %v", ds.Name, err)
 			continue
 		}
-		log.Printf("data source %s indexed", ds.Name)
+		logf(ctx, "data source %s indexed", ds.Name)
 	}
 	return nil
 }
@@ -135,10 +154,10 @@ func (s *Service) IndexModules(ctx context.Context, summarized bool) error {
 			summary = mod.Name
 		}
 		if err := s.IndexModule(ctx, mod.Name, summary); err != nil {
-			log.Printf("failed to index module %s: %v", mod.Name, err)
+			logf(ctx, "failed to index module %s: %v", mod.Name, err)
 			continue
 		}
-		log.Printf("module %s indexed", mod.Name)
+		logf(ctx, "module %s indexed", mod.Name)
 	}
 	return nil
 }
@@ -225,10 +244,10 @@ func (s *Service) IndexFields(ctx context.Context, summarized bool) error {
 			field.Description = field.Name
 		}
 		if err := s.IndexField(ctx, field.TypeName, field.Name, field.Description); err != nil {
-			log.Printf("failed to index field %s.%s: %v", field.TypeName, field.Name, err)
+			logf(ctx, "failed to index field %s.%s: %v", field.TypeName, field.Name, err)
 			continue
 		}
-		log.Printf("field %s.%s indexed", field.TypeName, field.Name)
+		logf(ctx, "field %s.%s indexed", field.TypeName, field.Name)
 	}
 	return nil
 }
@@ -315,10 +334,10 @@ func (s *Service) IndexTypes(ctx context.Context, summarized bool) error {
 			summary = t.Name
 		}
 		if err := s.IndexType(ctx, t.Name, summary); err != nil {
-			log.Printf("failed to index type %s.%s: %v", t.Module, t.Name, err)
+			logf(ctx, "failed to index type %s.%s: %v", t.Module, t.Name, err)
 			continue
 		}
-		log.Printf("type %s.%s indexed", t.Module, t.Name)
+		logf(ctx, "type %s.%s indexed", t.Module, t.Name)
 	}
 	return nil
 }
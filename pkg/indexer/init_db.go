@@ -41,8 +41,7 @@ func createDB(ctx context.Context, dbType db.ScriptDBType, dbPath string, vector
 	if err != nil {
 		return err
 	}
-	switch dbType {
-	case db.SDBPostgres:
+	if dbType == db.SDBPostgres {
 		// try to create the database (need to connect to the postgres database)
 		dbDSN, err := sources.ParseDSN(dbPath)
 		if err != nil {
@@ -59,26 +58,31 @@ func createDB(ctx context.Context, dbType db.ScriptDBType, dbPath string, vector
 		if err != nil {
 			return err
 		}
-		d, err = sql.Open("pgx", dbPath)
-		if err != nil {
-			return err
-		}
-		_, err = d.ExecContext(ctx, initSQL)
+	}
+	d, err := openDB(dbType, dbPath)
+	if err != nil {
 		return err
+	}
+	defer d.Close()
+	_, err = d.ExecContext(ctx, initSQL)
+	return err
+}
+
+// openDB opens the index database directly (without hugr).
+func openDB(dbType db.ScriptDBType, dbPath string) (*sql.DB, error) {
+	switch dbType {
+	case db.SDBPostgres:
+		return sql.Open("pgx", dbPath)
 	case db.SDBDuckDB:
 		if strings.HasPrefix(dbPath, "s3://") {
-			return errors.New("database is in readonly mode (s3)")
+			return nil, errors.New("database is in readonly mode (s3)")
 		}
 		conn, err := duckdb.NewConnector(dbPath, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		defer conn.Close()
-		d := sql.OpenDB(conn)
-		defer d.Close()
-		_, err = d.ExecContext(ctx, initSQL)
-		return err
+		return sql.OpenDB(conn), nil
 	default:
-		return errors.New("unsupported database type")
+		return nil, errors.New("unsupported database type")
 	}
 }
@@ -7,6 +7,11 @@ import (
 	"github.com/vektah/gqlparser/v2/ast"
 )
 
+// LoadSchema clears the index and fills it with the hugr schema.
+func (s *Service) LoadSchema(ctx context.Context) error {
+	return s.fillBaseSchema(ctx)
+}
+
 // fillBaseSchema initial schema from Hugr
 func (s *Service) fillBaseSchema(ctx context.Context) error {
 	// 1. fetch schema types
@@ -35,15 +40,7 @@ func (s *Service) fillBaseSchema(ctx context.Context) error {
 	var aa []Argument
 	am := map[string]struct{}{}
 	for _, st := range schema.Types {
-		t := Type{
-			Name:        st.Name,
-			Description: st.Description,
-			Kind:        st.Kind,
-			HugrType:    st.HugrType,
-			Module:      st.Module,
-			Catalog:     st.Catalog,
-		}
-		err := s.AddType(ctx, t)
+		err := s.AddType(ctx, newType(st))
 		if err != nil {
 			return fmt.Errorf("failed to add type %q: %w", st.Name, err)
 		}
@@ -52,34 +49,14 @@ func (s *Service) fillBaseSchema(ctx context.Context) error {
 			fields = st.InputFields
 		}
 		for _, f := range fields {
-			field := Field{
-				Name:        f.Name,
-				Description: f.Description,
-				TypeName:    st.Name,
-				HugrType:    f.HugrType,
-				Catalog:     f.Catalog,
-				Exclude:     f.Exclude,
-				Type:        f.Type.TypeName(),
-				IsList:      f.Type.IsList(),
-				IsNotNull:   f.Type.IsNotNull(),
-			}
-			ff = append(ff, field)
+			ff = append(ff, newField(st.Name, f))
 			for _, a := range f.Args {
 				key := fmt.Sprintf("%s.%s.%s", st.Name, f.Name, a.Name)
 				if _, ok := am[key]; ok {
 					return fmt.Errorf("duplicate argument %q in hugr schema", key)
 				}
 				am[key] = struct{}{}
-				arg := Argument{
-					Name:        a.Name,
-					FieldName:   f.Name,
-					TypeName:    st.Name,
-					Description: a.Description,
-					Type:        a.Type.TypeName(),
-					IsList:      a.Type.IsList(),
-					IsNotNull:   a.Type.IsNotNull(),
-				}
-				aa = append(aa, arg)
+				aa = append(aa, newArgument(st.Name, f.Name, a))
 			}
 		}
 	}
@@ -105,14 +82,7 @@ func (s *Service) fillBaseSchema(ctx context.Context) error {
 	}
 	// 6. Modules
 	for _, m := range meta.Modules() {
-		err := s.AddModule(ctx, Module{
-			Name:            m.Name,
-			Description:     m.Description,
-			QueryRoot:       m.QueryType,
-			MutationRoot:    m.MutationType,
-			FunctionRoot:    m.FunctionType,
-			MutFunctionRoot: m.MutationFunctionType,
-		})
+		err := s.AddModule(ctx, newModule(m))
 		if err != nil {
 			return fmt.Errorf("failed to add module %q: %w", m.Name, err)
 		}
@@ -120,14 +90,7 @@ func (s *Service) fillBaseSchema(ctx context.Context) error {
 
 	// 7. Data sources
 	for _, ds := range meta.DataSources {
-		err := s.AddDataSource(ctx, DataSource{
-			Name:        ds.Name,
-			Description: ds.Description,
-			Type:        ds.Type,
-			Prefix:      ds.Prefix,
-			AsModule:    ds.AsModule,
-			ReadOnly:    ds.ReadOnly,
-		})
+		err := s.AddDataSource(ctx, newDataSource(ds))
 		if err != nil {
 			return fmt.Errorf("failed to add data source %q: %w", ds.Name, err)
 		}
@@ -135,26 +98,11 @@ func (s *Service) fillBaseSchema(ctx context.Context) error {
 
 	// 8. Data objects
 	for _, do := range meta.DataObjects() {
-		m := meta.Module(do.Module)
-		if m == nil || m.QueryType == "" {
-			return fmt.Errorf("module %q not found for data object %q", do.Module, do.Name)
-		}
-		object := DataObject{
-			Name:           do.Name,
-			FilterTypeName: do.FilterType,
-		}
-		if do.Arguments != nil {
-			object.ArgsTypeName = do.Arguments.Type
-		}
-		for _, q := range do.Queries {
-			object.Queries = append(object.Queries, DataObjectQuery{
-				Name:      q.Name,
-				QueryType: string(q.Type),
-				QueryRoot: m.QueryType,
-			})
+		object, err := newDataObject(meta, do)
+		if err != nil {
+			return err
 		}
-
-		err := s.addDataObject(ctx, object)
+		err = s.addDataObject(ctx, object)
 		if err != nil {
 			return fmt.Errorf("failed to add data object %q: %w", do.Name, err)
 		}
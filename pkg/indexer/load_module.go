@@ -0,0 +1,474 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/hugr-lab/query-engine/pkg/types"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// LoadModule loads/reloads the module with its submodules, data objects and functions.
+// Types and fields that are not in the schema anymore are removed, summaries of unchanged items are kept.
+func (s *Service) LoadModule(ctx context.Context, name string) error {
+	if name == "" {
+		return errors.New("module name is required, use LoadSchema to reload the whole schema")
+	}
+	schema, err := s.fetchSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	meta, err := s.fetchSummary(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch meta summary: %w", err)
+	}
+	return s.loadModule(ctx, schema, meta, name)
+}
+
+func (s *Service) loadModule(ctx context.Context, schema *SchemaIntro, meta *metainfo.SchemaInfo, name string) error {
+	inModule := func(module string) bool {
+		return module == name || strings.HasPrefix(module, name+".")
+	}
+
+	var objects []*metainfo.DataObjectInfo
+	var functions []*metainfo.FunctionInfo
+	mi := meta.Module(name)
+	if mi != nil {
+		objects = mi.DataObjects()
+		functions = append(mi.AllFunctions(), mi.AllMutationFunctions()...)
+	}
+
+	typesMap, fieldsMap, modulesMap, dataSourcesMap, err := schemaItemsForUpdate(schema, meta, objects, functions)
+	if err != nil {
+		return err
+	}
+	if mi != nil {
+		for _, sm := range mi.Modules() {
+			if err := fillModulesTypesRecursively(meta, sm, typesMap, fieldsMap, modulesMap); err != nil {
+				return err
+			}
+		}
+	}
+
+	// remove the module types, submodules and root fields that are not in the schema anymore
+	err = s.deleteStaleTypes(ctx, schema, map[string]any{
+		"_or": []map[string]any{
+			{"module": map[string]any{"eq": name}},
+			{"module": map[string]any{"like": name + ".%"}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = s.deleteStaleModules(ctx, meta, inModule)
+	if err != nil {
+		return err
+	}
+	if mi == nil {
+		// remove the module field from the parent module root types
+		parent := ""
+		if i := strings.LastIndex(name, "."); i != -1 {
+			parent = name[:i]
+		}
+		if pm := meta.Module(parent); pm != nil {
+			roots := map[string]struct{}{}
+			for _, t := range []string{pm.QueryType, pm.MutationType, pm.FunctionType, pm.MutationFunctionType} {
+				if t != "" {
+					roots[t] = struct{}{}
+				}
+			}
+			if err := s.deleteStaleFields(ctx, schema, roots, nil); err != nil {
+				return err
+			}
+		}
+		logf(ctx, "module %s: not found in schema, removed from index", name)
+		return nil
+	}
+	err = s.deleteStaleFields(ctx, schema, typesMap, nil)
+	if err != nil {
+		return err
+	}
+
+	err = s.loadSchemaPatrial(ctx, schema, meta, true, typesMap, fieldsMap, modulesMap, dataSourcesMap)
+	if err != nil {
+		return fmt.Errorf("failed to load module %s: %w", name, err)
+	}
+	logf(ctx, "module %s: loaded %d data objects and %d functions", name, len(objects), len(functions))
+	return nil
+}
+
+// LoadDataSource loads/reloads the data source with its data objects and functions.
+// Types and fields that are not in the schema anymore are removed, summaries of unchanged items are kept.
+func (s *Service) LoadDataSource(ctx context.Context, name string) error {
+	if name == "" {
+		return errors.New("data source name is required")
+	}
+	schema, err := s.fetchSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	meta, err := s.fetchSummary(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch meta summary: %w", err)
+	}
+	return s.loadDataSource(ctx, schema, meta, name)
+}
+
+func (s *Service) loadDataSource(ctx context.Context, schema *SchemaIntro, meta *metainfo.SchemaInfo, name string) error {
+	var objects []*metainfo.DataObjectInfo
+	for _, do := range meta.DataObjects() {
+		if do.DataSource == name {
+			objects = append(objects, do)
+		}
+	}
+	var functions []*metainfo.FunctionInfo
+	for _, f := range append(meta.Functions(), meta.MutationFunctions()...) {
+		if f.DataSource == name {
+			functions = append(functions, f)
+		}
+	}
+
+	typesMap, fieldsMap, modulesMap, dataSourcesMap, err := schemaItemsForUpdate(schema, meta, objects, functions)
+	if err != nil {
+		return err
+	}
+
+	// remove the data source types that are not in the schema anymore
+	err = s.deleteStaleTypes(ctx, schema, map[string]any{
+		"catalog": map[string]any{"eq": name},
+	})
+	if err != nil {
+		return err
+	}
+	// the data source modules and their fields in the parent modules can be changed,
+	// the modules and the root type fields of the other data sources are kept
+	modules, err := s.dataSourceModules(ctx, name, objects, functions)
+	if err != nil {
+		return err
+	}
+	inDataSource := func(module string) bool {
+		_, ok := modules[module]
+		return ok
+	}
+	err = s.deleteStaleModules(ctx, meta, inDataSource)
+	if err != nil {
+		return err
+	}
+	roots := map[string]struct{}{}
+	rootModules := map[string]string{}
+	for m := range modules {
+		mi := meta.Module(m)
+		if mi == nil {
+			continue
+		}
+		for _, t := range []string{mi.QueryType, mi.MutationType, mi.FunctionType, mi.MutationFunctionType} {
+			if t != "" {
+				roots[t] = struct{}{}
+				rootModules[t] = m
+			}
+		}
+	}
+	for t := range typesMap {
+		roots[t] = struct{}{}
+	}
+	err = s.deleteStaleFields(ctx, schema, roots, func(f Field) bool {
+		m, ok := rootModules[f.TypeName]
+		if !ok || f.Catalog == name {
+			return true
+		}
+		// the submodule field of the module root type
+		if m != "" {
+			return inDataSource(m + "." + f.Name)
+		}
+		return inDataSource(f.Name)
+	})
+	if err != nil {
+		return err
+	}
+	if meta.DataSource(name) == nil {
+		if err := s.deleteDataSource(ctx, name); err != nil {
+			return err
+		}
+		logf(ctx, "data source %s: not found in schema, removed from index", name)
+		return nil
+	}
+	dataSourcesMap[name] = struct{}{}
+
+	err = s.loadSchemaPatrial(ctx, schema, meta, true, typesMap, fieldsMap, modulesMap, dataSourcesMap)
+	if err != nil {
+		return fmt.Errorf("failed to load data source %s: %w", name, err)
+	}
+	logf(ctx, "data source %s: loaded %d data objects and %d functions", name, len(objects), len(functions))
+	return nil
+}
+
+// schemaItemsForUpdate collects types, fields, modules and data sources of the data objects and functions.
+func schemaItemsForUpdate(schema *SchemaIntro, meta *metainfo.SchemaInfo,
+	objects []*metainfo.DataObjectInfo, functions []*metainfo.FunctionInfo,
+) (
+	typesMap map[string]struct{},
+	fieldsMap map[string]map[string]struct{},
+	modulesMap map[string]struct{},
+	dataSourcesMap map[string]struct{},
+	err error,
+) {
+	typesMap = map[string]struct{}{}
+	fieldsMap = map[string]map[string]struct{}{}
+	modulesMap = map[string]struct{}{}
+	dataSourcesMap = map[string]struct{}{}
+	for _, do := range objects {
+		err = fillDataObjectTypesForUpdate(schema, meta, do.Name, typesMap, fieldsMap, modulesMap, dataSourcesMap)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to fill data object %s types for update: %w", do.Name, err)
+		}
+	}
+	for _, f := range functions {
+		err = fillFunctionTypesForUpdate(schema, meta, f.Module, f.Name, typesMap, fieldsMap, modulesMap, dataSourcesMap)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to fill function %s.%s types for update: %w", f.Module, f.Name, err)
+		}
+	}
+	return typesMap, fieldsMap, modulesMap, dataSourcesMap, nil
+}
+
+// deleteStaleTypes deletes the index types (with fields, arguments and data objects) that match the filter,
+// but are not in the schema.
+func (s *Service) deleteStaleTypes(ctx context.Context, schema *SchemaIntro, filter map[string]any) error {
+	var tt []Type
+	err := s.queryOne(ctx, `query ($filter: mcp_types_filter) {
+		core {
+			mcp {
+				types(filter: $filter) {
+					name
+				}
+			}
+		}
+	}`, map[string]any{"filter": filter}, "core.mcp.types", &tt)
+	if err != nil {
+		return fmt.Errorf("failed to query types for clean up: %w", err)
+	}
+	for _, t := range tt {
+		if schema.TypeByName(t.Name) != nil {
+			continue
+		}
+		if err := s.deleteDataObject(ctx, t.Name); err != nil {
+			return err
+		}
+		if err := s.deleteType(ctx, t.Name); err != nil {
+			return err
+		}
+		logf(ctx, "type %s: removed from index", t.Name)
+	}
+	return nil
+}
+
+// dataSourceModules returns the modules of the data source with their parent modules:
+// the modules of the indexed data source types and fields and the modules of its current data objects and functions.
+func (s *Service) dataSourceModules(ctx context.Context, name string,
+	objects []*metainfo.DataObjectInfo, functions []*metainfo.FunctionInfo,
+) (map[string]struct{}, error) {
+	var data struct {
+		Types  []Type `json:"types"`
+		Fields []struct {
+			RootType Type `json:"root_type"`
+		} `json:"fields"`
+	}
+	err := s.queryOne(ctx, `query ($name: String!) {
+		core {
+			mcp {
+				types(filter: { catalog: { eq: $name } }) {
+					module
+				}
+				fields(filter: { catalog: { eq: $name } }) {
+					root_type {
+						module
+					}
+				}
+			}
+		}
+	}`, map[string]any{"name": name}, "core.mcp", &data)
+	if err != nil && !errors.Is(err, types.ErrNoData) {
+		return nil, fmt.Errorf("failed to query data source modules: %w", err)
+	}
+	modules := map[string]struct{}{}
+	add := func(module string) {
+		modules[""] = struct{}{}
+		pp := strings.Split(module, ".")
+		for i := range pp {
+			modules[strings.Join(pp[:i+1], ".")] = struct{}{}
+		}
+	}
+	for _, t := range data.Types {
+		add(t.Module)
+	}
+	for _, f := range data.Fields {
+		add(f.RootType.Module)
+	}
+	for _, do := range objects {
+		add(do.Module)
+	}
+	for _, f := range functions {
+		add(f.Module)
+	}
+	return modules, nil
+}
+
+// deleteStaleFields deletes the index fields and arguments of the types that are not in the schema,
+// match limits the deleted fields (nil - all).
+func (s *Service) deleteStaleFields(ctx context.Context, schema *SchemaIntro, typesMap map[string]struct{}, match func(f Field) bool) error {
+	if len(typesMap) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(typesMap))
+	for name := range typesMap {
+		names = append(names, name)
+	}
+	var data struct {
+		Fields    []Field    `json:"fields"`
+		Arguments []Argument `json:"arguments"`
+	}
+	err := s.queryOne(ctx, `query ($types: [String!]) {
+		core {
+			mcp {
+				fields(filter: { type_name: { in: $types } }) {
+					name
+					type_name
+					catalog
+				}
+				arguments(filter: { type_name: { in: $types } }) {
+					name
+					type_name
+					field_name
+				}
+			}
+		}
+	}`, map[string]any{"types": names}, "core.mcp", &data)
+	if err != nil {
+		return fmt.Errorf("failed to query fields for clean up: %w", err)
+	}
+
+	var deleteFieldFilters, deleteArgFilters []map[string]map[string]any
+	for _, f := range data.Fields {
+		if schemaField(schema, f.TypeName, f.Name) != nil || match != nil && !match(f) {
+			continue
+		}
+		deleteFieldFilters = append(deleteFieldFilters, map[string]map[string]any{
+			"name":      {"eq": f.Name},
+			"type_name": {"eq": f.TypeName},
+		})
+		deleteArgFilters = append(deleteArgFilters, map[string]map[string]any{
+			"field_name": {"eq": f.Name},
+			"type_name":  {"eq": f.TypeName},
+		})
+	}
+	for _, a := range data.Arguments {
+		f := schemaField(schema, a.TypeName, a.FieldName)
+		if f == nil {
+			// deleted with the field
+			continue
+		}
+		if slices.ContainsFunc(f.Args, func(arg ArgIntro) bool { return arg.Name == a.Name }) {
+			continue
+		}
+		deleteArgFilters = append(deleteArgFilters, map[string]map[string]any{
+			"name":       {"eq": a.Name},
+			"field_name": {"eq": a.FieldName},
+			"type_name":  {"eq": a.TypeName},
+		})
+	}
+	if len(deleteFieldFilters) == 0 && len(deleteArgFilters) == 0 {
+		return nil
+	}
+	logf(ctx, "removing %d fields and %d arguments from index", len(deleteFieldFilters), len(deleteArgFilters))
+	// the empty _or filter matches all rows
+	if len(deleteFieldFilters) == 0 {
+		deleteFieldFilters = append(deleteFieldFilters, map[string]map[string]any{"name": {"is_null": true}})
+	}
+	return s.deleteFieldsAndArguments(ctx, deleteFieldFilters, deleteArgFilters)
+}
+
+// deleteStaleModules deletes the index modules that match the predicate, but are not in the schema.
+func (s *Service) deleteStaleModules(ctx context.Context, meta *metainfo.SchemaInfo, match func(name string) bool) error {
+	var mm []Module
+	err := s.queryOne(ctx, `query {
+		core {
+			mcp {
+				modules {
+					name
+				}
+			}
+		}
+	}`, nil, "core.mcp.modules", &mm)
+	if err != nil {
+		return fmt.Errorf("failed to query modules for clean up: %w", err)
+	}
+	for _, m := range mm {
+		if !match(m.Name) || meta.Module(m.Name) != nil {
+			continue
+		}
+		if err := s.deleteModule(ctx, m.Name); err != nil {
+			return err
+		}
+		logf(ctx, "module %s: removed from index", m.Name)
+	}
+	return nil
+}
+
+func (s *Service) deleteDataObject(ctx context.Context, name string) error {
+	res, err := s.h.Query(ctx, `mutation ($name: String!) {
+		core {
+			mcp {
+				delete_data_object_queries (filter: { object_name: { eq: $name }}) { success }
+				delete_data_objects (filter: { name: { eq: $name }}) { success }
+			}
+		}
+	}`, map[string]any{
+		"name": name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete data object: %w", err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return fmt.Errorf("failed to delete data object: %w", res.Err())
+	}
+	return nil
+}
+
+func schemaField(schema *SchemaIntro, typeName, fieldName string) *FieldIntro {
+	t := schema.TypeByName(typeName)
+	if t == nil {
+		return nil
+	}
+	fields := t.Fields
+	if t.Kind == string(ast.InputObject) {
+		fields = t.InputFields
+	}
+	for i := range fields {
+		if fields[i].Name == fieldName {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// queryOne runs the query and scans the data by the path, v is not changed if there is no data.
+func (s *Service) queryOne(ctx context.Context, query string, vars map[string]any, path string, v any) error {
+	res, err := s.h.Query(ctx, query, vars)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return res.Err()
+	}
+	err = res.ScanData(path, v)
+	if errors.Is(err, types.ErrNoData) {
+		return nil
+	}
+	return err
+}
@@ -55,6 +55,10 @@ func (s *Service) LoadFunction(ctx context.Context, module, name string, patrial
 	if err != nil {
 		return fmt.Errorf("failed to fetch meta summary: %w", err)
 	}
+	return s.loadFunction(ctx, schema, meta, module, name, patrial)
+}
+
+func (s *Service) loadFunction(ctx context.Context, schema *SchemaIntro, meta *metainfo.SchemaInfo, module, name string, patrial bool) error {
 	if !patrial {
 		err := s.clearFunctionTypes(ctx, schema, meta, module, name)
 		if err != nil {
@@ -65,7 +69,7 @@ func (s *Service) LoadFunction(ctx context.Context, module, name string, patrial
 	fieldsMap := map[string]map[string]struct{}{}
 	modulesMap := map[string]struct{}{}
 	dataSourcesMap := map[string]struct{}{}
-	err = fillFunctionTypesForUpdate(schema, meta, module, name, typesMap, fieldsMap, modulesMap, dataSourcesMap)
+	err := fillFunctionTypesForUpdate(schema, meta, module, name, typesMap, fieldsMap, modulesMap, dataSourcesMap)
 	if err != nil {
 		return fmt.Errorf("failed to fill function types for update: %w", err)
 	}
@@ -76,19 +80,6 @@ func (s *Service) LoadFunction(ctx context.Context, module, name string, patrial
 	return nil
 }
 
-func (s *Service) LoadDataSource(ctx context.Context, name string) error {
-	return nil
-}
-
-func (s *Service) LoadModule(ctx context.Context, name string) error {
-	// 1. Fetch schema and summary
-	// 2. Get all data objects and functions for the module
-	// 2. Clear module, data objects and functions types, fields, arguments if needed
-	// 3. Load functions, data objects and modules types, fields, arguments
-
-	return nil
-}
-
 func (s *Service) loadSchemaPatrial(ctx context.Context, schema *SchemaIntro, meta *metainfo.SchemaInfo, update bool,
 	typesMap map[string]struct{},
 	fieldsMap map[string]map[string]struct{},
@@ -114,15 +105,7 @@ func (s *Service) loadSchemaPatrial(ctx context.Context, schema *SchemaIntro, me
 				continue
 			}
 		}
-		t := Type{
-			Name:        st.Name,
-			Description: st.Description,
-			Kind:        st.Kind,
-			HugrType:    st.HugrType,
-			Module:      st.Module,
-			Catalog:     st.Catalog,
-		}
-		err := s.mergeType(ctx, t, update)
+		err := s.mergeType(ctx, newType(st), update)
 		if err != nil {
 			return fmt.Errorf("failed to add type %q: %w", st.Name, err)
 		}
@@ -139,34 +122,14 @@ func (s *Service) loadSchemaPatrial(ctx context.Context, schema *SchemaIntro, me
 					continue
 				}
 			}
-			field := Field{
-				Name:        f.Name,
-				Description: f.Description,
-				TypeName:    st.Name,
-				HugrType:    f.HugrType,
-				Catalog:     f.Catalog,
-				Exclude:     f.Exclude,
-				Type:        f.Type.TypeName(),
-				IsList:      f.Type.IsList(),
-				IsNotNull:   f.Type.IsNotNull(),
-			}
-			ff = append(ff, field)
+			ff = append(ff, newField(st.Name, f))
 			for _, a := range f.Args {
 				key := fmt.Sprintf("%s.%s.%s", st.Name, f.Name, a.Name)
 				if _, ok := am[key]; ok {
 					return fmt.Errorf("duplicate argument %q in hugr schema", key)
 				}
 				am[key] = struct{}{}
-				arg := Argument{
-					Name:        a.Name,
-					FieldName:   f.Name,
-					TypeName:    st.Name,
-					Description: a.Description,
-					Type:        a.Type.TypeName(),
-					IsList:      a.Type.IsList(),
-					IsNotNull:   a.Type.IsNotNull(),
-				}
-				aa = append(aa, arg)
+				aa = append(aa, newArgument(st.Name, f.Name, a))
 			}
 		}
 	}
@@ -195,14 +158,7 @@ func (s *Service) loadSchemaPatrial(ctx context.Context, schema *SchemaIntro, me
 				continue
 			}
 		}
-		err := s.mergeModule(ctx, Module{
-			Name:            m.Name,
-			Description:     m.Description,
-			QueryRoot:       m.QueryType,
-			MutationRoot:    m.MutationType,
-			FunctionRoot:    m.FunctionType,
-			MutFunctionRoot: m.MutationFunctionType,
-		}, false)
+		err := s.mergeModule(ctx, newModule(m), update)
 		if err != nil {
 			return fmt.Errorf("failed to add module %q: %w", m.Name, err)
 		}
@@ -215,14 +171,7 @@ func (s *Service) loadSchemaPatrial(ctx context.Context, schema *SchemaIntro, me
 				continue
 			}
 		}
-		err := s.mergeDataSource(ctx, DataSource{
-			Name:        ds.Name,
-			Description: ds.Description,
-			Type:        ds.Type,
-			Prefix:      ds.Prefix,
-			AsModule:    ds.AsModule,
-			ReadOnly:    ds.ReadOnly,
-		}, update)
+		err := s.mergeDataSource(ctx, newDataSource(ds), update)
 		if err != nil {
 			return fmt.Errorf("failed to add data source %q: %w", ds.Name, err)
 		}
@@ -235,26 +184,11 @@ func (s *Service) loadSchemaPatrial(ctx context.Context, schema *SchemaIntro, me
 				continue
 			}
 		}
-		m := meta.Module(do.Module)
-		if m == nil || m.QueryType == "" {
-			return fmt.Errorf("module %q not found for data object %q", do.Module, do.Name)
-		}
-		object := DataObject{
-			Name:           do.Name,
-			FilterTypeName: do.FilterType,
-		}
-		if do.Arguments != nil {
-			object.ArgsTypeName = do.Arguments.Type
-		}
-		for _, q := range do.Queries {
-			object.Queries = append(object.Queries, DataObjectQuery{
-				Name:      q.Name,
-				QueryType: string(q.Type),
-				QueryRoot: m.QueryType,
-			})
+		object, err := newDataObject(meta, do)
+		if err != nil {
+			return err
 		}
-
-		err := s.addDataObject(ctx, object)
+		err = s.addDataObject(ctx, object)
 		if err != nil {
 			return fmt.Errorf("failed to add data object %q: %w", do.Name, err)
 		}
@@ -969,17 +903,39 @@ func (s *Service) checkTypeExists(ctx context.Context, name string) (bool, error
 
 func (s *Service) mergeType(ctx context.Context, t Type, update bool) error {
 	// 1. Check if type exists
-	exists, err := s.checkTypeExists(ctx, t.Name)
+	var existing *Type
+	err := s.queryOne(ctx, `query ($name: String!) {
+		core {
+			mcp {
+				types_by_pk(name: $name) {
+					name
+					description
+					long_description
+					kind
+					hugr_type
+					catalog
+					module
+					is_summarized
+				}
+			}
+		}
+	}`, map[string]any{"name": t.Name}, "core.mcp.types_by_pk", &existing)
 	if err != nil {
 		return fmt.Errorf("failed to check if type %q exists: %w", t.Name, err)
 	}
-	if exists && !update {
+	if existing == nil {
+		return s.AddType(ctx, t)
+	}
+	if !update {
 		// skip
 		return nil
 	}
-	if !exists {
-		return s.AddType(ctx, t)
+	// keep the summary if the type definition is not changed
+	if len(typeChanges(*existing, t)) == 0 {
+		return nil
 	}
+	// the long description is kept until the type is summarized again
+	t.Long = existing.Long
 	return s.updateType(ctx, t)
 }
 
@@ -1079,49 +1035,45 @@ func (s *Service) deleteType(ctx context.Context, name string) error {
 
 func (s *Service) mergeField(ctx context.Context, f Field, update bool) error {
 	// 1. Check if field exists
-	exists, err := s.checkFieldExists(ctx, f.TypeName, f.Name)
-	if err != nil {
-		return fmt.Errorf("failed to check if field %q.%q exists: %w", f.TypeName, f.Name, err)
-	}
-	if exists && !update {
-		// skip
-		return nil
-	}
-	if !exists {
-		return s.AddField(ctx, f)
-	}
-	return s.updateTypeField(ctx, f)
-}
-
-func (s *Service) checkFieldExists(ctx context.Context, typeName, fieldName string) (bool, error) {
-	res, err := s.h.Query(ctx, `query ($typeName: String!, $fieldName: String!) {
+	var existing *Field
+	err := s.queryOne(ctx, `query ($typeName: String!, $fieldName: String!) {
 		core {
 			mcp {
 				fields_by_pk(type_name: $typeName, name: $fieldName) {
 					name
+					type_name
+					description
+					type
+					hugr_type
+					catalog
+					is_list
+					is_non_null
+					mcp_exclude
+					is_indexed
+					is_summarized
 				}
 			}
 		}
 	}`, map[string]any{
-		"typeName":  typeName,
-		"fieldName": fieldName,
-	})
+		"typeName":  f.TypeName,
+		"fieldName": f.Name,
+	}, "core.mcp.fields_by_pk", &existing)
 	if err != nil {
-		return false, fmt.Errorf("query check field exists: %w", err)
+		return fmt.Errorf("failed to check if field %q.%q exists: %w", f.TypeName, f.Name, err)
 	}
-	defer res.Close()
-	if res.Err() != nil {
-		return false, fmt.Errorf("query check field exists: %w", res.Err())
+	if existing == nil {
+		return s.AddField(ctx, f)
 	}
-	var data any
-	err = res.ScanData("core.mcp.fields_by_pk", &data)
-	if errors.Is(err, types.ErrNoData) {
-		return false, nil
+	if !update {
+		// skip
+		return nil
 	}
-	if err != nil {
-		return false, fmt.Errorf("query check field exists: %w", err)
+	// keep the summary if the field definition is not changed
+	if len(fieldChanges(*existing, f)) == 0 {
+		return nil
 	}
-	return data != nil, nil
+	f.IsIndexed = existing.IsIndexed
+	return s.updateTypeField(ctx, f)
 }
 
 const updateTypeFieldMutation = `mutation ($name: String!, $type_name: String!, $input: mcp_fields_mut_data!) {
@@ -1217,50 +1169,41 @@ func (s *Service) deleteTypeField(ctx context.Context, typeName, fieldName strin
 
 func (s *Service) mergeArgument(ctx context.Context, arg Argument, update bool) error {
 	// 1. Check if argument exists
-	exists, err := s.checkArgumentExists(ctx, arg.TypeName, arg.FieldName, arg.Name)
-	if err != nil {
-		return fmt.Errorf("failed to check if argument %q.%q(%q) exists: %w", arg.TypeName, arg.FieldName, arg.Name, err)
-	}
-	if exists && !update {
-		// skip
-		return nil
-	}
-	if !exists {
-		return s.AddArgument(ctx, arg)
-	}
-	return s.updateArgument(ctx, arg)
-}
-
-func (s *Service) checkArgumentExists(ctx context.Context, typeName, fieldName, argName string) (bool, error) {
-	res, err := s.h.Query(ctx, `query ($typeName: String!, $fieldName: String!, $argName: String!) {
+	var existing *Argument
+	err := s.queryOne(ctx, `query ($typeName: String!, $fieldName: String!, $argName: String!) {
 		core {
 			mcp {
 				arguments_by_pk(type_name: $typeName, field_name: $fieldName, name: $argName) {
 					name
+					type_name
+					field_name
+					description
+					type
+					is_list
+					is_non_null
 				}
 			}
 		}
 	}`, map[string]any{
-		"typeName":  typeName,
-		"fieldName": fieldName,
-		"argName":   argName,
-	})
+		"typeName":  arg.TypeName,
+		"fieldName": arg.FieldName,
+		"argName":   arg.Name,
+	}, "core.mcp.arguments_by_pk", &existing)
 	if err != nil {
-		return false, fmt.Errorf("query check argument exists: %w", err)
+		return fmt.Errorf("failed to check if argument %q.%q(%q) exists: %w", arg.TypeName, arg.FieldName, arg.Name, err)
 	}
-	defer res.Close()
-	if res.Err() != nil {
-		return false, fmt.Errorf("query check argument exists: %w", res.Err())
+	if existing == nil {
+		return s.AddArgument(ctx, arg)
 	}
-	var data any
-	err = res.ScanData("core.mcp.arguments_by_pk", &data)
-	if errors.Is(err, types.ErrNoData) {
-		return false, nil
+	if !update {
+		// skip
+		return nil
 	}
-	if err != nil {
-		return false, fmt.Errorf("query check argument exists: %w", err)
+	// keep the (summarized) description if the argument definition is not changed
+	if len(argumentChanges(*existing, arg)) == 0 {
+		return nil
 	}
-	return data != nil, nil
+	return s.updateArgument(ctx, arg)
 }
 
 func (s *Service) updateArgument(ctx context.Context, arg Argument) error {
@@ -1332,46 +1275,39 @@ func (s *Service) deleteArgument(ctx context.Context, typeName, fieldName, argNa
 
 func (s *Service) mergeDataSource(ctx context.Context, source DataSource, update bool) error {
 	// 1. Check if data source exists
-	exists, err := s.checkDataSourceExists(ctx, source.Name)
-	if err != nil {
-		return fmt.Errorf("failed to check if data source %q exists: %w", source.Name, err)
-	}
-	if exists && !update {
-		// skip
-		return nil
-	}
-	if !exists {
-		return s.AddDataSource(ctx, source)
-	}
-	return s.updateDataSource(ctx, source)
-}
-
-func (s *Service) checkDataSourceExists(ctx context.Context, name string) (bool, error) {
-	res, err := s.h.Query(ctx, `query ($name: String!) {
+	var existing *DataSource
+	err := s.queryOne(ctx, `query ($name: String!) {
 		core {
 			mcp {
 				data_sources_by_pk(name: $name) {
 					name
+					description
+					long_description
+					type
+					prefix
+					as_module
+					read_only
+					is_summarized
 				}
 			}
 		}
-	}`, map[string]any{
-		"name": name,
-	})
+	}`, map[string]any{"name": source.Name}, "core.mcp.data_sources_by_pk", &existing)
 	if err != nil {
-		return false, fmt.Errorf("query check data source exists: %w", err)
+		return fmt.Errorf("failed to check if data source %q exists: %w", source.Name, err)
 	}
-	defer res.Close()
-	if res.Err() != nil {
-		return false, fmt.Errorf("query check data source exists: %w", res.Err())
+	if existing == nil {
+		return s.AddDataSource(ctx, source)
 	}
-	var data any
-	err = res.ScanData("core.mcp.data_sources_by_pk", &data)
-	if errors.Is(err, types.ErrNoData) {
-		return false, nil
+	if !update {
+		// skip
+		return nil
 	}
-
-	return data != nil, nil
+	// keep the summary if the data source definition is not changed
+	if len(dataSourceChanges(*existing, source)) == 0 {
+		return nil
+	}
+	source.LongDescription = existing.LongDescription
+	return s.updateDataSource(ctx, source)
 }
 
 const updateDataSourceMutation = `mutation ($name: String!, $data: mcp_data_sources_mut_data!) {
@@ -1461,46 +1397,39 @@ func (s *Service) deleteDataSource(ctx context.Context, name string) error {
 
 func (s *Service) mergeModule(ctx context.Context, module Module, update bool) error {
 	// 1. Check if module exists
-	exists, err := s.checkModuleExists(ctx, module.Name)
-	if err != nil {
-		return fmt.Errorf("failed to check if module %q exists: %w", module.Name, err)
-	}
-	if exists && !update {
-		// skip
-		return nil
-	}
-	if !exists {
-		return s.AddModule(ctx, module)
-	}
-	return s.updateModule(ctx, module)
-}
-
-func (s *Service) checkModuleExists(ctx context.Context, name string) (bool, error) {
-	res, err := s.h.Query(ctx, `query ($name: String!) {
+	var existing *Module
+	err := s.queryOne(ctx, `query ($name: String!) {
 		core {
 			mcp {
 				modules_by_pk(name: $name) {
 					name
+					description
+					long_description
+					query_root
+					mutation_root
+					function_root
+					mut_function_root
+					is_summarized
 				}
 			}
 		}
-	}`, map[string]any{
-		"name": name,
-	})
+	}`, map[string]any{"name": module.Name}, "core.mcp.modules_by_pk", &existing)
 	if err != nil {
-		return false, fmt.Errorf("query check module exists: %w", err)
+		return fmt.Errorf("failed to check if module %q exists: %w", module.Name, err)
 	}
-	defer res.Close()
-	if res.Err() != nil {
-		return false, fmt.Errorf("query check module exists: %w", res.Err())
+	if existing == nil {
+		return s.AddModule(ctx, module)
 	}
-	var data any
-	err = res.ScanData("core.mcp.modules_by_pk", &data)
-	if errors.Is(err, types.ErrNoData) {
-		return false, nil
+	if !update {
+		// skip
+		return nil
 	}
-
-	return data != nil, nil
+	// keep the summary if the module definition is not changed
+	if len(moduleChanges(*existing, module)) == 0 {
+		return nil
+	}
+	module.LongDescription = existing.LongDescription
+	return s.updateModule(ctx, module)
 }
 
 const updateModuleMutation = `mutation ($name: String!, $data: mcp_modules_mut_data!) {
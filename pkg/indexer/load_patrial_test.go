@@ -519,3 +519,39 @@ func TestService_loadPatrial(t *testing.T) {
 		t.Fatalf("failed to load schema partial: %v", err)
 	}
 }
+
+func TestService_LoadModule(t *testing.T) {
+	s := New(testConfig, testHugr)
+	if err := s.Init(t.Context()); err != nil {
+		t.Fatalf("failed to init service: %v", err)
+	}
+
+	if err := s.LoadModule(t.Context(), "tf"); err != nil {
+		t.Fatalf("failed to load module: %v", err)
+	}
+	exists, err := s.checkTypeExists(t.Context(), "tf_road_parts")
+	if err != nil {
+		t.Fatalf("failed to check type exists: %v", err)
+	}
+	if !exists {
+		t.Fatalf("type tf_road_parts does not exist after module load")
+	}
+}
+
+func TestService_LoadDataSource(t *testing.T) {
+	s := New(testConfig, testHugr)
+	if err := s.Init(t.Context()); err != nil {
+		t.Fatalf("failed to init service: %v", err)
+	}
+
+	if err := s.LoadDataSource(t.Context(), "tf"); err != nil {
+		t.Fatalf("failed to load data source: %v", err)
+	}
+	exists, err := s.checkTypeExists(t.Context(), "tf_road_parts")
+	if err != nil {
+		t.Fatalf("failed to check type exists: %v", err)
+	}
+	if !exists {
+		t.Fatalf("type tf_road_parts does not exist after data source load")
+	}
+}
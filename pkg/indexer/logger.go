@@ -0,0 +1,25 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+type loggerCtxKey string
+
+const loggerCtxKeyName loggerCtxKey = "indexer-logger"
+
+// CtxWithLogger sets the logger of the indexer progress messages, e.g. the background job log.
+// The messages are written to the standard logger if it is not set.
+func CtxWithLogger(ctx context.Context, logf func(format string, args ...any)) context.Context {
+	return context.WithValue(ctx, loggerCtxKeyName, logf)
+}
+
+func logf(ctx context.Context, format string, args ...any) {
+	if l, ok := ctx.Value(loggerCtxKeyName).(func(string, ...any)); ok && l != nil {
+		l(format, args...)
+		return
+	}
+	log.Output(2, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,20 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCtxWithLogger(t *testing.T) {
+	var messages []string
+	ctx := CtxWithLogger(context.Background(), func(format string, args ...any) {
+		messages = append(messages, fmt.Sprintf(format, args...))
+	})
+	logf(ctx, "module %s: loaded %d data objects", "shop", 2)
+	logf(context.Background(), "the standard logger")
+	if !reflect.DeepEqual(messages, []string{"module shop: loaded 2 data objects"}) {
+		t.Errorf("unexpected messages %v", messages)
+	}
+}
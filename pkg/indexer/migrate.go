@@ -0,0 +1,169 @@
+package indexer
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hugr-lab/query-engine/pkg/db"
+)
+
+// The migrations upgrade the existing index database, they are applied in the version order.
+// Each migration is a SQL script template migrations/<version>.sql (the same template functions as in schema.sql).
+// Adding a migration:
+//  1. add migrations/<version>.sql with the changes;
+//  2. apply the same changes to schema.sql (new databases are created with the latest schema) and schema.graphql;
+//  3. set dbVersion to the migration version.
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+type migration struct {
+	version string
+	script  string
+}
+
+func loadMigrations() ([]migration, error) {
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var mm []migration
+	for _, file := range files {
+		script, err := migrationsFS.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", file, err)
+		}
+		version := strings.TrimSuffix(path.Base(file), ".sql")
+		if _, err := parseVersion(version); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", file, err)
+		}
+		mm = append(mm, migration{version: version, script: string(script)})
+	}
+	slices.SortFunc(mm, func(a, b migration) int {
+		return compareVersions(a.version, b.version)
+	})
+	return mm, nil
+}
+
+// Migrate upgrades the index database to the current version and returns the applied versions.
+// The database should not be attached to hugr (DuckDB file is locked).
+func Migrate(ctx context.Context, path string, vectorSize int) ([]string, error) {
+	if vectorSize <= 0 {
+		// the migrations create the vector columns of the size
+		return nil, fmt.Errorf("invalid embeddings vector size %d, it should be positive", vectorSize)
+	}
+	mm, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	dbType := db.SDBDuckDB
+	if strings.HasPrefix(path, "postgres://") {
+		dbType = db.SDBPostgres
+	}
+	d, err := openDB(dbType, path)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+	return migrate(ctx, d, dbType, mm, vectorSize)
+}
+
+func migrate(ctx context.Context, d *sql.DB, dbType db.ScriptDBType, mm []migration, vectorSize int) ([]string, error) {
+	current, err := currentDBVersion(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	var applied []string
+	for _, m := range mm {
+		if compareVersions(m.version, current) <= 0 {
+			continue
+		}
+		script, err := db.ParseSQLScriptTemplate(dbType, m.script, dbInitParams{
+			DBVersion:  m.version,
+			VectorSize: vectorSize,
+		})
+		if err != nil {
+			return applied, fmt.Errorf("parse migration %s: %w", m.version, err)
+		}
+		log.Printf("applying migration %s", m.version)
+		err = applyMigration(ctx, d, m.version, script)
+		if err != nil {
+			return applied, fmt.Errorf("apply migration %s: %w", m.version, err)
+		}
+		applied = append(applied, m.version)
+	}
+	return applied, nil
+}
+
+func applyMigration(ctx context.Context, d *sql.DB, version, script string) error {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO version_info (version) VALUES ('"+version+"');"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func currentDBVersion(ctx context.Context, d *sql.DB) (string, error) {
+	rows, err := d.QueryContext(ctx, "SELECT version FROM version_info;")
+	if err != nil {
+		return "", fmt.Errorf("query db version: %w", err)
+	}
+	defer rows.Close()
+	var versions []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return "", fmt.Errorf("scan db version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("query db version: %w", err)
+	}
+	if len(versions) == 0 {
+		return "", ErrWrongDBVersion
+	}
+	return latestVersion(versions), nil
+}
+
+// latestVersion returns the greatest version, the version_info keeps all applied versions.
+func latestVersion(versions []string) string {
+	return slices.MaxFunc(versions, compareVersions)
+}
+
+func parseVersion(v string) ([]int, error) {
+	var out []int
+	for p := range strings.SplitSeq(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", v)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// compareVersions compares the dotted numeric versions, invalid versions are compared as strings.
+func compareVersions(a, b string) int {
+	av, aerr := parseVersion(a)
+	bv, berr := parseVersion(b)
+	if aerr != nil || berr != nil {
+		return strings.Compare(a, b)
+	}
+	return slices.Compare(av, bv)
+}
@@ -0,0 +1,89 @@
+package indexer
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hugr-lab/query-engine/pkg/db"
+)
+
+func TestMigrations(t *testing.T) {
+	mm, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	for i, m := range mm {
+		if c := compareVersions(m.version, dbVersion); c > 0 {
+			t.Errorf("migration %s is newer than db version %s", m.version, dbVersion)
+		}
+		if i == len(mm)-1 && m.version != dbVersion {
+			t.Errorf("last migration %s, want db version %s", m.version, dbVersion)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.db")
+	if _, err := Migrate(t.Context(), path, 0); err == nil {
+		t.Error("expected error for the empty vector size")
+	}
+	d, err := openDB(db.SDBDuckDB, path)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer d.Close()
+	_, err = d.ExecContext(t.Context(), `
+		CREATE TABLE version_info (version TEXT NOT NULL PRIMARY KEY, applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW());
+		INSERT INTO version_info (version) VALUES ('0.0.1');
+	`)
+	if err != nil {
+		t.Fatalf("create db: %v", err)
+	}
+
+	mm := []migration{
+		{version: "0.0.1", script: "CREATE TABLE skipped (id INTEGER);"},
+		{version: "0.0.2", script: "CREATE TABLE items (id INTEGER);"},
+		{version: "0.0.10", script: "{{ if isPostgres }}ALTER TABLE items ADD COLUMN vec vector({{ .VectorSize }});{{ else }}ALTER TABLE items ADD COLUMN vec FLOAT[{{ .VectorSize }}];{{ end }}"},
+	}
+	applied, err := migrate(t.Context(), d, db.SDBDuckDB, mm, 3)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if !slices.Equal(applied, []string{"0.0.2", "0.0.10"}) {
+		t.Errorf("applied = %v", applied)
+	}
+	if v, err := currentDBVersion(t.Context(), d); err != nil || v != "0.0.10" {
+		t.Errorf("version = %q, %v, want 0.0.10", v, err)
+	}
+	if _, err := d.ExecContext(t.Context(), "INSERT INTO items (id, vec) VALUES (1, [1, 2, 3]);"); err != nil {
+		t.Errorf("migrated table: %v", err)
+	}
+
+	// the failed migration is rolled back
+	mm = append(mm, migration{version: "0.1.0", script: "CREATE TABLE broken (id INTEGER); SELECT * FROM unknown;"})
+	applied, err = migrate(t.Context(), d, db.SDBDuckDB, mm, 3)
+	if err == nil || len(applied) != 0 {
+		t.Fatalf("migrate = %v, %v, want error", applied, err)
+	}
+	if v, _ := currentDBVersion(t.Context(), d); v != "0.0.10" {
+		t.Errorf("version = %q, want 0.0.10", v)
+	}
+	var n int
+	err = d.QueryRowContext(t.Context(), "SELECT count(*) FROM information_schema.tables WHERE table_name = 'broken';").Scan(&n)
+	if err != nil || n != 0 {
+		t.Errorf("broken table is created: %d, %v", n, err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	if compareVersions("0.0.10", "0.0.9") <= 0 {
+		t.Errorf("0.0.10 should be greater than 0.0.9")
+	}
+	if compareVersions("0.1.0", "0.1.0") != 0 {
+		t.Errorf("versions should be equal")
+	}
+	if v := latestVersion([]string{"0.0.1", "0.0.10", "0.0.2"}); v != "0.0.10" {
+		t.Errorf("latest = %s, want 0.0.10", v)
+	}
+}
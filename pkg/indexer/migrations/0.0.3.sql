@@ -0,0 +1,9 @@
+-- MCP sessions
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT NOT NULL PRIMARY KEY,
+    user_id TEXT NOT NULL DEFAULT '',
+    data {{if isPostgres }} JSONB {{ else }} JSON {{ end }},
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
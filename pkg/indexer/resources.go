@@ -0,0 +1,385 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hugr-lab/query-engine/pkg/compiler/base"
+	"github.com/hugr-lab/query-engine/pkg/types"
+)
+
+// SchemaOverview is the list of the enabled data sources and modules with their summaries.
+type SchemaOverview struct {
+	DataSources []DataSource `json:"data_sources"`
+	Modules     []Module     `json:"modules"`
+}
+
+// ModuleDetails the module summary with its direct submodules, data objects and functions.
+type ModuleDetails struct {
+	Module
+	SubModules   []Module `json:"submodules,omitempty"`
+	DataObjects  []Type   `json:"data_objects,omitempty"`
+	Functions    []Field  `json:"functions,omitempty"`
+	MutFunctions []Field  `json:"mut_functions,omitempty"`
+}
+
+// DataObjectDetails the data object (table or view) summary with its fields and queries.
+type DataObjectDetails struct {
+	Type
+	FilterType string                   `json:"filter_type,omitempty"`
+	ArgsType   string                   `json:"args_type,omitempty"`
+	Queries    []DataObjectQueryDetails `json:"queries,omitempty"`
+}
+
+type DataObjectQueryDetails struct {
+	Name        string     `json:"name"`
+	QueryType   string     `json:"query_type"`
+	QueryRoot   string     `json:"query_root"`
+	Description string     `json:"description,omitempty"`
+	Arguments   []Argument `json:"arguments,omitempty"`
+}
+
+// FunctionDetails the module function with its arguments and the return type.
+type FunctionDetails struct {
+	Field
+	Module     string `json:"module"`
+	IsMutation bool   `json:"is_mutation"`
+}
+
+const resourceFieldsQuery = `
+	name
+	type_name
+	description
+	type
+	hugr_type
+	catalog
+	is_list
+	is_non_null
+	is_primary_key
+	mcp_exclude
+	arguments {
+		name
+		type
+		description
+		is_list
+		is_non_null
+	}
+`
+
+// SchemaOverview returns the enabled data sources and modules from the index.
+func (s *Service) SchemaOverview(ctx context.Context) (*SchemaOverview, error) {
+	var overview SchemaOverview
+	err := s.queryOne(ctx, `query ($ttl: Int!) {
+		core {
+			mcp {
+				data_sources(
+					filter: {disabled: {eq: false}}
+					order_by: [{field: "name"}]
+				) @cache(ttl: $ttl) {
+					name
+					description
+					long_description
+					type
+					prefix
+					as_module
+					read_only
+					is_summarized
+				}
+				modules(
+					filter: {disabled: {eq: false}}
+					order_by: [{field: "name"}]
+				) @cache(ttl: $ttl) {
+					name
+					description
+					long_description
+					query_root
+					mutation_root
+					function_root
+					mut_function_root
+					is_summarized
+				}
+			}
+		}
+	}`, map[string]any{
+		"ttl": s.c.ttl,
+	}, "core.mcp", &overview)
+	if err != nil {
+		return nil, fmt.Errorf("query schema overview: %w", err)
+	}
+	return &overview, nil
+}
+
+// ModuleDetails returns the module with its direct submodules, data objects and functions.
+// It returns types.ErrNoData if the module is not found or disabled.
+func (s *Service) ModuleDetails(ctx context.Context, name string) (*ModuleDetails, error) {
+	var data struct {
+		Module *struct {
+			Module
+			Function    *Type  `json:"function"`
+			MutFunction *Type  `json:"mut_function"`
+			DataObjects []Type `json:"types_in_module"`
+		} `json:"modules_by_pk"`
+		Modules []Module `json:"modules"`
+	}
+	err := s.queryOne(ctx, `query ($name: String!, $prefix: String!, $dot: [String!]!, $sm: String!, $ttl: Int!) {
+		core {
+			mcp {
+				modules_by_pk(name: $name) @cache(ttl: $ttl) {
+					name
+					description
+					long_description
+					query_root
+					mutation_root
+					function_root
+					mut_function_root
+					is_summarized
+					disabled
+					function {
+						fields(
+							filter: {_not: {hugr_type: {eq: $sm}}}
+							order_by: [{field: "name"}]
+						) {`+resourceFieldsQuery+`}
+					}
+					mut_function {
+						fields(
+							filter: {_not: {hugr_type: {eq: $sm}}}
+							order_by: [{field: "name"}]
+						) {`+resourceFieldsQuery+`}
+					}
+					types_in_module(
+						filter: {hugr_type: {in: $dot}}
+						order_by: [{field: "name"}]
+					) {
+						name
+						description
+						hugr_type
+						catalog
+					}
+				}
+				modules(
+					filter: {
+						name: {like: $prefix}
+						disabled: {eq: false}
+					}
+					order_by: [{field: "name"}]
+				) @cache(ttl: $ttl) {
+					name
+					description
+					is_summarized
+				}
+			}
+		}
+	}`, map[string]any{
+		"name":   name,
+		"prefix": moduleChildrenPrefix(name) + "%",
+		"dot":    []string{string(base.HugrTypeTable), string(base.HugrTypeView)},
+		"sm":     base.HugrTypeFieldSubmodule,
+		"ttl":    s.c.ttl,
+	}, "core.mcp", &data)
+	if err != nil {
+		return nil, fmt.Errorf("query module %q: %w", name, err)
+	}
+	if data.Module == nil || data.Module.Disabled {
+		return nil, types.ErrNoData
+	}
+	md := &ModuleDetails{
+		Module:      data.Module.Module,
+		SubModules:  directSubModules(name, data.Modules),
+		DataObjects: data.Module.DataObjects,
+	}
+	if data.Module.Function != nil {
+		md.Functions = excludeFields(data.Module.Function.Fields)
+	}
+	if data.Module.MutFunction != nil {
+		md.MutFunctions = excludeFields(data.Module.MutFunction.Fields)
+	}
+	return md, nil
+}
+
+// DataObjectDetails returns the data object type with its fields and queries.
+// It returns types.ErrNoData if the data object is not found.
+func (s *Service) DataObjectDetails(ctx context.Context, name string) (*DataObjectDetails, error) {
+	var data struct {
+		FilterType string `json:"filter_type_name"`
+		ArgsType   string `json:"args_type_name"`
+		Type       *Type  `json:"type"`
+		Queries    []struct {
+			Name      string `json:"name"`
+			QueryType string `json:"query_type"`
+			QueryRoot string `json:"query_root"`
+			Field     *Field `json:"field"`
+		} `json:"queries"`
+	}
+	err := s.queryOne(ctx, `query ($name: String!, $ttl: Int!) {
+		core {
+			mcp {
+				data_objects_by_pk(name: $name) @cache(ttl: $ttl) {
+					filter_type_name
+					args_type_name
+					type {
+						name
+						description
+						long_description
+						kind
+						hugr_type
+						catalog
+						module
+						is_summarized
+						fields(order_by: [{field: "name"}]) {`+resourceFieldsQuery+`}
+					}
+					queries(order_by: [{field: "query_type"}, {field: "name"}]) {
+						name
+						query_type
+						query_root
+						field {
+							description
+							arguments {
+								name
+								type
+								description
+								is_list
+								is_non_null
+							}
+						}
+					}
+				}
+			}
+		}
+	}`, map[string]any{
+		"name": name,
+		"ttl":  s.c.ttl,
+	}, "core.mcp.data_objects_by_pk", &data)
+	if err != nil {
+		return nil, fmt.Errorf("query data object %q: %w", name, err)
+	}
+	if data.Type == nil {
+		return nil, types.ErrNoData
+	}
+	do := &DataObjectDetails{
+		Type:       *data.Type,
+		FilterType: data.FilterType,
+		ArgsType:   data.ArgsType,
+	}
+	do.Fields = excludeFields(do.Fields)
+	for _, q := range data.Queries {
+		qd := DataObjectQueryDetails{
+			Name:      q.Name,
+			QueryType: q.QueryType,
+			QueryRoot: q.QueryRoot,
+		}
+		if q.Field != nil {
+			qd.Description = q.Field.Description
+			qd.Arguments = q.Field.Arguments
+		}
+		do.Queries = append(do.Queries, qd)
+	}
+	return do, nil
+}
+
+// FunctionDetails returns the module function (or mutation function) with its arguments and the return type fields.
+// It returns types.ErrNoData if the function is not found.
+func (s *Service) FunctionDetails(ctx context.Context, module, name string) (*FunctionDetails, error) {
+	var data struct {
+		Function    *Type `json:"function"`
+		MutFunction *Type `json:"mut_function"`
+	}
+	err := s.queryOne(ctx, `query ($module: String!, $name: String!, $ttl: Int!) {
+		core {
+			mcp {
+				modules_by_pk(name: $module) @cache(ttl: $ttl) {
+					function {
+						fields(filter: {name: {eq: $name}}) {`+resourceFieldsQuery+`
+							field_type {
+								name
+								description
+								kind
+								hugr_type
+								fields(order_by: [{field: "name"}]) {
+									name
+									description
+									type
+									hugr_type
+									is_list
+									is_non_null
+									mcp_exclude
+								}
+							}
+						}
+					}
+					mut_function {
+						fields(filter: {name: {eq: $name}}) {`+resourceFieldsQuery+`
+							field_type {
+								name
+								description
+								kind
+								hugr_type
+								fields(order_by: [{field: "name"}]) {
+									name
+									description
+									type
+									hugr_type
+									is_list
+									is_non_null
+									mcp_exclude
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}`, map[string]any{
+		"module": module,
+		"name":   name,
+		"ttl":    s.c.ttl,
+	}, "core.mcp.modules_by_pk", &data)
+	if err != nil {
+		return nil, fmt.Errorf("query function %q of module %q: %w", name, module, err)
+	}
+	for i, t := range []*Type{data.Function, data.MutFunction} {
+		if t == nil || len(t.Fields) == 0 {
+			continue
+		}
+		f := t.Fields[0]
+		if f.FieldType != nil {
+			f.FieldType.Fields = excludeFields(f.FieldType.Fields)
+		}
+		return &FunctionDetails{
+			Field:      f,
+			Module:     module,
+			IsMutation: i == 1,
+		}, nil
+	}
+	return nil, types.ErrNoData
+}
+
+// moduleChildrenPrefix returns the name prefix of the module submodules.
+func moduleChildrenPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + "."
+}
+
+// directSubModules filters the first level submodules of the module.
+func directSubModules(name string, mm []Module) []Module {
+	prefix := moduleChildrenPrefix(name)
+	var out []Module
+	for _, m := range mm {
+		rest, ok := strings.CutPrefix(m.Name, prefix)
+		if !ok || rest == "" || strings.Contains(rest, ".") {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// excludeFields removes the fields that are excluded from the MCP.
+func excludeFields(ff []Field) []Field {
+	return slices.DeleteFunc(ff, func(f Field) bool {
+		return f.Exclude
+	})
+}
@@ -0,0 +1,288 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hugr-lab/query-engine/pkg/types"
+)
+
+// SavedQuery is a vetted GraphQL query stored in the library for reuse by the agents.
+type SavedQuery struct {
+	Name            string         `json:"name" jsonschema_description:"Unique name of the saved query"`
+	Description     string         `json:"description" jsonschema_description:"Description of the query: what it returns and when to use it"`
+	Query           string         `json:"query" jsonschema_description:"The GraphQL query text"`
+	OperationName   string         `json:"operation_name,omitempty" jsonschema_description:"The operation to execute if the query contains multiple operations"`
+	VariablesSchema map[string]any `json:"variables_schema,omitempty" jsonschema_description:"JSON schema of the query variables"`
+	Tags            []string       `json:"tags,omitempty" jsonschema_description:"Tags of the query"`
+	Module          string         `json:"module,omitempty" jsonschema_description:"The module that owns the query"`
+	Author          string         `json:"author,omitempty" jsonschema_description:"The author of the query"`
+	CreatedAt       *time.Time     `json:"created_at,omitempty" jsonschema_description:"Creation time"`
+	UpdatedAt       *time.Time     `json:"updated_at,omitempty" jsonschema_description:"Last update time"`
+	Score           float64        `json:"score,omitempty" jsonschema_description:"Relevance score of the query for the search"`
+}
+
+type SearchSavedQueriesRequest struct {
+	Query    string   `json:"query" jsonschema_description:"The natural-language query to search for relevant saved queries"`
+	Module   string   `json:"module,omitempty" jsonschema_description:"Optional module to filter the saved queries"`
+	Tags     []string `json:"tags,omitempty" jsonschema_description:"Optional tags, the saved queries should have all of them"`
+	TopK     int      `json:"top_k" jsonschema_description:"The number of top relevant saved queries to return" jsonschema:"minimum=1,default=5,maximum=50"`
+	MinScore float64  `json:"min_score" jsonschema_description:"Minimum relevance score threshold (between 0 and 1) to filter the results" jsonschema:"minimum=0,maximum=1,default=0"`
+}
+
+const savedQueryFields = `
+	name
+	description
+	query
+	operation_name
+	variables_schema
+	tags
+	module
+	author
+	created_at
+	updated_at
+`
+
+const searchSavedQueriesQuery = `query ($filter: mcp_saved_queries_filter, $limit: Int!) {
+		core {
+			mcp {
+				saved_queries(
+					filter: $filter
+					limit: $limit
+					order_by: [{ field: "name" }]
+				) {` + savedQueryFields + `}
+			}
+		}
+	}`
+
+const searchSavedQueriesQueryWithEmbedding = `query ($filter: mcp_saved_queries_filter, $limit: Int!, $query: String!, $ttl: Int!) {
+		core {
+			mcp {
+				saved_queries(
+					filter: $filter
+					limit: $limit
+					order_by: [{ field: "score" }]
+				) @cache(ttl: $ttl) {` + savedQueryFields + `
+					score: _distance_to_query(query: $query)
+				}
+			}
+		}
+	}`
+
+// SearchSavedQueries returns the saved queries ranked by the relevance to the natural-language query.
+func (s *Service) SearchSavedQueries(ctx context.Context, req *SearchSavedQueriesRequest) (*SearchResult[SavedQuery], error) {
+	if req.TopK < 1 || req.TopK > 50 {
+		req.TopK = 5
+	}
+	filter := map[string]any{}
+	if req.Module != "" {
+		filter["module"] = map[string]any{"eq": req.Module}
+	}
+	if len(req.Tags) != 0 {
+		// the queries should have all the tags
+		filter["tags"] = map[string]any{"contains": req.Tags}
+	}
+	q := searchSavedQueriesQuery
+	vars := map[string]any{
+		// the min score is applied after the ranking
+		"limit": req.TopK * 4,
+	}
+	if len(filter) != 0 {
+		vars["filter"] = filter
+	}
+	embedded := s.c.EmbeddingsEnabled && req.Query != ""
+	if embedded {
+		q = searchSavedQueriesQueryWithEmbedding
+		vars["query"] = req.Query
+		vars["ttl"] = s.c.ttl
+	}
+	res, err := s.h.Query(ctx, q, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search saved queries: %w", err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, fmt.Errorf("failed to search saved queries: %w", res.Err())
+	}
+	var items []SavedQuery
+	err = res.ScanData("core.mcp.saved_queries", &items)
+	if err != nil && !errors.Is(err, types.ErrNoData) {
+		return nil, fmt.Errorf("failed to decode saved queries: %w", err)
+	}
+	out := &SearchResult[SavedQuery]{}
+	for _, it := range items {
+		if embedded {
+			// convert distance to score
+			it.Score = 1 - it.Score
+			if it.Score < req.MinScore {
+				continue
+			}
+		}
+		out.Items = append(out.Items, it)
+	}
+	out.Total = len(out.Items)
+	if len(out.Items) > req.TopK {
+		out.Items = out.Items[:req.TopK]
+	}
+	return out, nil
+}
+
+// SavedQuery returns the saved query by name, it returns types.ErrNoData if the query is not found.
+func (s *Service) SavedQuery(ctx context.Context, name string) (*SavedQuery, error) {
+	var q *SavedQuery
+	err := s.queryOne(ctx, `query ($name: String!) {
+		core {
+			mcp {
+				saved_queries_by_pk(name: $name) {`+savedQueryFields+`}
+			}
+		}
+	}`, map[string]any{"name": name}, "core.mcp.saved_queries_by_pk", &q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get saved query %q: %w", name, err)
+	}
+	if q == nil {
+		return nil, types.ErrNoData
+	}
+	return q, nil
+}
+
+const addSavedQueryMutation = `mutation ($data: mcp_saved_queries_mut_input_data!) {
+		core {
+			mcp {
+				insert_saved_queries(data: $data) {
+					name
+				}
+			}
+		}
+	}`
+
+const addSavedQueryMutationWithEmbedding = `mutation ($data: mcp_saved_queries_mut_input_data!, $summary: String!) {
+		core {
+			mcp {
+				insert_saved_queries(data: $data, summary: $summary) {
+					name
+				}
+			}
+		}
+	}`
+
+const updateSavedQueryMutation = `mutation ($name: String!, $data: mcp_saved_queries_mut_data!) {
+		core {
+			mcp {
+				update_saved_queries(
+					filter: { name: { eq: $name }}
+					data: $data
+				) {
+					success
+				}
+			}
+		}
+	}`
+
+const updateSavedQueryMutationWithEmbedding = `mutation ($name: String!, $data: mcp_saved_queries_mut_data!, $summary: String!) {
+		core {
+			mcp {
+				update_saved_queries(
+					filter: { name: { eq: $name }}
+					data: $data
+					summary: $summary
+				) {
+					success
+				}
+			}
+		}
+	}`
+
+// SaveQuery adds the query to the library or updates the existing one with the same name.
+// The description is embedded for the semantic search if the embeddings are enabled.
+func (s *Service) SaveQuery(ctx context.Context, q SavedQuery) error {
+	if q.Name == "" || q.Query == "" {
+		return errors.New("saved query name and query are required")
+	}
+	existing, err := s.SavedQuery(ctx, q.Name)
+	if err != nil && !errors.Is(err, types.ErrNoData) {
+		return err
+	}
+	data := map[string]any{
+		"description":      q.Description,
+		"query":            q.Query,
+		"operation_name":   q.OperationName,
+		"variables_schema": q.VariablesSchema,
+		"tags":             q.Tags,
+		"module":           q.Module,
+		"author":           q.Author,
+		"updated_at":       time.Now().UTC(),
+	}
+	summary := savedQuerySummary(q)
+	vars := map[string]any{
+		"data":    data,
+		"summary": summary,
+	}
+	var query string
+	switch {
+	case existing == nil && s.c.EmbeddingsEnabled:
+		query = addSavedQueryMutationWithEmbedding
+	case existing == nil:
+		query = addSavedQueryMutation
+	case s.c.EmbeddingsEnabled:
+		query = updateSavedQueryMutationWithEmbedding
+	default:
+		query = updateSavedQueryMutation
+	}
+	if existing == nil {
+		data["name"] = q.Name
+	} else {
+		vars["name"] = q.Name
+	}
+	res, err := s.h.Query(ctx, query, vars)
+	if err != nil {
+		return fmt.Errorf("failed to save query %q: %w", q.Name, err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return fmt.Errorf("failed to save query %q: %w", q.Name, res.Err())
+	}
+	return nil
+}
+
+// DeleteSavedQuery removes the query from the library.
+func (s *Service) DeleteSavedQuery(ctx context.Context, name string) error {
+	res, err := s.h.Query(ctx, `mutation ($name: String!) {
+		core {
+			mcp {
+				delete_saved_queries(filter: { name: { eq: $name }}) {
+					success
+				}
+			}
+		}
+	}`, map[string]any{"name": name})
+	if err != nil {
+		return fmt.Errorf("failed to delete saved query %q: %w", name, err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return fmt.Errorf("failed to delete saved query %q: %w", name, res.Err())
+	}
+	return nil
+}
+
+// savedQuerySummary returns the text to embed for the semantic search.
+func savedQuerySummary(q SavedQuery) string {
+	summary := q.Name
+	if q.Description != "" {
+		summary += ": " + q.Description
+	}
+	if q.Module != "" {
+		summary += "\nModule: " + q.Module
+	}
+	for i, t := range q.Tags {
+		if i == 0 {
+			summary += "\nTags: " + t
+			continue
+		}
+		summary += ", " + t
+	}
+	return summary
+}
@@ -34,6 +34,26 @@ func (s *Service) fetchSummary(ctx context.Context) (*metainfo.SchemaInfo, error
 	return &info, nil
 }
 
+// SchemaSummary returns the cached schema summary: the modules, data objects with the relations and the functions.
+func (s *Service) SchemaSummary(ctx context.Context) (*metainfo.SchemaInfo, error) {
+	var info metainfo.SchemaInfo
+	err := s.queryOne(ctx, `query ($ttl: Int!) {
+		function{
+			core{
+				meta{
+					schema_summary @cache(ttl: $ttl)
+				}
+			}
+		}
+	}`, map[string]any{
+		"ttl": s.c.ttl,
+	}, "function.core.meta.schema_summary", &info)
+	if err != nil {
+		return nil, fmt.Errorf("query schema summary: %w", err)
+	}
+	return &info, nil
+}
+
 func (s *Service) fetchSchema(ctx context.Context) (*SchemaIntro, error) {
 	res, err := s.h.Query(ctx, `query schema {
 		__schema{
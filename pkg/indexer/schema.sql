@@ -91,3 +91,26 @@ CREATE TABLE IF NOT EXISTS data_object_queries (
     query_type TEXT NOT NULL,
     PRIMARY KEY (name, object_name)
 );
+
+CREATE TABLE IF NOT EXISTS saved_queries (
+    name TEXT NOT NULL PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    query TEXT NOT NULL,
+    operation_name TEXT NOT NULL DEFAULT '',
+    variables_schema {{if isPostgres }} JSONB {{ else }} JSON {{ end }},
+    tags TEXT[],
+    module TEXT NOT NULL DEFAULT '',
+    author TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    vec {{if isPostgres }} vector({{ .VectorSize }}) {{ else }} FLOAT[{{ .VectorSize }}] {{ end }} -- query description embedding
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id TEXT NOT NULL PRIMARY KEY,
+    user_id TEXT NOT NULL DEFAULT '',
+    data {{if isPostgres }} JSONB {{ else }} JSON {{ end }},
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
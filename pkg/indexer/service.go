@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hugr-lab/mcp/pkg/pool"
@@ -13,6 +14,7 @@ import (
 	"github.com/hugr-lab/query-engine/pkg/data-sources/sources"
 	"github.com/hugr-lab/query-engine/pkg/db"
 	"github.com/hugr-lab/query-engine/pkg/types"
+	"github.com/vektah/gqlparser/v2/ast"
 
 	_ "embed"
 )
@@ -20,7 +22,7 @@ import (
 //go:embed schema.graphql
 var hschema string
 
-const dbVersion = "0.0.1"
+const dbVersion = "0.0.3"
 const dataSourceName = "core.mcp"
 
 type Config struct {
@@ -36,6 +38,8 @@ type Config struct {
 
 	CacheTTL time.Duration
 	ttl      int // cache ttl in seconds
+
+	Watch WatchConfig
 }
 
 // Indexed storage for hugr schema
@@ -45,6 +49,12 @@ type Service struct {
 
 	is_init bool
 	loaded  bool // types are loaded
+
+	syncMu sync.Mutex // single-flight guard for the schema synchronization
+
+	vmu       sync.Mutex
+	vschema   *ast.Schema // cached schema for the query validation
+	vschemaAt time.Time
 }
 
 func New(config Config, h *hugr.Client) *Service {
@@ -190,7 +200,7 @@ func (s *Service) checkDBVersion(ctx context.Context) error {
 	res, err := s.h.Query(ctx, `query mcp {
 		core{
 			mcp{
-			version_info(limit: 1, order_by: [{field: "applied_at"}]){
+			version_info{
 				version
 				applied_at
 			}
@@ -215,8 +225,16 @@ func (s *Service) checkDBVersion(ctx context.Context) error {
 	if len(info) == 0 {
 		return ErrWrongDBVersion
 	}
-	if dbVersion != info[0].Version {
-		return ErrWrongDBVersion
+	versions := make([]string, 0, len(info))
+	for _, i := range info {
+		versions = append(versions, i.Version)
+	}
+	current := latestVersion(versions)
+	switch c := compareVersions(current, dbVersion); {
+	case c < 0:
+		return fmt.Errorf("%w: index version %s, required %s, run the migrate command", ErrWrongDBVersion, current, dbVersion)
+	case c > 0:
+		return fmt.Errorf("%w: index version %s is newer than supported %s", ErrWrongDBVersion, current, dbVersion)
 	}
 
 	return nil
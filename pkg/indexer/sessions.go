@@ -0,0 +1,138 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/sessions"
+)
+
+// SessionStore keeps the MCP sessions in the index database, the sessions survive the server restarts.
+type SessionStore struct {
+	s *Service
+}
+
+var _ sessions.Store = (*SessionStore)(nil)
+
+// SessionStore returns the persistent sessions store.
+func (s *Service) SessionStore() *SessionStore {
+	return &SessionStore{s: s}
+}
+
+func (st *SessionStore) Get(ctx context.Context, id string) (*sessions.Session, error) {
+	var row *struct {
+		Data *sessions.Session `json:"data"`
+	}
+	err := st.s.queryOne(ctx, `query ($id: String!) {
+		core {
+			mcp {
+				sessions_by_pk(id: $id) {
+					data
+				}
+			}
+		}
+	}`, map[string]any{"id": id}, "core.mcp.sessions_by_pk", &row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if row == nil || row.Data == nil {
+		return nil, sessions.ErrNotFound
+	}
+	return row.Data, nil
+}
+
+// Put saves the session: the existing session is updated, the new one is inserted.
+// The insert fails if the concurrent request has inserted the same session, then the session is updated.
+func (st *SessionStore) Put(ctx context.Context, ss *sessions.Session) error {
+	data := map[string]any{
+		"user_id":    ss.UserID,
+		"data":       ss,
+		"updated_at": ss.UpdatedAt,
+		"expires_at": ss.ExpiresAt,
+	}
+	updated, err := st.update(ctx, ss.ID, data)
+	if err != nil || updated {
+		return err
+	}
+	insert := maps.Clone(data)
+	insert["id"] = ss.ID
+	insert["created_at"] = ss.CreatedAt
+	ierr := st.s.exec(ctx, `mutation ($data: mcp_sessions_mut_input_data!) {
+		core {
+			mcp {
+				insert_sessions(data: $data) {
+					id
+				}
+			}
+		}
+	}`, map[string]any{"data": insert}, "failed to save session")
+	if ierr == nil {
+		return nil
+	}
+	updated, err = st.update(ctx, ss.ID, data)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ierr
+	}
+	return nil
+}
+
+// update updates the session data, it returns false if the session does not exist.
+func (st *SessionStore) update(ctx context.Context, id string, data map[string]any) (bool, error) {
+	var res struct {
+		AffectedRows int `json:"affected_rows"`
+	}
+	err := st.s.queryOne(ctx, `mutation ($id: String!, $data: mcp_sessions_mut_data!) {
+		core {
+			mcp {
+				update_sessions(filter: { id: { eq: $id }}, data: $data) {
+					affected_rows
+				}
+			}
+		}
+	}`, map[string]any{"id": id, "data": data}, "core.mcp.update_sessions", &res)
+	if err != nil {
+		return false, fmt.Errorf("failed to save session: %w", err)
+	}
+	return res.AffectedRows != 0, nil
+}
+
+func (st *SessionStore) Delete(ctx context.Context, id string) error {
+	return st.s.exec(ctx, `mutation ($id: String!) {
+		core {
+			mcp {
+				delete_sessions(filter: { id: { eq: $id }}) {
+					success
+				}
+			}
+		}
+	}`, map[string]any{"id": id}, "failed to delete session")
+}
+
+func (st *SessionStore) DeleteExpired(ctx context.Context, before time.Time) error {
+	return st.s.exec(ctx, `mutation ($before: Timestamp!) {
+		core {
+			mcp {
+				delete_sessions(filter: { expires_at: { lt: $before }}) {
+					success
+				}
+			}
+		}
+	}`, map[string]any{"before": before.UTC()}, "failed to delete expired sessions")
+}
+
+func (s *Service) exec(ctx context.Context, query string, vars map[string]any, msg string) error {
+	res, err := s.h.Query(ctx, query, vars)
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return fmt.Errorf("%s: %w", msg, res.Err())
+	}
+	return nil
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
 	"github.com/hugr-lab/mcp/pkg/summary"
+	"github.com/hugr-lab/query-engine/pkg/compiler/base"
 	"github.com/hugr-lab/query-engine/pkg/types"
 	"golang.org/x/sync/errgroup"
 )
@@ -30,12 +30,12 @@ func (s *Service) Summarize(ctx context.Context) error {
 	eg.SetLimit(s.c.Summarize.MaxConnections)
 	for _, t := range objects {
 		eg.Go(func() error {
-			log.Printf("data object %s: scheduling summarization", t.Name)
+			logf(ctx, "data object %s: scheduling summarization", t.Name)
 			err := s.SummarizeDataObject(ctxg, sum, meta, t)
 			if err != nil {
-				log.Printf("data object %s: failed to summarize data object: %v", t.Name, err)
+				logf(ctx, "data object %s: failed to summarize data object: %v", t.Name, err)
 			}
-			log.Printf("data object %s: summarization scheduled", t.Name)
+			logf(ctx, "data object %s: summarization scheduled", t.Name)
 			return nil
 		})
 	}
@@ -44,12 +44,12 @@ func (s *Service) Summarize(ctx context.Context) error {
 	functions, err := s.FunctionFieldsForSummary(ctxg)
 	for _, f := range functions {
 		eg.Go(func() error {
-			log.Printf("function %s: scheduling summarization", f.Name)
+			logf(ctx, "function %s: scheduling summarization", f.Name)
 			err := s.SummarizeFunction(ctxg, sum, meta, f)
 			if err != nil {
-				log.Printf("function %s: failed to summarize function: %v", f.Name, err)
+				logf(ctx, "function %s: failed to summarize function: %v", f.Name, err)
 			}
-			log.Printf("function %s: summarization scheduled", f.Name)
+			logf(ctx, "function %s: summarization scheduled", f.Name)
 			return nil
 		})
 	}
@@ -58,7 +58,7 @@ func (s *Service) Summarize(ctx context.Context) error {
 		return fmt.Errorf("failed to summarize data objects and functions: %w", err)
 	}
 
-	log.Println("Data objects and functions summarization completed")
+	logf(ctx, "Data objects and functions summarization completed")
 
 	// 4. Summarize Data Sources
 	eg, ctxg = errgroup.WithContext(ctx)
@@ -69,12 +69,12 @@ func (s *Service) Summarize(ctx context.Context) error {
 	}
 	for _, ds := range dataSources {
 		eg.Go(func() error {
-			log.Printf("data source %s: scheduling summarization", ds)
+			logf(ctx, "data source %s: scheduling summarization", ds)
 			err := s.SummarizeDataSource(ctxg, sum, ds)
 			if err != nil {
-				log.Printf("data source %s: failed to summarize data source: %v", ds, err)
+				logf(ctx, "data source %s: failed to summarize data source: %v", ds, err)
 			}
-			log.Printf("data source %s: summarization scheduled", ds)
+			logf(ctx, "data source %s: summarization scheduled", ds)
 			return nil
 		})
 	}
@@ -87,7 +87,7 @@ func (s *Service) Summarize(ctx context.Context) error {
 	eg.SetLimit(s.c.Summarize.MaxConnections)
 	modules, err := s.modulesForSummary(ctxg)
 	if errors.Is(err, types.ErrNoData) {
-		log.Println("No modules to summarize")
+		logf(ctx, "No modules to summarize")
 		return nil
 	}
 	if err != nil {
@@ -95,12 +95,12 @@ func (s *Service) Summarize(ctx context.Context) error {
 	}
 	for _, m := range modules {
 		eg.Go(func() error {
-			log.Printf("module %s: scheduling summarization", m)
+			logf(ctx, "module %s: scheduling summarization", m)
 			err := s.SummarizeModule(ctxg, sum, m)
 			if err != nil {
-				log.Printf("module %s: failed to summarize module: %v", m, err)
+				logf(ctx, "module %s: failed to summarize module: %v", m, err)
 			}
-			log.Printf("module %s: summarization scheduled", m)
+			logf(ctx, "module %s: summarization scheduled", m)
 			return nil
 		})
 	}
@@ -108,6 +108,57 @@ func (s *Service) Summarize(ctx context.Context) error {
 	return eg.Wait()
 }
 
+// SummarizeDataObjectByName summarizes the data object (table or view) by its type name.
+func (s *Service) SummarizeDataObjectByName(ctx context.Context, name string) error {
+	res, err := s.h.Query(ctx, `query ($name: String!) {
+		core {
+			mcp {
+				types_by_pk(name: $name) {
+					name
+					module
+					hugr_type
+					description
+				}
+			}
+		}
+	}`, map[string]any{
+		"name": name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get data object type: %w", err)
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return fmt.Errorf("failed to get data object type: %w", res.Err())
+	}
+	var t Type
+	err = res.ScanData("core.mcp.types_by_pk", &t)
+	if errors.Is(err, types.ErrNoData) {
+		return fmt.Errorf("data object %s not found", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode data object type: %w", err)
+	}
+	if t.HugrType != base.HugrTypeTable && t.HugrType != base.HugrTypeView {
+		return fmt.Errorf("type %s is not a data object", name)
+	}
+	meta, err := s.fetchSummary(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch meta summary: %w", err)
+	}
+	return s.SummarizeDataObject(ctx, summary.New(s.c.Summarize), meta, t)
+}
+
+// SummarizeModuleByName summarizes the module by its name.
+func (s *Service) SummarizeModuleByName(ctx context.Context, name string) error {
+	return s.SummarizeModule(ctx, summary.New(s.c.Summarize), name)
+}
+
+// SummarizeDataSourceByName summarizes the data source by its name.
+func (s *Service) SummarizeDataSourceByName(ctx context.Context, name string) error {
+	return s.SummarizeDataSource(ctx, summary.New(s.c.Summarize), name)
+}
+
 const updateDataSourceDescriptionQuery = `mutation ($name: String!, $desc: String!, $long: String!, $isSummarized: Boolean!) {
 		core {
 			mcp {
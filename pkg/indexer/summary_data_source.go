@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 
 	"github.com/hugr-lab/mcp/pkg/summary"
 	"github.com/hugr-lab/query-engine/pkg/compiler/base"
@@ -138,7 +137,7 @@ func (s *Service) dataSourceForSummary(ctx context.Context, name string) (*summa
 
 	err = res.ScanData("core.mcp.modules", &dss.Submodules)
 	if errors.Is(err, types.ErrNoData) {
-		log.Println("No data objects to summarize")
+		logf(ctx, "No data objects to summarize")
 		return nil, nil
 	}
 	if err != nil {
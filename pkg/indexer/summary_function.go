@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/hugr-lab/mcp/pkg/summary"
@@ -50,7 +49,7 @@ func (s *Service) FunctionFieldsForSummary(ctx context.Context) ([]Field, error)
 	var fields []Field
 	err = res.ScanData("core.mcp.fields", &fields)
 	if errors.Is(err, types.ErrNoData) {
-		log.Println("No functions to summarize")
+		logf(ctx, "No functions to summarize")
 		return nil, nil
 	}
 	if err != nil {
@@ -80,12 +79,12 @@ func (s *Service) SummarizeFunction(ctx context.Context, sum *summary.Service, m
 	}
 	// 3. Get Summary
 	start := time.Now()
-	log.Printf("function %s: summarization start", path)
+	logf(ctx, "function %s: summarization start", path)
 	fs, err := sum.SummarizeFunction(ctx, meta, fi)
 	if err != nil {
 		return fmt.Errorf("function %s failed to get summary: %w", path, err)
 	}
-	log.Printf("function %s: summarization completed in %s", path, time.Since(start))
+	logf(ctx, "function %s: summarization completed in %s", path, time.Since(start))
 
 	// 4. update function field desc
 	if err := s.UpdateFieldDescription(ctx, f.TypeName, f.Name, fs.Long, true); err != nil {
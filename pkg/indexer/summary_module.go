@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"slices"
 	"sort"
 	"strings"
@@ -80,12 +79,12 @@ func (s *Service) SummarizeModule(ctx context.Context, sum *summary.Service, nam
 	})
 
 	start := time.Now()
-	log.Printf("module %s: summarization start", name)
+	logf(ctx, "module %s: summarization start", name)
 	ms, err := sum.SummarizeModule(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to summarize module %s: %w", name, err)
 	}
-	log.Printf("module %s: summarization completed in %v", name, time.Since(start))
+	logf(ctx, "module %s: summarization completed in %v", name, time.Since(start))
 
 	// update module types
 	if mm.QueryRoot != "" && ms.QueryType != "" {
@@ -273,7 +272,7 @@ func (s *Service) moduleForSummary(ctx context.Context, name string) (*moduleFor
 	var module moduleForSummary
 	err = res.ScanData("core.mcp.modules_by_pk", &module)
 	if errors.Is(err, types.ErrNoData) {
-		log.Println("No modules to summarize")
+		logf(ctx, "No modules to summarize")
 		return nil, nil
 	}
 	if err != nil {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/hugr-lab/mcp/pkg/summary"
@@ -45,7 +44,7 @@ func (s *Service) DataObjectTypesForSummary(ctx context.Context) ([]Type, error)
 	var dataObjects []Type
 	err = res.ScanData("core.mcp.types", &dataObjects)
 	if errors.Is(err, types.ErrNoData) {
-		log.Println("No data objects to summarize")
+		logf(ctx, "No data objects to summarize")
 		return nil, nil
 	}
 	if err != nil {
@@ -67,15 +66,15 @@ func (s *Service) SummarizeDataObject(ctx context.Context, sum *summary.Service,
 		do = meta.View(path)
 	}
 	if do == nil {
-		log.Printf("Skipping summary for %s: not found", path)
+		logf(ctx, "Skipping summary for %s: not found", path)
 	}
 	start := time.Now()
-	log.Printf("data object %s: summarization start", path)
+	logf(ctx, "data object %s: summarization start", path)
 	ds, err := sum.SummarizeDataObject(ctx, meta, do)
 	if err != nil {
 		return fmt.Errorf("failed to summarize data object %s: %w", path, err)
 	}
-	log.Printf("data object %s: Summary complete in %v", path, time.Since(start))
+	logf(ctx, "data object %s: Summary complete in %v", path, time.Since(start))
 
 	// update types
 	// 2. Update fields descriptions
@@ -139,7 +138,7 @@ func (s *Service) SummarizeDataObject(ctx context.Context, sum *summary.Service,
 		for _, ref := range do.References {
 			rs, ok := ds.References[ref.Name]
 			if !ok {
-				log.Printf("data object %s: Reference %s not found", t.Name, ref.Name)
+				logf(ctx, "data object %s: Reference %s not found", t.Name, ref.Name)
 				continue
 			}
 			// 4.1. Update data query field
@@ -185,7 +184,7 @@ func (s *Service) SummarizeDataObject(ctx context.Context, sum *summary.Service,
 		for _, sq := range do.Subqueries {
 			sqs, ok := ds.SubQueries[sq.Name]
 			if !ok {
-				log.Printf("data object %s: Subquery %s not found", t.Name, sq.Name)
+				logf(ctx, "data object %s: Subquery %s not found", t.Name, sq.Name)
 				continue
 			}
 			// 5.1. Update data query field
@@ -250,7 +249,7 @@ func (s *Service) SummarizeDataObject(ctx context.Context, sum *summary.Service,
 		return fmt.Errorf("data object %s: Failed to get module by name: %w", t.Name, err)
 	}
 	if ds.Queries == nil {
-		log.Printf("data object %s: No queries to update", t.Name)
+		logf(ctx, "data object %s: No queries to update", t.Name)
 	}
 	if ds.Queries != nil {
 		var dsPrefix string
@@ -264,7 +263,7 @@ func (s *Service) SummarizeDataObject(ctx context.Context, sum *summary.Service,
 		for _, sq := range do.Queries {
 			desc, ok := ds.Queries[sq.Name]
 			if !ok {
-				log.Printf("data object %s: Query %s not found", t.Name, sq.Name)
+				logf(ctx, "data object %s: Query %s not found", t.Name, sq.Name)
 				continue
 			}
 			// 7.1. Update module queries
@@ -343,7 +342,7 @@ func (s *Service) SummarizeDataObject(ctx context.Context, sum *summary.Service,
 		}
 	}
 
-	log.Println("data object", t.Name, "updated successfully")
+	logf(ctx, "data object %s updated successfully", t.Name)
 
 	return nil
 }
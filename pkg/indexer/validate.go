@@ -0,0 +1,419 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	"github.com/vektah/gqlparser/v2/validator/core"
+	"github.com/vektah/gqlparser/v2/validator/rules"
+)
+
+// ValidationResult is the result of the query validation against the hugr schema.
+type ValidationResult struct {
+	Valid  bool              `json:"valid" jsonschema_description:"Whether the query is valid"`
+	Errors []ValidationError `json:"errors,omitempty" jsonschema_description:"The validation errors"`
+}
+
+type ValidationError struct {
+	Message     string   `json:"message" jsonschema_description:"The error message"`
+	Rule        string   `json:"rule,omitempty" jsonschema_description:"The validation rule (empty for the syntax errors)"`
+	Line        int      `json:"line,omitempty" jsonschema_description:"The line of the error in the query"`
+	Column      int      `json:"column,omitempty" jsonschema_description:"The column of the error in the query"`
+	Path        string   `json:"path,omitempty" jsonschema_description:"The path of the offending field in the query (aliases are used as names)"`
+	Suggestions []string `json:"suggestions,omitempty" jsonschema_description:"Suggested names for the unknown fields or arguments"`
+}
+
+// the default validation schema cache time
+const validationSchemaTTL = time.Minute
+
+// ValidateQuery parses the query and validates it against the hugr schema without executing.
+// The suggestions for the unknown fields and arguments are drawn from the index.
+func (s *Service) ValidateQuery(ctx context.Context, query string) (*ValidationResult, error) {
+	schema, err := s.validationSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return validateQuery(schema, query, func(typeName, fieldName string) []string {
+		names, err := s.indexedNames(ctx, typeName, fieldName)
+		if err != nil || len(names) == 0 {
+			// the type is not indexed (e.g. excluded module), use the schema definition
+			return schemaNames(schema, typeName, fieldName)
+		}
+		return names
+	}), nil
+}
+
+// schemaNames returns the field names of the type or the argument names of the field from the schema.
+func schemaNames(schema *ast.Schema, typeName, fieldName string) []string {
+	def := schema.Types[typeName]
+	if def == nil {
+		return nil
+	}
+	var names []string
+	if fieldName == "" {
+		for _, f := range def.Fields {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+	if f := def.Fields.ForName(fieldName); f != nil {
+		for _, a := range f.Arguments {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+// validationSchema returns the cached schema for the validation, it is reloaded after the cache TTL.
+func (s *Service) validationSchema(ctx context.Context) (*ast.Schema, error) {
+	s.vmu.Lock()
+	defer s.vmu.Unlock()
+	ttl := s.c.CacheTTL
+	if ttl <= 0 {
+		ttl = validationSchemaTTL
+	}
+	if s.vschema != nil && time.Since(s.vschemaAt) < ttl {
+		return s.vschema, nil
+	}
+	intro, err := s.fetchValidationSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	schema, err := buildValidationSchema(intro)
+	if err != nil {
+		return nil, err
+	}
+	s.vschema, s.vschemaAt = schema, time.Now()
+	return schema, nil
+}
+
+// indexedNames returns the field names of the type or the argument names of the field if it is set.
+func (s *Service) indexedNames(ctx context.Context, typeName, fieldName string) ([]string, error) {
+	var data struct {
+		Fields []struct {
+			Name    string `json:"name"`
+			Exclude bool   `json:"mcp_exclude"`
+		} `json:"fields"`
+		Arguments []struct {
+			Name string `json:"name"`
+		} `json:"arguments"`
+	}
+	err := s.queryOne(ctx, `query ($type: String!, $field: String!, $args: Boolean!, $ttl: Int!) {
+		core {
+			mcp {
+				fields(filter: {type_name: {eq: $type}}) @skip(if: $args) @cache(ttl: $ttl) {
+					name
+					mcp_exclude
+				}
+				arguments(filter: {type_name: {eq: $type}, field_name: {eq: $field}}) @include(if: $args) @cache(ttl: $ttl) {
+					name
+				}
+			}
+		}
+	}`, map[string]any{
+		"type":  typeName,
+		"field": fieldName,
+		"args":  fieldName != "",
+		"ttl":   s.c.ttl,
+	}, "core.mcp", &data)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range data.Fields {
+		if !f.Exclude {
+			names = append(names, f.Name)
+		}
+	}
+	for _, a := range data.Arguments {
+		names = append(names, a.Name)
+	}
+	return names, nil
+}
+
+var (
+	unknownFieldRe    = regexp.MustCompile(`^Cannot query field "([^"]+)" on type "([^"]+)"`)
+	unknownArgumentRe = regexp.MustCompile(`^Unknown argument "([^"]+)" on field "([^".]+)\.([^"]+)"`)
+)
+
+// validateQuery validates the query, names returns the known field names of the type
+// or argument names of the field (if fieldName is set) for the suggestions.
+func validateQuery(schema *ast.Schema, query string, names func(typeName, fieldName string) []string) *ValidationResult {
+	doc, err := parser.ParseQuery(&ast.Source{Name: "query", Input: query})
+	if err != nil {
+		return &ValidationResult{Errors: validationErrors(nil, gqlErrors(err), nil)}
+	}
+	errs := validator.ValidateWithRules(schema, doc, rules.NewDefaultRules())
+	if len(errs) == 0 {
+		return &ValidationResult{Valid: true}
+	}
+	return &ValidationResult{Errors: validationErrors(doc, errs, names)}
+}
+
+func gqlErrors(err error) gqlerror.List {
+	var list gqlerror.List
+	if errors.As(err, &list) {
+		return list
+	}
+	var ge *gqlerror.Error
+	if errors.As(err, &ge) {
+		return gqlerror.List{ge}
+	}
+	return gqlerror.List{gqlerror.Wrap(err)}
+}
+
+func validationErrors(doc *ast.QueryDocument, errs gqlerror.List, names func(typeName, fieldName string) []string) []ValidationError {
+	out := make([]ValidationError, 0, len(errs))
+	for _, e := range errs {
+		ve := ValidationError{
+			Message: e.Message,
+			Rule:    e.Rule,
+		}
+		if len(e.Locations) != 0 {
+			ve.Line, ve.Column = e.Locations[0].Line, e.Locations[0].Column
+			if doc != nil {
+				ve.Path = selectionPath(doc, ve.Line, ve.Column)
+			}
+		}
+		if names != nil {
+			if m := unknownFieldRe.FindStringSubmatch(e.Message); m != nil {
+				ve.Suggestions = core.SuggestionList(m[1], names(m[2], ""))
+			}
+			if m := unknownArgumentRe.FindStringSubmatch(e.Message); m != nil {
+				ve.Suggestions = core.SuggestionList(m[1], names(m[2], m[3]))
+			}
+		}
+		out = append(out, ve)
+	}
+	return out
+}
+
+// selectionPath returns the dotted path of the field at the position,
+// the path starts with the operation name (or type) or the fragment name.
+func selectionPath(doc *ast.QueryDocument, line, column int) string {
+	for _, op := range doc.Operations {
+		root := op.Name
+		if root == "" {
+			root = string(op.Operation)
+		}
+		if p, ok := findSelection(op.SelectionSet, []string{root}, line, column); ok {
+			return strings.Join(p, ".")
+		}
+	}
+	for _, f := range doc.Fragments {
+		if p, ok := findSelection(f.SelectionSet, []string{f.Name}, line, column); ok {
+			return strings.Join(p, ".")
+		}
+	}
+	return ""
+}
+
+func findSelection(ss ast.SelectionSet, path []string, line, column int) ([]string, bool) {
+	for _, sel := range ss {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			p := append(slices.Clone(path), sel.Alias)
+			if sel.Position != nil && sel.Position.Line == line && sel.Position.Column == column {
+				return p, true
+			}
+			for _, arg := range sel.Arguments {
+				if arg.Position != nil && arg.Position.Line == line && arg.Position.Column == column {
+					return append(p, arg.Name), true
+				}
+			}
+			if res, ok := findSelection(sel.SelectionSet, p, line, column); ok {
+				return res, true
+			}
+		case *ast.InlineFragment:
+			if res, ok := findSelection(sel.SelectionSet, path, line, column); ok {
+				return res, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// validationSchemaIntro is the full introspection of the schema for the validation.
+type validationSchemaIntro struct {
+	QueryType    *TypeRefIntro              `json:"queryType"`
+	MutationType *TypeRefIntro              `json:"mutationType"`
+	Types        []validationTypeIntro      `json:"types"`
+	Directives   []validationDirectiveIntro `json:"directives"`
+}
+
+type validationTypeIntro struct {
+	TypeIntro
+	InputFields   []ArgIntro     `json:"inputFields"` // with the default values
+	Interfaces    []TypeRefIntro `json:"interfaces"`
+	PossibleTypes []TypeRefIntro `json:"possibleTypes"`
+}
+
+type validationDirectiveIntro struct {
+	Name      string     `json:"name"`
+	Locations []string   `json:"locations"`
+	Args      []ArgIntro `json:"args"`
+}
+
+// the type reference with the nesting enough for the list of non-null lists
+const validationTypeRef = `type { name kind ofType { name kind ofType { name kind ofType { name kind ofType { name kind ofType { name kind ofType { name kind } } } } } } }`
+
+func (s *Service) fetchValidationSchema(ctx context.Context) (*validationSchemaIntro, error) {
+	var schema validationSchemaIntro
+	err := s.queryOne(ctx, `query {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			types {
+				name
+				kind
+				interfaces { name }
+				possibleTypes { name }
+				enumValues { name }
+				inputFields { name defaultValue `+validationTypeRef+` }
+				fields {
+					name
+					args { name defaultValue `+validationTypeRef+` }
+					`+validationTypeRef+`
+				}
+			}
+			directives {
+				name
+				locations
+				args { name defaultValue `+validationTypeRef+` }
+			}
+		}
+	}`, nil, "__schema", &schema)
+	if err != nil {
+		return nil, err
+	}
+	return &schema, nil
+}
+
+// buildValidationSchema converts the introspection to SDL and loads it with the gqlparser prelude.
+func buildValidationSchema(intro *validationSchemaIntro) (*ast.Schema, error) {
+	prelude, err := parser.ParseSchema(validator.Prelude)
+	if err != nil {
+		return nil, err
+	}
+	builtin := map[string]bool{}
+	for _, d := range prelude.Definitions {
+		builtin[d.Name] = true
+	}
+	for _, d := range prelude.Directives {
+		builtin["@"+d.Name] = true
+	}
+
+	var sb strings.Builder
+	if intro.QueryType != nil {
+		sb.WriteString("schema {\n  query: " + intro.QueryType.Name + "\n")
+		if intro.MutationType != nil && intro.MutationType.Name != "" {
+			sb.WriteString("  mutation: " + intro.MutationType.Name + "\n")
+		}
+		sb.WriteString("}\n\n")
+	}
+	for _, d := range intro.Directives {
+		if builtin["@"+d.Name] || len(d.Locations) == 0 {
+			continue
+		}
+		sb.WriteString("directive @" + d.Name)
+		writeSDLArgs(&sb, d.Args)
+		sb.WriteString(" on " + strings.Join(d.Locations, " | ") + "\n")
+	}
+	for _, t := range intro.Types {
+		if builtin[t.Name] || strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		switch t.Kind {
+		case "SCALAR":
+			sb.WriteString("scalar " + t.Name + "\n")
+		case "ENUM":
+			sb.WriteString("enum " + t.Name + " {")
+			for _, v := range t.EnumValues {
+				sb.WriteString(" " + v.Name)
+			}
+			sb.WriteString(" }\n")
+		case "UNION":
+			var members []string
+			for _, p := range t.PossibleTypes {
+				members = append(members, p.Name)
+			}
+			sb.WriteString("union " + t.Name + " = " + strings.Join(members, " | ") + "\n")
+		case "INPUT_OBJECT":
+			sb.WriteString("input " + t.Name + " {\n")
+			for _, f := range t.InputFields {
+				sb.WriteString("  " + f.Name + ": " + sdlTypeRef(&f.Type))
+				if f.DefaultValue != "" {
+					sb.WriteString(" = " + f.DefaultValue)
+				}
+				sb.WriteString("\n")
+			}
+			sb.WriteString("}\n")
+		case "OBJECT", "INTERFACE":
+			if t.Kind == "OBJECT" {
+				sb.WriteString("type " + t.Name)
+			} else {
+				sb.WriteString("interface " + t.Name)
+			}
+			if len(t.Interfaces) != 0 {
+				var ii []string
+				for _, i := range t.Interfaces {
+					ii = append(ii, i.Name)
+				}
+				sb.WriteString(" implements " + strings.Join(ii, " & "))
+			}
+			sb.WriteString(" {\n")
+			for _, f := range t.Fields {
+				sb.WriteString("  " + f.Name)
+				writeSDLArgs(&sb, f.Args)
+				sb.WriteString(": " + sdlTypeRef(&f.Type) + "\n")
+			}
+			sb.WriteString("}\n")
+		}
+	}
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "hugr.graphql", Input: sb.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema for validation: %w", err)
+	}
+	return schema, nil
+}
+
+func writeSDLArgs(sb *strings.Builder, args []ArgIntro) {
+	if len(args) == 0 {
+		return
+	}
+	sb.WriteString("(")
+	for i, a := range args {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(a.Name + ": " + sdlTypeRef(&a.Type))
+		if a.DefaultValue != "" {
+			sb.WriteString(" = " + a.DefaultValue)
+		}
+	}
+	sb.WriteString(")")
+}
+
+// sdlTypeRef returns the type reference in the SDL notation, e.g. [String!]!
+func sdlTypeRef(t *TypeRefIntro) string {
+	if t == nil {
+		return "String"
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return sdlTypeRef(t.OfType) + "!"
+	case "LIST":
+		return "[" + sdlTypeRef(t.OfType) + "]"
+	}
+	return t.Name
+}
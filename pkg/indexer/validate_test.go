@@ -0,0 +1,74 @@
+package indexer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateQuery(t *testing.T) {
+	intField := TypeRefIntro{Name: "Int", Kind: "SCALAR"}
+	strField := TypeRefIntro{Name: "String", Kind: "SCALAR"}
+	schema, err := buildValidationSchema(&validationSchemaIntro{
+		QueryType: &TypeRefIntro{Name: "Query"},
+		Types: []validationTypeIntro{
+			{TypeIntro: TypeIntro{Name: "Query", Kind: "OBJECT", Fields: []FieldIntro{
+				{Name: "orders", Type: TypeRefIntro{Kind: "NON_NULL", OfType: &TypeRefIntro{Kind: "LIST", OfType: &TypeRefIntro{Kind: "NON_NULL", OfType: &TypeRefIntro{Name: "orders", Kind: "OBJECT"}}}},
+					Args: []ArgIntro{
+						{Name: "limit", Type: intField},
+						{Name: "filter", Type: TypeRefIntro{Name: "orders_filter", Kind: "INPUT_OBJECT"}},
+					},
+				},
+			}}},
+			{TypeIntro: TypeIntro{Name: "orders", Kind: "OBJECT", Fields: []FieldIntro{
+				{Name: "id", Type: intField},
+				{Name: "status", Type: strField},
+			}}},
+			{TypeIntro: TypeIntro{Name: "orders_filter", Kind: "INPUT_OBJECT"}, InputFields: []ArgIntro{
+				{Name: "status", Type: strField},
+				{Name: "strict", Type: TypeRefIntro{Kind: "NON_NULL", OfType: &TypeRefIntro{Name: "Boolean", Kind: "SCALAR"}}, DefaultValue: "false"},
+			}},
+		},
+		Directives: []validationDirectiveIntro{
+			{Name: "cache", Locations: []string{"FIELD", "QUERY"}, Args: []ArgIntro{{Name: "ttl", Type: intField}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("build schema: %v", err)
+	}
+	names := func(typeName, fieldName string) []string {
+		if fieldName != "" {
+			return schemaNames(schema, typeName, fieldName)
+		}
+		// index names are used for the suggestions
+		return []string{"id", "status", "total"}
+	}
+
+	res := validateQuery(schema, `query { orders(limit: 10, filter: {status: "new"}) @cache(ttl: 60) { id status } }`, names)
+	if !res.Valid {
+		t.Fatalf("expected valid query, got %+v", res.Errors)
+	}
+
+	res = validateQuery(schema, "query q {\n  o: orders(limt: 10) {\n    id\n    totl\n  }\n}", names)
+	if res.Valid || len(res.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %+v", res.Errors)
+	}
+	for _, e := range res.Errors {
+		switch e.Rule {
+		case "FieldsOnCorrectType":
+			if e.Line != 4 || e.Column != 5 || e.Path != "q.o.totl" || !slices.Equal(e.Suggestions, []string{"total"}) {
+				t.Errorf("unknown field error = %+v", e)
+			}
+		case "KnownArgumentNames":
+			if e.Line != 2 || e.Path != "q.o" || !slices.Equal(e.Suggestions, []string{"limit"}) {
+				t.Errorf("unknown argument error = %+v", e)
+			}
+		default:
+			t.Errorf("unexpected error %+v", e)
+		}
+	}
+
+	res = validateQuery(schema, "query {\n  orders {", names)
+	if res.Valid || len(res.Errors) != 1 || res.Errors[0].Line != 2 || res.Errors[0].Rule != "" {
+		t.Errorf("syntax error = %+v", res.Errors)
+	}
+}
@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrSyncInProgress is returned if the schema synchronization is already running.
+var ErrSyncInProgress = errors.New("schema synchronization is already in progress")
+
+type WatchConfig struct {
+	// Interval between the schema checks, the watcher is disabled if it is zero.
+	Interval time.Duration
+	// MaxBackoff limits the delay after failed checks (default 10 intervals).
+	MaxBackoff time.Duration
+	// Jitter is a random part of the delay (0..1), 0 disables the jitter, negative - the default 0.1.
+	Jitter float64
+	// Summarize the changed items after the synchronization.
+	// New and changed items are embedded on load if the embeddings are enabled.
+	Summarize bool
+}
+
+// Watch checks the hugr schema for changes until the context is canceled.
+// If the schema hash is changed the index is synchronized with the schema (see SyncSchema).
+func (s *Service) Watch(ctx context.Context) {
+	wc := s.c.Watch
+	if wc.Interval <= 0 {
+		return
+	}
+	log.Printf("schema watcher: started with interval %s", wc.Interval)
+	var last string
+	failures := 0
+	for {
+		hash, err := s.watchCheck(ctx, last)
+		switch {
+		case errors.Is(err, ErrSyncInProgress):
+			log.Printf("schema watcher: %v", err)
+		case err != nil:
+			failures++
+			log.Printf("schema watcher: check failed (%d): %v", failures, err)
+		default:
+			failures = 0
+			last = hash
+		}
+		select {
+		case <-ctx.Done():
+			log.Printf("schema watcher: stopped")
+			return
+		case <-time.After(watchDelay(wc, failures, rand.Float64())):
+		}
+	}
+}
+
+// watchCheck returns the current schema hash, the index is synchronized if it differs from the last one.
+func (s *Service) watchCheck(ctx context.Context, last string) (string, error) {
+	hash, err := s.schemaHash(ctx)
+	if err != nil {
+		return "", err
+	}
+	if hash == last {
+		return hash, nil
+	}
+	diff, err := s.SyncSchema(ctx, false)
+	if err != nil {
+		return "", err
+	}
+	if !diff.IsEmpty() {
+		log.Printf("schema watcher: index synchronized, %d changes", len(diff.Changes))
+	}
+	if s.c.Watch.Summarize {
+		// not summarized items are summarized also after the failed attempts
+		err = s.Summarize(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize changes: %w", err)
+		}
+	}
+	return hash, nil
+}
+
+// schemaHash returns the hash of the hugr schema introspection and meta summary.
+func (s *Service) schemaHash(ctx context.Context) (string, error) {
+	schema, err := s.fetchSchema(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	meta, err := s.fetchSummary(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch meta summary: %w", err)
+	}
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	if err := enc.Encode(schema); err != nil {
+		return "", err
+	}
+	if err := enc.Encode(meta); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// watchDelay returns the delay before the next check, it grows exponentially with the failures
+// up to the max backoff, r is a random value in [0, 1) for the jitter.
+func watchDelay(wc WatchConfig, failures int, r float64) time.Duration {
+	maxBackoff := wc.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * wc.Interval
+	}
+	jitter := wc.Jitter
+	if jitter < 0 || jitter > 1 {
+		jitter = 0.1
+	}
+	delay := wc.Interval
+	for range failures {
+		delay *= 2
+		if delay >= maxBackoff {
+			delay = maxBackoff
+			break
+		}
+	}
+	// spread the delay in [delay*(1-jitter/2), delay*(1+jitter/2))
+	return time.Duration(float64(delay) * (1 + jitter*(r-0.5)))
+}
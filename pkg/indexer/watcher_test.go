@@ -0,0 +1,42 @@
+package indexer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWatchDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		wc       WatchConfig
+		failures int
+		r        float64
+		want     time.Duration
+	}{
+		{name: "no failures", wc: WatchConfig{Interval: time.Minute}, r: 0.5, want: time.Minute},
+		{name: "backoff", wc: WatchConfig{Interval: time.Minute}, failures: 2, r: 0.5, want: 4 * time.Minute},
+		{name: "default max backoff", wc: WatchConfig{Interval: time.Minute}, failures: 10, r: 0.5, want: 10 * time.Minute},
+		{name: "max backoff", wc: WatchConfig{Interval: time.Minute, MaxBackoff: 3 * time.Minute}, failures: 5, r: 0.5, want: 3 * time.Minute},
+		{name: "min jitter", wc: WatchConfig{Interval: time.Minute, Jitter: 0.5}, r: 0, want: 45 * time.Second},
+		{name: "max jitter", wc: WatchConfig{Interval: time.Minute, Jitter: 0.5}, r: 1, want: 75 * time.Second},
+		{name: "default jitter", wc: WatchConfig{Interval: 100 * time.Second, Jitter: -1}, r: 0, want: 95 * time.Second},
+		{name: "no jitter", wc: WatchConfig{Interval: 100 * time.Second}, r: 0, want: 100 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := watchDelay(tt.wc, tt.failures, tt.r); got != tt.want {
+				t.Errorf("watchDelay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncSchemaSingleFlight(t *testing.T) {
+	s := New(Config{}, nil)
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if _, err := s.SyncSchema(t.Context(), false); !errors.Is(err, ErrSyncInProgress) {
+		t.Errorf("SyncSchema() error = %v, want %v", err, ErrSyncInProgress)
+	}
+}
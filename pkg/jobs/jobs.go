@@ -0,0 +1,251 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// Func is the job body, logf appends the message to the job log.
+type Func func(ctx context.Context, logf func(format string, args ...any)) error
+
+// Job is a background task state.
+type Job struct {
+	ID         string         `json:"id"`
+	Kind       string         `json:"kind"`
+	Params     map[string]any `json:"params,omitempty"`
+	Status     Status         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	StartedAt  *time.Time     `json:"started_at,omitempty"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+
+	log []LogEntry
+}
+
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+func (j *Job) done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCanceled
+}
+
+// Manager runs the jobs one by one in the background and keeps the last finished jobs.
+type Manager struct {
+	keep int
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string
+	queue chan queued
+}
+
+type queued struct {
+	id string
+	fn Func
+}
+
+// New creates the jobs manager, keep is a number of the finished jobs to keep (default 100).
+// The jobs are run until the context is canceled.
+func New(ctx context.Context, keep int) *Manager {
+	if keep <= 0 {
+		keep = 100
+	}
+	m := &Manager{
+		keep:  keep,
+		jobs:  map[string]*Job{},
+		queue: make(chan queued, 1024),
+	}
+	go m.worker(ctx)
+	return m
+}
+
+// Submit queues the job and returns its state.
+func (m *Manager) Submit(kind string, params map[string]any, fn Func) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	j := &Job{
+		ID:        id,
+		Kind:      kind,
+		Params:    params,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case m.queue <- queued{id: id, fn: fn}:
+	default:
+		return Job{}, fmt.Errorf("jobs queue is full")
+	}
+	m.jobs[id] = j
+	m.order = append(m.order, id)
+	m.cleanup()
+	return j.copy(), nil
+}
+
+// Get returns the job state by id.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return j.copy(), true
+}
+
+// Log returns the job log entries starting from the offset.
+func (m *Manager) Log(id string, offset int) ([]LogEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	if offset < 0 || offset >= len(j.log) {
+		return []LogEntry{}, true
+	}
+	return slices.Clone(j.log[offset:]), true
+}
+
+// List returns the jobs, the latest first.
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Job, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		out = append(out, m.jobs[m.order[i]].copy())
+	}
+	return out
+}
+
+func (m *Manager) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			m.cancelPending()
+			return
+		case q := <-m.queue:
+			m.run(ctx, q)
+		}
+	}
+}
+
+func (m *Manager) run(ctx context.Context, q queued) {
+	m.update(q.id, func(j *Job) {
+		now := time.Now()
+		j.Status = StatusRunning
+		j.StartedAt = &now
+	})
+	logf := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		log.Printf("job %s: %s", q.id, msg)
+		m.update(q.id, func(j *Job) {
+			j.log = append(j.log, LogEntry{Time: time.Now(), Message: msg})
+		})
+	}
+	err := runSafe(ctx, q.fn, logf)
+	m.update(q.id, func(j *Job) {
+		now := time.Now()
+		j.FinishedAt = &now
+		switch {
+		case err == nil:
+			j.Status = StatusSucceeded
+		case ctx.Err() != nil:
+			j.Status = StatusCanceled
+			j.Error = err.Error()
+		default:
+			j.Status = StatusFailed
+			j.Error = err.Error()
+		}
+	})
+	if err != nil {
+		logf("failed: %v", err)
+		return
+	}
+	logf("completed")
+}
+
+func runSafe(ctx context.Context, fn Func, logf func(string, ...any)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, logf)
+}
+
+func (m *Manager) update(id string, fn func(j *Job)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if j, ok := m.jobs[id]; ok {
+		fn(j)
+	}
+}
+
+func (m *Manager) cancelPending() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		if j.Status == StatusPending {
+			j.Status = StatusCanceled
+		}
+	}
+}
+
+// cleanup removes the oldest finished jobs over the limit.
+func (m *Manager) cleanup() {
+	finished := 0
+	for _, id := range m.order {
+		if m.jobs[id].done() {
+			finished++
+		}
+	}
+	if finished <= m.keep {
+		return
+	}
+	order := m.order[:0]
+	for _, id := range m.order {
+		if finished > m.keep && m.jobs[id].done() {
+			delete(m.jobs, id)
+			finished--
+			continue
+		}
+		order = append(order, id)
+	}
+	m.order = order
+}
+
+func (j *Job) copy() Job {
+	c := *j
+	c.log = nil
+	return c
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitJob(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		j, ok := m.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if j.done() {
+			return j
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s is not finished", id)
+	return Job{}
+}
+
+func TestManager(t *testing.T) {
+	m := New(t.Context(), 10)
+
+	ok, err := m.Submit("test", map[string]any{"name": "a"}, func(ctx context.Context, logf func(string, ...any)) error {
+		logf("step %d", 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	failed, err := m.Submit("test", nil, func(ctx context.Context, logf func(string, ...any)) error {
+		return errors.New("boom")
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	panicked, err := m.Submit("test", nil, func(ctx context.Context, logf func(string, ...any)) error {
+		panic("oops")
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	if j := waitJob(t, m, ok.ID); j.Status != StatusSucceeded || j.StartedAt == nil || j.FinishedAt == nil {
+		t.Errorf("job = %+v, want succeeded", j)
+	}
+	entries, _ := m.Log(ok.ID, 0)
+	if len(entries) != 2 || entries[0].Message != "step 1" || entries[1].Message != "completed" {
+		t.Errorf("log = %+v", entries)
+	}
+	if entries, _ := m.Log(ok.ID, 1); len(entries) != 1 {
+		t.Errorf("log from offset = %+v", entries)
+	}
+	if j := waitJob(t, m, failed.ID); j.Status != StatusFailed || j.Error != "boom" {
+		t.Errorf("job = %+v, want failed", j)
+	}
+	if j := waitJob(t, m, panicked.ID); j.Status != StatusFailed {
+		t.Errorf("job = %+v, want failed", j)
+	}
+	if list := m.List(); len(list) != 3 || list[0].ID != panicked.ID {
+		t.Errorf("list = %+v", list)
+	}
+	if _, ok := m.Get("unknown"); ok {
+		t.Errorf("unknown job is found")
+	}
+}
+
+func TestManagerKeep(t *testing.T) {
+	m := New(t.Context(), 2)
+	var last Job
+	for range 5 {
+		j, err := m.Submit("test", nil, func(ctx context.Context, logf func(string, ...any)) error { return nil })
+		if err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+		waitJob(t, m, j.ID)
+		last = j
+	}
+	// the cleanup runs on submit, so the last finished job is over the limit
+	if n := len(m.List()); n > 3 {
+		t.Errorf("jobs = %d, want at most 3", n)
+	}
+	if _, ok := m.Get(last.ID); !ok {
+		t.Errorf("last job is removed")
+	}
+}
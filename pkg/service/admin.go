@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/hugr-lab/mcp/pkg/auth"
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+const adminSecretHeader = "x-mcp-admin-secret"
+
+// adminHandler returns the admin API handler, the long-running endpoints start background jobs.
+func (s *Service) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /admin/load/schema", s.adminJob("load-schema", nil,
+		func(ctx context.Context, _ adminRequest) error {
+			return s.indexer.LoadSchema(ctx)
+		}))
+	mux.HandleFunc("POST /admin/load/data-object", s.adminJob("load-data-object", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.LoadDataObject(ctx, req.Name, req.Partial)
+		}))
+	mux.HandleFunc("POST /admin/load/function", s.adminJob("load-function", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.LoadFunction(ctx, req.Module, req.Name, req.Partial)
+		}))
+	mux.HandleFunc("POST /admin/load/module", s.adminJob("load-module", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.LoadModule(ctx, req.Name)
+		}))
+	mux.HandleFunc("POST /admin/load/data-source", s.adminJob("load-data-source", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.LoadDataSource(ctx, req.Name)
+		}))
+	mux.HandleFunc("POST /admin/summarize", s.adminJob("summarize", nil,
+		func(ctx context.Context, _ adminRequest) error {
+			return s.indexer.Summarize(ctx)
+		}))
+	mux.HandleFunc("POST /admin/summarize/data-object", s.adminJob("summarize-data-object", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.SummarizeDataObjectByName(ctx, req.Name)
+		}))
+	mux.HandleFunc("POST /admin/summarize/module", s.adminJob("summarize-module", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.SummarizeModuleByName(ctx, req.Name)
+		}))
+	mux.HandleFunc("POST /admin/summarize/data-source", s.adminJob("summarize-data-source", requireName,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.SummarizeDataSourceByName(ctx, req.Name)
+		}))
+	mux.HandleFunc("POST /admin/index", s.adminJob("index", nil,
+		func(ctx context.Context, req adminRequest) error {
+			return s.indexer.Index(ctx, req.Summarized)
+		}))
+	mux.HandleFunc("GET /admin/diff", func(w http.ResponseWriter, r *http.Request) {
+		diff, err := s.indexer.Diff(auth.CtxWithAdmin(r.Context()))
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, diff)
+	})
+	mux.HandleFunc("POST /admin/diff/apply", s.adminJob("diff-apply", nil,
+		func(ctx context.Context, req adminRequest) error {
+			_, err := s.indexer.SyncSchema(ctx, req.Summarize)
+			return err
+		}))
+	mux.HandleFunc("PUT /admin/saved-queries", func(w http.ResponseWriter, r *http.Request) {
+		var q indexer.SavedQuery
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		if q.Name == "" || q.Query == "" {
+			writeJSONError(w, http.StatusBadRequest, errors.New("name and query are required"))
+			return
+		}
+		// the saved queries are executed by the data tools, they should pass the query policy
+		if perr := s.policy.check(q.Query); perr != nil {
+			writeJSON(w, http.StatusBadRequest, perr)
+			return
+		}
+		if err := s.indexer.SaveQuery(auth.CtxWithAdmin(r.Context()), q); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"name": q.Name})
+	})
+	mux.HandleFunc("DELETE /admin/saved-queries/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.indexer.DeleteSavedQuery(auth.CtxWithAdmin(r.Context()), r.PathValue("name")); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"name": r.PathValue("name")})
+	})
+	mux.HandleFunc("GET /admin/jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.jobs.List())
+	})
+	mux.HandleFunc("GET /admin/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, ok := s.jobs.Get(r.PathValue("id"))
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, errors.New("job not found"))
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+	mux.HandleFunc("GET /admin/jobs/{id}/log", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		entries, ok := s.jobs.Log(r.PathValue("id"), offset)
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, errors.New("job not found"))
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.checkAdminSecret(r) {
+			writeJSONError(w, http.StatusUnauthorized, errors.New("admin secret is required"))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (s *Service) checkAdminSecret(r *http.Request) bool {
+	secret := r.Header.Get(adminSecretHeader)
+	if secret == "" {
+		secret, _ = auth.TokenFromRequest(r)
+	}
+	return secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(s.cfg.AdminSecret)) == 1
+}
+
+type adminRequest struct {
+	Name       string `json:"name"`
+	Module     string `json:"module"`
+	Partial    bool   `json:"partial"`
+	Summarized bool   `json:"summarized"`
+	Summarize  bool   `json:"summarize"`
+}
+
+func (r adminRequest) params() map[string]any {
+	params := map[string]any{}
+	if r.Name != "" {
+		params["name"] = r.Name
+	}
+	if r.Module != "" {
+		params["module"] = r.Module
+	}
+	if r.Partial {
+		params["partial"] = true
+	}
+	if r.Summarized {
+		params["summarized"] = true
+	}
+	if r.Summarize {
+		params["summarize"] = true
+	}
+	return params
+}
+
+func requireName(req adminRequest) error {
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+// adminJob decodes the request, validates it and submits the background job.
+func (s *Service) adminJob(kind string, validate func(adminRequest) error, fn func(ctx context.Context, req adminRequest) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req adminRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+		if validate != nil {
+			if err := validate(req); err != nil {
+				writeJSONError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+		job, err := s.jobs.Submit(kind, req.params(), func(ctx context.Context, logf func(string, ...any)) error {
+			logf("%s started", kind)
+			// the indexer progress is written to the job log
+			return fn(indexer.CtxWithLogger(auth.CtxWithAdmin(ctx), logf), req)
+		})
+		if err != nil {
+			writeJSONError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, job)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/jobs"
+)
+
+func TestAdminHandler(t *testing.T) {
+	s := &Service{
+		cfg:    Config{AdminSecret: "admin"},
+		jobs:   jobs.New(t.Context(), 0),
+		policy: queryPolicy{allowed: []string{"shop"}},
+	}
+	h := s.adminHandler()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		secret string
+		want   int
+	}{
+		{name: "no secret", method: http.MethodGet, path: "/admin/jobs", want: http.StatusUnauthorized},
+		{name: "wrong secret", method: http.MethodGet, path: "/admin/jobs", secret: "wrong", want: http.StatusUnauthorized},
+		{name: "jobs list", method: http.MethodGet, path: "/admin/jobs", secret: "admin", want: http.StatusOK},
+		{name: "unknown job", method: http.MethodGet, path: "/admin/jobs/unknown", secret: "admin", want: http.StatusNotFound},
+		{name: "unknown job log", method: http.MethodGet, path: "/admin/jobs/unknown/log", secret: "admin", want: http.StatusNotFound},
+		{name: "name is required", method: http.MethodPost, path: "/admin/load/data-object", body: `{}`, secret: "admin", want: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPost, path: "/admin/summarize/module", body: `{`, secret: "admin", want: http.StatusBadRequest},
+		{name: "saved query is required", method: http.MethodPut, path: "/admin/saved-queries", body: `{"name": "q"}`, secret: "admin", want: http.StatusBadRequest},
+		{name: "saved mutation", method: http.MethodPut, path: "/admin/saved-queries", body: `{"name": "q", "query": "mutation { shop { delete_orders { affected_rows } } }"}`, secret: "admin", want: http.StatusBadRequest},
+		{name: "saved query not allowed", method: http.MethodPut, path: "/admin/saved-queries", body: `{"name": "q", "query": "{ crm { customers { id } } }"}`, secret: "admin", want: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodGet, path: "/admin/load/schema", secret: "admin", want: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.secret != "" {
+				req.Header.Set(adminSecretHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/hugr-lab/mcp/pkg/artifacts"
+	"github.com/hugr-lab/mcp/pkg/auth"
+	"github.com/hugr-lab/query-engine/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The query results that are too large to inline are written to the artifact store,
+// the artifacts are available as the resources by id to the user that created them until they expire.
+
+const maxArtifactResourceSize = 32 * 1024 * 1024
+
+var dataExportArtifactTool = mcp.NewTool("data-export_artifact",
+	mcp.WithDescription("Execute a GraphQL query and write the full result of a data object query (table) to a file artifact as CSV, JSON Lines, Parquet or GeoJSON. Returns the artifact id, the row count, the schema and the resource URI to fetch the file or pass it to other tools. Use it for the results that are too large to inline"),
+	mcp.WithInputSchema[dataExportArtifactInput](),
+	mcp.WithOutputSchema[dataArtifact](),
+)
+
+var artifactResourceTemplate = mcp.NewResourceTemplate(resourceURIPrefix+"artifact/{id}", "Query result artifact",
+	mcp.WithTemplateDescription("The query result file (CSV, JSON Lines, Parquet or GeoJSON) exported by the data-export_artifact tool"),
+)
+
+type dataExportArtifactInput struct {
+	Query         string         `json:"query" jsonschema_description:"The GraphQL query to execute. Should be a read-only query (no mutations)"`
+	Variables     map[string]any `json:"variables,omitempty" jsonschema_description:"Optional variables to pass to the GraphQL query"`
+	Path          string         `json:"path,omitempty" jsonschema_description:"The path to the data object query result in the response data (e.g. module.orders), can be omitted if the query returns a single table"`
+	Format        string         `json:"format,omitempty" jsonschema_description:"The artifact file format" jsonschema:"enum=csv,enum=jsonl,enum=parquet,enum=geojson,default=csv"`
+	GeometryField string         `json:"geometry_field,omitempty" jsonschema_description:"The geometry field for the GeoJSON format, the first geometry field is used if omitted"`
+}
+
+type dataArtifact struct {
+	artifacts.Artifact
+	URI string `json:"uri" jsonschema_description:"The resource URI of the artifact file"`
+}
+
+func (s *Service) dataExportArtifactHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &dataExportArtifactInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.Query == "" {
+		return mcp.NewToolResultError("query is required"), nil
+	}
+	if perr := s.policy.check(input.Query); perr != nil {
+		return perr.toolResult(), nil
+	}
+	if input.Format == "" {
+		input.Format = string(artifacts.FormatCSV)
+	}
+
+	res, err := s.hugr.Query(ctx, input.Query, input.Variables)
+	if err == nil {
+		defer res.Close()
+		err = res.Err()
+	}
+	s.recordQuery(ctx, dataExportArtifactTool.Name, input.Query, input.Variables, err)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to execute query", err), nil
+	}
+	path, table, err := resultTable(res.Data, input.Path)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get query result", err), nil
+	}
+	records, err := table.Records()
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to read query result", err), nil
+	}
+	defer func() {
+		for _, rec := range records {
+			rec.Release()
+		}
+	}()
+	schema := arrow.NewSchema(nil, nil)
+	if len(records) != 0 {
+		schema = records[0].Schema()
+	}
+
+	a, err := s.artifacts.Create(ctx, schema, records, artifacts.CreateRequest{
+		Format:        artifacts.Format(input.Format),
+		GeometryField: input.GeometryField,
+		Query:         input.Query,
+		Path:          path,
+		UserID:        artifactUserID(ctx),
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to create artifact", err), nil
+	}
+	s.recordArtifact(ctx, a)
+
+	return mcp.NewToolResultStructuredOnly(dataArtifact{Artifact: *a, URI: artifactURI(a.ID)}), nil
+}
+
+func (s *Service) artifactResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	id := resourceArgument(request, "id")
+	a, r, err := s.artifacts.Open(ctx, id)
+	if errors.Is(err, artifacts.ErrNotFound) {
+		return nil, fmt.Errorf("artifact %q not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	if a.UserID != artifactUserID(ctx) {
+		return nil, fmt.Errorf("artifact %q not found", id)
+	}
+	if a.Size > maxArtifactResourceSize {
+		return nil, fmt.Errorf("artifact %q is too large (%d bytes) to read as a resource", id, a.Size)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if a.Format == artifacts.FormatParquet {
+		return []mcp.ResourceContents{mcp.BlobResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: a.ContentType,
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		}}, nil
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: a.ContentType,
+		Text:     string(data),
+	}}, nil
+}
+
+// artifactUserID returns the user id of the request, it is empty if the authentication is disabled.
+func artifactUserID(ctx context.Context) string {
+	if user := auth.UserInfoFromCtx(ctx); user != nil {
+		return user.UserID
+	}
+	return ""
+}
+
+func artifactURI(id string) string {
+	return resourceURIPrefix + "artifact/" + id
+}
+
+// resultTable returns the table by the data path, or the single table of the response if the path is empty.
+func resultTable(data map[string]any, path string) (string, db.ArrowTable, error) {
+	tables := map[string]db.ArrowTable{}
+	collectTables(data, "", tables)
+	if path != "" {
+		t, ok := tables[path]
+		if !ok {
+			return "", nil, fmt.Errorf("path %q is not a table in the query result, the tables: %s", path, tablePaths(tables))
+		}
+		return path, t, nil
+	}
+	switch len(tables) {
+	case 0:
+		return "", nil, errors.New("the query result has no tables, query the data object rows")
+	case 1:
+		for p, t := range tables {
+			return p, t, nil
+		}
+	}
+	return "", nil, fmt.Errorf("the query result has several tables, set the path: %s", tablePaths(tables))
+}
+
+func collectTables(v any, path string, tables map[string]db.ArrowTable) {
+	switch v := v.(type) {
+	case db.ArrowTable:
+		tables[path] = v
+	case map[string]any:
+		for k, d := range v {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			collectTables(d, p, tables)
+		}
+	}
+}
+
+func tablePaths(tables map[string]db.ArrowTable) string {
+	var paths []string
+	for p := range tables {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return strings.Join(paths, ", ")
+}
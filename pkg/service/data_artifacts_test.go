@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/hugr-lab/mcp/pkg/artifacts"
+	"github.com/hugr-lab/mcp/pkg/auth"
+	"github.com/hugr-lab/query-engine/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestResultTable(t *testing.T) {
+	orders, customers := db.NewArrowTable(), db.NewArrowTable()
+	value := db.JsonValue(`{"total": 1}`)
+	data := map[string]any{
+		"shop": map[string]any{
+			"orders":    orders,
+			"customers": customers,
+			"stats":     &value,
+		},
+	}
+
+	path, table, err := resultTable(data, "shop.orders")
+	if err != nil || path != "shop.orders" || table != orders {
+		t.Errorf("unexpected table %q: %v", path, err)
+	}
+	if _, _, err := resultTable(data, "shop.stats"); err == nil {
+		t.Error("expected error for the object result")
+	}
+	if _, _, err := resultTable(data, ""); err == nil {
+		t.Error("expected error for several tables")
+	}
+	delete(data["shop"].(map[string]any), "customers")
+	if path, _, err := resultTable(data, ""); err != nil || path != "shop.orders" {
+		t.Errorf("unexpected single table %q: %v", path, err)
+	}
+}
+
+func TestArtifactResourceOwner(t *testing.T) {
+	as := artifacts.NewWithStore(artifacts.NewLocalStore(t.TempDir()))
+	s := &Service{artifacts: as}
+	ctx := auth.CtxWithUserInfo(context.Background(), &auth.UserInfo{UserID: "u1"})
+	a, err := as.Create(ctx, arrow.NewSchema(nil, nil), nil, artifacts.CreateRequest{Format: artifacts.FormatCSV, UserID: artifactUserID(ctx)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := mcp.ReadResourceRequest{}
+	req.Params.URI = artifactURI(a.ID)
+	req.Params.Arguments = map[string]any{"id": a.ID}
+	if _, err := s.artifactResourceHandler(ctx, req); err != nil {
+		t.Errorf("the owner can't read the artifact: %v", err)
+	}
+	for _, ctx := range []context.Context{
+		auth.CtxWithUserInfo(context.Background(), &auth.UserInfo{UserID: "u2"}),
+		context.Background(),
+	} {
+		if _, err := s.artifactResourceHandler(ctx, req); err == nil {
+			t.Error("expected error for the artifact of another user")
+		}
+	}
+}
@@ -9,7 +9,7 @@ import (
 )
 
 var dataInlineGraphQLResultTool = mcp.NewTool("data-inline_graphql_result",
-	mcp.WithDescription("Execute a GraphQL query (optionally apply a jq transform) and inline a small JSON result directly in the response. Useful for dynamic data fetching within a single request. Limited by size"),
+	mcp.WithDescription("Execute a GraphQL query (optionally apply a jq transform) and inline a small JSON result directly in the response. Useful for dynamic data fetching within a single request. Limited by size. Only the read-only queries are executed, the mutations, subscriptions and not allowed root fields are rejected"),
 	mcp.WithInputSchema[simpleGraphQLRequest](),
 	mcp.WithOutputSchema[map[string]any](),
 )
@@ -23,9 +23,10 @@ type simpleGraphQLRequest struct {
 }
 
 type simpleGraphQLResponse struct {
-	IsTruncated bool `json:"is_truncated" jsonschema_description:"Whether the result was truncated due to exceeding the maximum size"`
-	Size        int  `json:"original_size" jsonschema_description:"The size (in bytes) of the original JSON result before truncation"`
-	Response    any  `json:"data,omitempty" jsonschema_description:"The JSON result of the GraphQL query after applying the jq transform, if any. May be omitted as not full JSON result if it was truncated."`
+	IsTruncated bool   `json:"is_truncated" jsonschema_description:"Whether the result was truncated due to exceeding the maximum size"`
+	Size        int    `json:"original_size" jsonschema_description:"The size (in bytes) of the original JSON result before truncation"`
+	Response    any    `json:"data,omitempty" jsonschema_description:"The JSON result of the GraphQL query after applying the jq transform, if any. Omitted if the result exceeds the maximum size."`
+	Hint        string `json:"hint,omitempty" jsonschema_description:"How to get the result that exceeds the maximum size"`
 }
 
 func (s *Service) dataInlineGraphQLResultHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -37,6 +38,20 @@ func (s *Service) dataInlineGraphQLResultHandler(ctx context.Context, request mc
 	if input.Query == "" {
 		return mcp.NewToolResultError("query is required"), nil
 	}
+	if perr := s.policy.check(input.Query); perr != nil {
+		return perr.toolResult(), nil
+	}
+	out, err := s.inlineGraphQLResult(ctx, input)
+	s.recordQuery(ctx, dataInlineGraphQLResultTool.Name, input.Query, input.Variables, err)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to execute query", err), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+// inlineGraphQLResult executes the query with the user credentials and truncates the result to the max size.
+func (s *Service) inlineGraphQLResult(ctx context.Context, input *simpleGraphQLRequest) (*simpleGraphQLResponse, error) {
 	if input.MaxResultSize <= 0 {
 		input.MaxResultSize = 2 * 1024 * 1024 * 1024
 	}
@@ -50,26 +65,23 @@ func (s *Service) dataInlineGraphQLResultHandler(ctx context.Context, request mc
 		},
 	})
 	if err != nil {
-		return mcp.NewToolResultErrorFromErr("failed to execute query", err), nil
+		return nil, err
 	}
 	if res == nil {
-		return mcp.NewToolResultStructuredOnly(simpleGraphQLResponse{
-			IsTruncated: false,
-			Size:        0,
-			Response:    nil,
-		}), nil
+		return &simpleGraphQLResponse{}, nil
 	}
-	out := simpleGraphQLResponse{
+	out := &simpleGraphQLResponse{
 		IsTruncated: false,
 		Size:        len(*res),
 	}
 
 	if input.MaxResultSize > 0 && len(*res) > input.MaxResultSize {
+		// the cut JSON is not valid, the result is omitted
 		out.IsTruncated = true
-		out.Response = string((*res)[:input.MaxResultSize])
+		out.Hint = "the result exceeds the max size, reduce it with the jq transform or aggregations, read the rows by pages with data-query_page or export them with data-export_artifact"
 	} else {
 		out.Response = res
 	}
 
-	return mcp.NewToolResultStructuredOnly(out), nil
+	return out, nil
 }
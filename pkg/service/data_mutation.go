@@ -0,0 +1,400 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/auth"
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The data mutations are executed in two steps: the dry run returns the mutation, the number of the affected rows
+// and the confirm token, the token is passed back to execute exactly the same mutation.
+
+const confirmTokenTTL = 5 * time.Minute
+
+var errWritesDisabled = errors.New("the data mutations are disabled on the MCP server")
+
+var dataMutateTool = mcp.NewTool("data-mutate",
+	mcp.WithDescription("Insert, update or delete the data object rows. The call without the confirm token is a dry run: it returns the mutation, the number of the rows that will be affected (counted by the same filter) and the confirm token. Show them to the user and repeat the same call with the confirm token to execute the mutation after the user approval. The update and delete require a filter. The read-only data sources can't be changed"),
+	mcp.WithInputSchema[dataMutateInput](),
+	mcp.WithOutputSchema[dataMutateOutput](),
+)
+
+type dataMutateInput struct {
+	ObjectName   string         `json:"object_name" jsonschema_description:"The name of the data object (GraphQL type) to change"`
+	Operation    string         `json:"operation" jsonschema_description:"The mutation operation" jsonschema:"enum=insert,enum=update,enum=delete"`
+	Filter       map[string]any `json:"filter,omitempty" jsonschema_description:"The filter of the rows to update or delete, the JSON object that represents the GraphQL filter input of the data object, e.g. {\"id\": {\"eq\": 10}}"`
+	Data         map[string]any `json:"data,omitempty" jsonschema_description:"The row to insert or the field values to update, e.g. {\"status\": \"closed\"}"`
+	ConfirmToken string         `json:"confirm_token,omitempty" jsonschema_description:"The confirm token returned by the dry run of the same request, executes the mutation"`
+}
+
+type dataMutateOutput struct {
+	DryRun       bool           `json:"dry_run" jsonschema_description:"Whether the mutation was not executed"`
+	Mutation     string         `json:"mutation" jsonschema_description:"The GraphQL mutation"`
+	Variables    map[string]any `json:"variables,omitempty" jsonschema_description:"The mutation variables"`
+	DataSource   string         `json:"data_source,omitempty" jsonschema_description:"The data source of the data object"`
+	AffectedRows *int           `json:"affected_rows,omitempty" jsonschema_description:"The number of the rows that will be (dry run) or were affected"`
+	ConfirmToken string         `json:"confirm_token,omitempty" jsonschema_description:"The token to execute the mutation, pass it with the same request"`
+	ExpiresAt    *time.Time     `json:"expires_at,omitempty" jsonschema_description:"The confirm token expiration time"`
+	Result       any            `json:"result,omitempty" jsonschema_description:"The mutation result"`
+}
+
+func (s *Service) dataMutateHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &dataMutateInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if s.cfg.ReadOnly {
+		return mcp.NewToolResultError(errWritesDisabled.Error()), nil
+	}
+
+	info, err := s.indexer.DataObjectMutationsInfo(ctx, input.ObjectName)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get data object info", err), nil
+	}
+	if info.ReadOnly {
+		return mcp.NewToolResultError(fmt.Sprintf("the data source %q of the data object %q is read-only", info.DataSource, info.Name)), nil
+	}
+	m, err := buildMutation(info, input)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to build mutation", err), nil
+	}
+	// the mutation and the dry-run count query are limited by the allowed query roots
+	if perr := s.policy.checkDataObject(info.Module, m.field.Name, info.AggregationQuery); perr != nil {
+		return perr.toolResult(), nil
+	}
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	if input.Filter != nil {
+		if err := b.validateInput(ctx, input.Filter, info.FilterType, "filter"); err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid filter", err), nil
+		}
+	}
+	if arg, ok := m.field.Argument("data"); ok {
+		if err := b.validateInput(ctx, input.Data, arg.Type, "data"); err != nil {
+			return mcp.NewToolResultErrorFromErr("invalid data", err), nil
+		}
+	}
+	if input.Operation == "insert" && m.field.ResultType == info.Name {
+		// the inserted row is returned
+		m.selection, err = s.insertSelection(ctx, info.Name)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get data object fields", err), nil
+		}
+	}
+	out := &dataMutateOutput{
+		Mutation:   m.query(),
+		Variables:  m.vars,
+		DataSource: info.DataSource,
+	}
+
+	payload, err := confirmPayload(ctx, s.sessionID(ctx), input)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.ConfirmToken == "" {
+		out.DryRun = true
+		out.AffectedRows, err = s.mutationAffectedRows(ctx, info, input)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to count affected rows", err), nil
+		}
+		token, expires := s.confirmTokens.issue(payload)
+		out.ConfirmToken, out.ExpiresAt = token, &expires
+		return mcp.NewToolResultStructuredOnly(out), nil
+	}
+	if err := s.confirmTokens.verify(input.ConfirmToken, payload); err != nil {
+		return mcp.NewToolResultErrorFromErr("the mutation is not confirmed, run the dry run again", err), nil
+	}
+
+	res, err := s.hugr.Query(ctx, out.Mutation, m.vars)
+	if err == nil {
+		defer res.Close()
+		err = res.Err()
+	}
+	s.recordQuery(ctx, dataMutateTool.Name, out.Mutation, m.vars, err)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to execute mutation", err), nil
+	}
+	var result map[string]any
+	if err := res.ScanData(m.path(), &result); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to read mutation result", err), nil
+	}
+	out.Result = result
+	if n, ok := result["affected_rows"].(float64); ok {
+		out.AffectedRows = intPtr(int(n))
+	}
+	if input.Operation == "insert" {
+		out.AffectedRows = intPtr(1)
+	}
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+// mutationAffectedRows counts the rows by the mutation filter with the aggregation query of the data object.
+func (s *Service) mutationAffectedRows(ctx context.Context, info *indexer.DataObjectMutationsInfo, input *dataMutateInput) (*int, error) {
+	if input.Operation == "insert" {
+		return intPtr(1), nil
+	}
+	if info.AggregationQuery == "" {
+		// the count is not available
+		return nil, nil
+	}
+	q := &mutationQuery{
+		operation: "query",
+		module:    info.Module,
+		field:     &indexer.DataObjectMutation{Name: info.AggregationQuery},
+		args:      []string{"filter: $filter"},
+		varDefs:   []string{"$filter: " + info.FilterType},
+		vars:      map[string]any{"filter": input.Filter},
+		selection: []string{"_rows_count"},
+	}
+	res, err := s.hugr.Query(ctx, q.query(), q.vars)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	var count int
+	if err := res.ScanData(q.path()+"._rows_count", &count); err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// insertSelection returns the scalar fields of the data object to select in the insert mutation.
+func (s *Service) insertSelection(ctx context.Context, objectName string) ([]string, error) {
+	fields, err := s.indexer.TypeFields(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	var sel []string
+	for _, f := range fields {
+		if f.Exclude || f.IsList || f.FieldType == nil {
+			continue
+		}
+		if f.FieldType.Kind != "SCALAR" && f.FieldType.Kind != "ENUM" {
+			continue
+		}
+		sel = append(sel, f.Name)
+	}
+	if len(sel) == 0 {
+		return nil, fmt.Errorf("data object %q has no scalar fields", objectName)
+	}
+	return sel, nil
+}
+
+// mutationQuery is the single field operation nested into the module fields.
+type mutationQuery struct {
+	operation string
+	module    string
+	field     *indexer.DataObjectMutation
+	args      []string
+	varDefs   []string
+	vars      map[string]any
+	selection []string
+}
+
+func buildMutation(info *indexer.DataObjectMutationsInfo, input *dataMutateInput) (*mutationQuery, error) {
+	var field *indexer.DataObjectMutation
+	switch input.Operation {
+	case "insert":
+		field = info.Insert
+	case "update":
+		field = info.Update
+	case "delete":
+		field = info.Delete
+	default:
+		return nil, fmt.Errorf("unknown operation %q, should be insert, update or delete", input.Operation)
+	}
+	if field == nil {
+		return nil, fmt.Errorf("data object %q does not support %s mutations", info.Name, input.Operation)
+	}
+	q := &mutationQuery{
+		operation: "mutation",
+		module:    info.Module,
+		field:     field,
+		vars:      map[string]any{},
+		selection: []string{"success", "affected_rows", "message"},
+	}
+	if input.Operation != "insert" {
+		// the mutation without the filter changes all rows
+		if len(input.Filter) == 0 {
+			return nil, fmt.Errorf("the %s mutation requires a filter", input.Operation)
+		}
+		if err := q.addArgument("filter", input.Filter); err != nil {
+			return nil, err
+		}
+	} else if len(input.Filter) != 0 {
+		return nil, errors.New("the insert mutation does not accept a filter")
+	}
+	if input.Operation != "delete" {
+		if len(input.Data) == 0 {
+			return nil, fmt.Errorf("the %s mutation requires data", input.Operation)
+		}
+		if err := q.addArgument("data", input.Data); err != nil {
+			return nil, err
+		}
+	} else if len(input.Data) != 0 {
+		return nil, errors.New("the delete mutation does not accept data")
+	}
+	return q, nil
+}
+
+func (q *mutationQuery) addArgument(name string, value any) error {
+	arg, ok := q.field.Argument(name)
+	if !ok {
+		return fmt.Errorf("mutation %q has no argument %q", q.field.Name, name)
+	}
+	t := arg.Type
+	if arg.IsList {
+		t = "[" + t + "!]"
+	}
+	if arg.IsNotNull {
+		t += "!"
+	}
+	q.args = append(q.args, name+": $"+name)
+	q.varDefs = append(q.varDefs, "$"+name+": "+t)
+	q.vars[name] = value
+	return nil
+}
+
+func (q *mutationQuery) modules() []string {
+	if q.module == "" {
+		return nil
+	}
+	return strings.Split(q.module, ".")
+}
+
+// path returns the path to the field result in the response data.
+func (q *mutationQuery) path() string {
+	return strings.Join(append(q.modules(), q.field.Name), ".")
+}
+
+func (q *mutationQuery) query() string {
+	var sb strings.Builder
+	sb.WriteString(q.operation)
+	if len(q.varDefs) != 0 {
+		sb.WriteString(" (" + strings.Join(q.varDefs, ", ") + ")")
+	}
+	sb.WriteString(" {\n")
+	modules := q.modules()
+	indent := "  "
+	for _, m := range modules {
+		sb.WriteString(indent + m + " {\n")
+		indent += "  "
+	}
+	sb.WriteString(indent + q.field.Name)
+	if len(q.args) != 0 {
+		sb.WriteString("(" + strings.Join(q.args, ", ") + ")")
+	}
+	sb.WriteString(" {\n")
+	for _, f := range q.selection {
+		sb.WriteString(indent + "  " + f + "\n")
+	}
+	sb.WriteString(indent + "}\n")
+	for range modules {
+		indent = indent[2:]
+		sb.WriteString(indent + "}\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// confirmPayload is the canonical request that is signed by the confirm token,
+// the token is valid only for the same user, session and mutation.
+func confirmPayload(ctx context.Context, sessionID string, input *dataMutateInput) ([]byte, error) {
+	var userID string
+	if user := auth.UserInfoFromCtx(ctx); user != nil {
+		userID = user.UserID
+	}
+	// the maps are encoded with the sorted keys
+	return json.Marshal([]any{userID, sessionID, input.ObjectName, input.Operation, input.Filter, input.Data})
+}
+
+// confirmTokens issues the signed tokens that confirm the dry-run mutations,
+// the key is generated on start, the tokens are not valid after the server restart.
+// Each token is single-use: the consumed tokens are kept until they expire.
+type confirmTokens struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+func newConfirmTokens(ttl time.Duration) *confirmTokens {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &confirmTokens{key: key, ttl: ttl, now: time.Now, used: map[string]time.Time{}}
+}
+
+func (t *confirmTokens) issue(payload []byte) (string, time.Time) {
+	expires := t.now().Add(t.ttl).UTC().Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	// the nonce makes the tokens of the repeated dry runs different
+	nonce := make([]byte, 12)
+	rand.Read(nonce)
+	n := base64.RawURLEncoding.EncodeToString(nonce)
+	return exp + "." + n + "." + t.sign(exp, n, payload), expires
+}
+
+// verify checks the token and consumes it, the second use of the token is rejected.
+func (t *confirmTokens) verify(token string, payload []byte) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed confirm token")
+	}
+	exp, nonce, sig := parts[0], parts[1], parts[2]
+	if !hmac.Equal([]byte(sig), []byte(t.sign(exp, nonce, payload))) {
+		return errors.New("the confirm token does not match the request")
+	}
+	sec, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return errors.New("malformed confirm token")
+	}
+	expires := time.Unix(sec, 0)
+	now := t.now()
+	if now.After(expires) {
+		return errors.New("the confirm token is expired")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for k, e := range t.used {
+		if now.After(e) {
+			delete(t.used, k)
+		}
+	}
+	if _, ok := t.used[token]; ok {
+		return errors.New("the confirm token is already used")
+	}
+	t.used[token] = expires
+	return nil
+}
+
+func (t *confirmTokens) sign(exp, nonce string, payload []byte) string {
+	h := hmac.New(sha256.New, t.key)
+	h.Write([]byte(exp))
+	h.Write([]byte{0})
+	h.Write([]byte(nonce))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+func TestBuildMutation(t *testing.T) {
+	info := &indexer.DataObjectMutationsInfo{
+		Name:       "shop_orders",
+		Module:     "shop.sales",
+		FilterType: "shop_orders_filter",
+		Insert: &indexer.DataObjectMutation{Name: "insert_orders", ResultType: "shop_orders", Arguments: []indexer.Argument{
+			{Name: "data", Type: "shop_orders_mut_input_data", IsNotNull: true},
+		}},
+		Update: &indexer.DataObjectMutation{Name: "update_orders", ResultType: "OperationResult", Arguments: []indexer.Argument{
+			{Name: "filter", Type: "shop_orders_filter"},
+			{Name: "data", Type: "shop_orders_mut_data", IsNotNull: true},
+		}},
+	}
+	filter := map[string]any{"id": map[string]any{"eq": 1}}
+	data := map[string]any{"status": "closed"}
+
+	m, err := buildMutation(info, &dataMutateInput{ObjectName: "shop_orders", Operation: "update", Filter: filter, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `mutation ($filter: shop_orders_filter, $data: shop_orders_mut_data!) {
+  shop {
+    sales {
+      update_orders(filter: $filter, data: $data) {
+        success
+        affected_rows
+        message
+      }
+    }
+  }
+}`
+	if q := m.query(); q != expected {
+		t.Errorf("unexpected mutation:\n%s", q)
+	}
+	if m.path() != "shop.sales.update_orders" || len(m.vars) != 2 {
+		t.Errorf("unexpected path %q or variables %v", m.path(), m.vars)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		input dataMutateInput
+	}{
+		{"update without filter", dataMutateInput{Operation: "update", Data: data}},
+		{"update without data", dataMutateInput{Operation: "update", Filter: filter}},
+		{"insert with filter", dataMutateInput{Operation: "insert", Filter: filter, Data: data}},
+		{"unsupported delete", dataMutateInput{Operation: "delete", Filter: filter}},
+		{"unknown operation", dataMutateInput{Operation: "upsert", Data: data}},
+	} {
+		if _, err := buildMutation(info, &tc.input); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestConfirmTokens(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ct := newConfirmTokens(time.Minute)
+	ct.now = func() time.Time { return now }
+
+	input := &dataMutateInput{ObjectName: "shop_orders", Operation: "delete", Filter: map[string]any{"id": map[string]any{"eq": 1.0}}}
+	payload, err := confirmPayload(context.Background(), "s1", input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, expires := ct.issue(payload)
+	if expires != now.Add(time.Minute) {
+		t.Errorf("unexpected expiration %v", expires)
+	}
+	// the repeated dry run issues the other token
+	if again, _ := ct.issue(payload); again == token {
+		t.Error("expected the different token for the repeated dry run")
+	}
+
+	// the changed request or session is not confirmed
+	changed, _ := confirmPayload(context.Background(), "s1", &dataMutateInput{ObjectName: "shop_orders", Operation: "delete", Filter: map[string]any{"id": map[string]any{"eq": 2.0}}})
+	if err := ct.verify(token, changed); err == nil {
+		t.Error("expected error for the changed filter")
+	}
+	other, _ := confirmPayload(context.Background(), "s2", input)
+	if err := ct.verify(token, other); err == nil {
+		t.Error("expected error for the other session")
+	}
+	if err := newConfirmTokens(time.Minute).verify(token, payload); err == nil {
+		t.Error("expected error for the other key")
+	}
+
+	// the token is single-use
+	if err := ct.verify(token, payload); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ct.verify(token, payload); err == nil {
+		t.Error("expected error for the second use of the token")
+	}
+
+	now = now.Add(2 * time.Minute)
+	token, _ = ct.issue(payload)
+	now = now.Add(2 * time.Minute)
+	if err := ct.verify(token, payload); err == nil {
+		t.Error("expected error for the expired token")
+	}
+}
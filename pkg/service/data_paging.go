@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/auth"
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The large data object results are read by pages, the cursor keeps the query and the position of the next page
+// on the server. The rows are paged by the primary key (keyset) if the data object has the single primary key field
+// and the rows are not ordered by the other fields, otherwise by limit and offset in the order completed
+// by the primary key fields.
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	resultCursorTTL = 30 * time.Minute
+
+	pagingKeyset = "keyset"
+	pagingOffset = "offset"
+)
+
+var dataQueryPageTool = mcp.NewTool("data-query_page",
+	mcp.WithDescription("Read the data object rows by pages. The first call takes the data object query (fields, filter, arguments, order_by) and returns the first page of rows and the next cursor, the following calls take only the cursor and return the next page. Use it to walk through the large results without loading them at once. The cursor expires in 30 minutes"),
+	mcp.WithInputSchema[dataQueryPageInput](),
+	mcp.WithOutputSchema[dataQueryPageOutput](),
+)
+
+type dataQueryPageInput struct {
+	Cursor     string             `json:"cursor,omitempty" jsonschema_description:"The next cursor returned by the previous page, the query fields are ignored if it is set"`
+	ObjectName string             `json:"object_name,omitempty" jsonschema_description:"The name of the data object (GraphQL type) to query"`
+	Fields     []string           `json:"fields,omitempty" jsonschema_description:"The fields to select, the reference (relation) fields are selected by the dotted paths, e.g. [\"id\", \"customer.name\"]"`
+	Filter     map[string]any     `json:"filter,omitempty" jsonschema_description:"Optional filter, the JSON object that represents the GraphQL filter input of the data object"`
+	Args       map[string]any     `json:"args,omitempty" jsonschema_description:"Optional arguments of the parameterized data object (view)"`
+	OrderBy    []dataQueryOrderBy `json:"order_by,omitempty" jsonschema_description:"Optional ordering, the rows are ordered by the primary key if omitted, the primary key fields are added to keep the pages order stable"`
+	DistinctOn []string           `json:"distinct_on,omitempty" jsonschema_description:"Optional fields to return the distinct rows on"`
+	PageSize   int                `json:"page_size,omitempty" jsonschema_description:"The number of rows in the page" jsonschema:"minimum=1,maximum=1000,default=100"`
+}
+
+type dataQueryPageOutput struct {
+	Rows       []map[string]any `json:"rows" jsonschema_description:"The page rows"`
+	Count      int              `json:"count" jsonschema_description:"The number of rows in the page"`
+	Page       int              `json:"page" jsonschema_description:"The page number, starting from 1"`
+	Paging     string           `json:"paging" jsonschema_description:"The paging method: keyset (by the primary key) or offset"`
+	NextCursor string           `json:"next_cursor,omitempty" jsonschema_description:"The cursor of the next page, omitted on the last page"`
+	Query      string           `json:"query" jsonschema_description:"The GraphQL query of the page"`
+	Variables  map[string]any   `json:"variables,omitempty" jsonschema_description:"The page query variables"`
+}
+
+func (s *Service) dataQueryPageHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &dataQueryPageInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	var userID string
+	if user := auth.UserInfoFromCtx(ctx); user != nil {
+		userID = user.UserID
+	}
+
+	c := &resultCursor{userID: userID, input: *input, pageSize: input.PageSize}
+	if input.Cursor != "" {
+		var ok bool
+		c, ok = s.cursors.get(input.Cursor)
+		if !ok || c.userID != userID {
+			return mcp.NewToolResultError("the cursor is not found or expired, start from the first page"), nil
+		}
+		if input.PageSize > 0 {
+			c.pageSize = input.PageSize
+		}
+	}
+	if c.input.ObjectName == "" {
+		return mcp.NewToolResultError("object_name is required for the first page"), nil
+	}
+	if c.pageSize <= 0 {
+		c.pageSize = defaultPageSize
+	}
+	if c.pageSize > maxPageSize {
+		c.pageSize = maxPageSize
+	}
+
+	info, err := s.indexer.DataObjectQueriesInfo(ctx, c.input.ObjectName)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get data object info", err), nil
+	}
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	if input.Cursor == "" {
+		c.keyset, c.keys, err = b.pagingKeys(ctx, info.Name, &c.input)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to get data object paging order", err), nil
+		}
+	}
+	q, err := b.pageQuery(ctx, info, c)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to build query", err), nil
+	}
+	if perr := s.policy.check(q.Query); perr != nil {
+		return perr.toolResult(), nil
+	}
+
+	rows, err := s.queryRows(ctx, q)
+	s.recordQuery(ctx, dataQueryPageTool.Name, q.Query, q.Variables, err)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to execute query", err), nil
+	}
+	// the page query reads one more row to find out if there is the next page
+	hasMore := len(rows) > c.pageSize
+	if hasMore {
+		rows = rows[:c.pageSize]
+	}
+	out := &dataQueryPageOutput{
+		Rows:      rows,
+		Count:     len(rows),
+		Page:      c.page + 1,
+		Paging:    pagingOffset,
+		Query:     q.Query,
+		Variables: q.Variables,
+	}
+	if c.keyset != "" {
+		out.Paging = pagingKeyset
+	}
+	if out.Rows == nil {
+		out.Rows = []map[string]any{}
+	}
+	if hasMore {
+		next := c.next(rows)
+		out.NextCursor = s.cursors.put(next)
+	}
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+func (s *Service) queryRows(ctx context.Context, q *dataBuildQueryOutput) ([]map[string]any, error) {
+	res, err := s.hugr.Query(ctx, q.Query, q.Variables)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	return decodeRows(res.DataPart(q.Path))
+}
+
+// decodeRows decodes the query result rows, the numbers are decoded as json.Number
+// to keep the precision of the big integer keys.
+func decodeRows(data any) ([]map[string]any, error) {
+	if data == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var rows []map[string]any
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// resultCursor is the position of the next page of the data object query.
+type resultCursor struct {
+	userID   string
+	input    dataQueryPageInput
+	pageSize int
+	keyset   string   // the primary key field for the keyset paging
+	last     any      // the primary key value of the last read row, json.Number for the numeric keys
+	keys     []string // the fields that make the order of the offset paging stable
+	offset   int
+	page     int
+}
+
+// next returns the cursor of the page after the rows.
+func (c *resultCursor) next(rows []map[string]any) *resultCursor {
+	n := *c
+	n.page++
+	if c.keyset != "" && len(rows) != 0 {
+		n.last = rows[len(rows)-1][c.keyset]
+		return &n
+	}
+	n.offset += len(rows)
+	return &n
+}
+
+// pagingKeys returns the primary key field if the rows can be paged by it (keyset), otherwise the fields
+// that are added to the order of the offset paging to make it stable: the distinct fields or the primary key fields.
+func (b *queryBuilder) pagingKeys(ctx context.Context, objectName string, input *dataQueryPageInput) (keyset string, keys []string, err error) {
+	if len(input.DistinctOn) != 0 {
+		// the rows are unique by the distinct fields
+		return "", input.DistinctOn, nil
+	}
+	ff, err := b.typeFields(ctx, objectName)
+	if err != nil {
+		return "", nil, err
+	}
+	var pk []string
+	for _, name := range slices.Sorted(maps.Keys(ff)) {
+		if ff[name].IsPrimaryKey {
+			pk = append(pk, name)
+		}
+	}
+	if len(pk) == 0 {
+		return "", nil, fmt.Errorf("data object %q has no primary key, the rows can't be paged in the stable order", objectName)
+	}
+	if len(pk) != 1 {
+		return "", pk, nil
+	}
+	switch {
+	case len(input.OrderBy) == 0:
+	case len(input.OrderBy) == 1 && input.OrderBy[0].Field == pk[0] &&
+		(input.OrderBy[0].Direction == "" || strings.EqualFold(input.OrderBy[0].Direction, "ASC")):
+	default:
+		return "", pk, nil
+	}
+	return pk[0], nil, nil
+}
+
+// pageQuery builds the query of the cursor page.
+func (b *queryBuilder) pageQuery(ctx context.Context, info *indexer.DataObjectQueriesInfo, c *resultCursor) (*dataBuildQueryOutput, error) {
+	req := &dataBuildQueryInput{
+		ObjectName: c.input.ObjectName,
+		Fields:     c.input.Fields,
+		Filter:     c.input.Filter,
+		Args:       c.input.Args,
+		OrderBy:    c.input.OrderBy,
+		DistinctOn: c.input.DistinctOn,
+		Limit:      c.pageSize + 1,
+	}
+	if c.keyset == "" {
+		req.Offset = c.offset
+		req.OrderBy = slices.Clone(req.OrderBy)
+		for _, k := range c.keys {
+			if !slices.ContainsFunc(req.OrderBy, func(o dataQueryOrderBy) bool { return o.Field == k }) {
+				req.OrderBy = append(req.OrderBy, dataQueryOrderBy{Field: k, Direction: "ASC"})
+			}
+		}
+		return b.build(ctx, info, req)
+	}
+	if !slices.Contains(req.Fields, c.keyset) {
+		req.Fields = append(slices.Clone(req.Fields), c.keyset)
+	}
+	req.OrderBy = []dataQueryOrderBy{{Field: c.keyset, Direction: "ASC"}}
+	if c.last != nil {
+		after := map[string]any{c.keyset: map[string]any{"gt": c.last}}
+		if len(req.Filter) == 0 {
+			req.Filter = after
+		} else {
+			req.Filter = map[string]any{"_and": []any{req.Filter, after}}
+		}
+	}
+	return b.build(ctx, info, req)
+}
+
+// resultCursors keeps the cursors in memory until they expire.
+type resultCursors struct {
+	mu    sync.Mutex
+	items map[string]cursorItem
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+type cursorItem struct {
+	c       resultCursor
+	expires time.Time
+}
+
+func newResultCursors(ttl time.Duration) *resultCursors {
+	return &resultCursors{items: map[string]cursorItem{}, ttl: ttl, now: time.Now}
+}
+
+func (rc *resultCursors) put(c *resultCursor) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	now := rc.now()
+	for k, item := range rc.items {
+		if now.After(item.expires) {
+			delete(rc.items, k)
+		}
+	}
+	rc.items[id] = cursorItem{c: *c, expires: now.Add(rc.ttl)}
+	return id
+}
+
+// get returns the copy of the cursor, the cursor can be read again (e.g. to repeat the failed page).
+func (rc *resultCursors) get(id string) (*resultCursor, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	item, ok := rc.items[id]
+	if !ok || rc.now().After(item.expires) {
+		return nil, false
+	}
+	c := item.c
+	return &c, true
+}
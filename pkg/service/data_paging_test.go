@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+func TestPageQuery(t *testing.T) {
+	types := map[string][]indexer.Field{
+		"shop_orders":        {testKey("id", "Int"), testScalar("total", "Int")},
+		"shop_lines":         {testKey("order_id", "Int"), testKey("line", "Int")},
+		"shop_logs":          {testScalar("message", "Int")},
+		"shop_orders_filter": {testInput("id", "IntFilter"), testInput("total", "IntFilter"), testInput("_and", "shop_orders_filter")},
+		"IntFilter":          {testInput("eq", "Int"), testInput("gt", "Int")},
+	}
+	info := &indexer.DataObjectQueriesInfo{
+		Name:       "shop_orders",
+		FilterType: "shop_orders_filter",
+		Module:     "shop",
+		Queries:    []indexer.DataObjectQueryInfo{{Name: "orders", Type: "select"}},
+	}
+	b := testQueryBuilder(types)
+	ctx := context.Background()
+
+	// keyset paging by the primary key
+	c := &resultCursor{pageSize: 2, input: dataQueryPageInput{
+		ObjectName: "shop_orders",
+		Fields:     []string{"total"},
+		Filter:     map[string]any{"total": map[string]any{"gt": 10}},
+	}}
+	var err error
+	c.keyset, c.keys, err = b.pagingKeys(ctx, "shop_orders", &c.input)
+	if err != nil || c.keyset != "id" || c.keys != nil {
+		t.Fatalf("unexpected keyset field %q %v: %v", c.keyset, c.keys, err)
+	}
+	// the big integer key keeps the precision
+	rows, err := decodeRows([]any{map[string]any{"id": 1}, map[string]any{"id": int64(9007199254740993)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = c.next(rows)
+	q, err := b.pageQuery(ctx, info, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(q.Query, `orders(filter: $filter, order_by: [{field: "id", direction: ASC}], limit: 3)`) ||
+		!strings.Contains(q.Query, "      id\n") {
+		t.Errorf("unexpected query:\n%s", q.Query)
+	}
+	expected := map[string]any{"_and": []any{
+		map[string]any{"total": map[string]any{"gt": 10}},
+		map[string]any{"id": map[string]any{"gt": json.Number("9007199254740993")}},
+	}}
+	if !reflect.DeepEqual(q.Variables["filter"], expected) {
+		t.Errorf("unexpected filter: %v", q.Variables["filter"])
+	}
+	if c.page != 1 || len(c.input.Fields) != 1 {
+		t.Errorf("unexpected cursor %+v", c)
+	}
+
+	// offset paging for the custom order and the composite key
+	for _, tc := range []struct {
+		object string
+		input  dataQueryPageInput
+		keys   []string
+	}{
+		{"shop_orders", dataQueryPageInput{OrderBy: []dataQueryOrderBy{{Field: "total"}}}, []string{"id"}},
+		{"shop_orders", dataQueryPageInput{DistinctOn: []string{"total"}}, []string{"total"}},
+		{"shop_lines", dataQueryPageInput{}, []string{"line", "order_id"}},
+	} {
+		if k, keys, _ := b.pagingKeys(ctx, tc.object, &tc.input); k != "" || !reflect.DeepEqual(keys, tc.keys) {
+			t.Errorf("%s %+v: unexpected paging keys %q %v", tc.object, tc.input, k, keys)
+		}
+	}
+	if _, _, err := b.pagingKeys(ctx, "shop_logs", &dataQueryPageInput{}); err == nil {
+		t.Error("expected error for the data object without the primary key")
+	}
+	c = &resultCursor{pageSize: 10, keys: []string{"id"}, input: dataQueryPageInput{
+		ObjectName: "shop_orders",
+		Fields:     []string{"id", "total"},
+		OrderBy:    []dataQueryOrderBy{{Field: "total", Direction: "DESC"}},
+	}}
+	c = c.next(make([]map[string]any, 10))
+	q, err = b.pageQuery(ctx, info, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(q.Query, `order_by: [{field: "total", direction: DESC}, {field: "id", direction: ASC}], limit: 11, offset: 10`) {
+		t.Errorf("unexpected query:\n%s", q.Query)
+	}
+	if len(c.input.OrderBy) != 1 {
+		t.Errorf("the cursor order is changed: %v", c.input.OrderBy)
+	}
+}
+
+func TestResultCursors(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rc := newResultCursors(time.Minute)
+	rc.now = func() time.Time { return now }
+
+	id := rc.put(&resultCursor{userID: "u1", page: 1})
+	c, ok := rc.get(id)
+	if !ok || c.userID != "u1" || c.page != 1 {
+		t.Fatalf("unexpected cursor %+v", c)
+	}
+	// the returned cursor is a copy
+	c.page = 5
+	if c, _ := rc.get(id); c.page != 1 {
+		t.Errorf("the cursor is changed: %+v", c)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := rc.get(id); ok {
+		t.Error("expected expired cursor")
+	}
+	rc.put(&resultCursor{})
+	if len(rc.items) != 1 {
+		t.Errorf("the expired cursors are not removed: %d", len(rc.items))
+	}
+}
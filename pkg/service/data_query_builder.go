@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vektah/gqlparser/v2/validator/core"
+)
+
+var dataBuildQueryTool = mcp.NewTool("data-build_query",
+	mcp.WithDescription("Build a correct GraphQL query for a data object from the selected fields (including reference paths), filter, arguments, order_by, limit and distinct_on. The module nesting and arguments placement are resolved from the schema index, the filter and arguments are validated against the data object filter and args types. The query is returned, not executed"),
+	mcp.WithInputSchema[dataBuildQueryInput](),
+	mcp.WithOutputSchema[dataBuildQueryOutput](),
+)
+
+type dataBuildQueryInput struct {
+	ObjectName string             `json:"object_name" jsonschema_description:"The name of the data object (GraphQL type) to query"`
+	Fields     []string           `json:"fields" jsonschema_description:"The fields to select, the reference (relation) fields are selected by the dotted paths, e.g. [\"id\", \"customer.name\", \"items.product.name\"]"`
+	Filter     map[string]any     `json:"filter,omitempty" jsonschema_description:"Optional filter, the JSON object that represents the GraphQL filter input of the data object, e.g. {\"status\": {\"eq\": \"new\"}, \"customer\": {\"category\": {\"eq\": \"premium\"}}}"`
+	Args       map[string]any     `json:"args,omitempty" jsonschema_description:"Optional arguments of the parameterized data object (view)"`
+	OrderBy    []dataQueryOrderBy `json:"order_by,omitempty" jsonschema_description:"Optional ordering, the ordered fields should be selected"`
+	Limit      int                `json:"limit,omitempty" jsonschema_description:"Optional maximum number of rows to return" jsonschema:"minimum=0"`
+	Offset     int                `json:"offset,omitempty" jsonschema_description:"Optional number of rows to skip" jsonschema:"minimum=0"`
+	DistinctOn []string           `json:"distinct_on,omitempty" jsonschema_description:"Optional fields to return the distinct rows on, the fields should be selected"`
+}
+
+type dataQueryOrderBy struct {
+	Field     string `json:"field" jsonschema_description:"The selected field path, e.g. name or customer.name"`
+	Direction string `json:"direction,omitempty" jsonschema_description:"The sort direction" jsonschema:"enum=ASC,enum=DESC,default=ASC"`
+}
+
+type dataBuildQueryOutput struct {
+	Query      string                    `json:"query" jsonschema_description:"The GraphQL query"`
+	Variables  map[string]any            `json:"variables,omitempty" jsonschema_description:"The query variables (filter and args)"`
+	Path       string                    `json:"path" jsonschema_description:"The path to the data object rows in the query result data"`
+	Validation *indexer.ValidationResult `json:"validation,omitempty" jsonschema_description:"The query validation result against the schema"`
+}
+
+func (s *Service) dataBuildQueryHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &dataBuildQueryInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+
+	info, err := s.indexer.DataObjectQueriesInfo(ctx, input.ObjectName)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get data object info", err), nil
+	}
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	out, err := b.build(ctx, info, input)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to build query", err), nil
+	}
+	validation, err := s.indexer.ValidateQuery(ctx, out.Query)
+	if err == nil && !validation.Valid {
+		out.Validation = validation
+	}
+
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+// queryBuilder builds the data object queries, the fields of the types are resolved from the index.
+type queryBuilder struct {
+	fields func(ctx context.Context, typeName string) ([]indexer.Field, error)
+	cache  map[string]map[string]indexer.Field
+}
+
+type selectionNode struct {
+	name     string
+	children []*selectionNode
+}
+
+func (n *selectionNode) child(name string) *selectionNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &selectionNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (b *queryBuilder) typeFields(ctx context.Context, typeName string) (map[string]indexer.Field, error) {
+	if ff, ok := b.cache[typeName]; ok {
+		return ff, nil
+	}
+	fields, err := b.fields(ctx, typeName)
+	if err != nil {
+		return nil, err
+	}
+	ff := make(map[string]indexer.Field, len(fields))
+	for _, f := range fields {
+		if !f.Exclude {
+			ff[f.Name] = f
+		}
+	}
+	if b.cache == nil {
+		b.cache = map[string]map[string]indexer.Field{}
+	}
+	b.cache[typeName] = ff
+	return ff, nil
+}
+
+func (b *queryBuilder) build(ctx context.Context, info *indexer.DataObjectQueriesInfo, req *dataBuildQueryInput) (*dataBuildQueryOutput, error) {
+	selectQuery := ""
+	for _, q := range info.Queries {
+		if q.Type == string(metainfo.QueryTypeSelect) {
+			selectQuery = q.Name
+			break
+		}
+	}
+	if selectQuery == "" {
+		return nil, fmt.Errorf("data object %q does not support select queries", info.Name)
+	}
+	if len(req.Fields) == 0 {
+		return nil, fmt.Errorf("at least one field should be selected")
+	}
+
+	// fields
+	root := &selectionNode{}
+	selected := map[string]bool{} // scalar paths
+	listPaths := map[string]bool{}
+	for _, path := range req.Fields {
+		isList, err := b.addSelection(ctx, root, info.Name, path)
+		if err != nil {
+			return nil, err
+		}
+		selected[path] = true
+		listPaths[path] = isList
+	}
+
+	// arguments
+	var args, varDefs []string
+	vars := map[string]any{}
+	if len(req.Args) != 0 {
+		if info.ArgsType == "" {
+			return nil, fmt.Errorf("data object %q does not accept arguments", info.Name)
+		}
+		if err := b.validateInput(ctx, req.Args, info.ArgsType, "args"); err != nil {
+			return nil, err
+		}
+		args = append(args, "args: $args")
+		varDefs = append(varDefs, "$args: "+info.ArgsType+"!")
+		vars["args"] = req.Args
+	}
+	if len(req.Filter) != 0 {
+		if info.FilterType == "" {
+			return nil, fmt.Errorf("data object %q does not support filters", info.Name)
+		}
+		if err := b.validateInput(ctx, req.Filter, info.FilterType, "filter"); err != nil {
+			return nil, err
+		}
+		args = append(args, "filter: $filter")
+		varDefs = append(varDefs, "$filter: "+info.FilterType)
+		vars["filter"] = req.Filter
+	}
+	if len(req.OrderBy) != 0 {
+		var items []string
+		for _, o := range req.OrderBy {
+			if !selected[o.Field] {
+				return nil, fmt.Errorf("order_by field %q should be selected", o.Field)
+			}
+			if listPaths[o.Field] {
+				return nil, fmt.Errorf("order_by field %q is selected through the list relation", o.Field)
+			}
+			dir := strings.ToUpper(o.Direction)
+			if dir == "" {
+				dir = "ASC"
+			}
+			if dir != "ASC" && dir != "DESC" {
+				return nil, fmt.Errorf("order_by direction %q should be ASC or DESC", o.Direction)
+			}
+			items = append(items, fmt.Sprintf("{field: %s, direction: %s}", strconv.Quote(o.Field), dir))
+		}
+		args = append(args, "order_by: ["+strings.Join(items, ", ")+"]")
+	}
+	if len(req.DistinctOn) != 0 {
+		var items []string
+		for _, f := range req.DistinctOn {
+			if !selected[f] || strings.Contains(f, ".") {
+				return nil, fmt.Errorf("distinct_on field %q should be a selected top-level field", f)
+			}
+			items = append(items, strconv.Quote(f))
+		}
+		args = append(args, "distinct_on: ["+strings.Join(items, ", ")+"]")
+	}
+	if req.Limit > 0 {
+		args = append(args, "limit: "+strconv.Itoa(req.Limit))
+	}
+	if req.Offset > 0 {
+		args = append(args, "offset: "+strconv.Itoa(req.Offset))
+	}
+
+	var modules []string
+	if info.Module != "" {
+		modules = strings.Split(info.Module, ".")
+	}
+	out := &dataBuildQueryOutput{
+		Query: selectionQuery(modules, selectQuery, varDefs, args, root.children),
+		Path:  strings.Join(append(modules, selectQuery), "."),
+	}
+	if len(vars) != 0 {
+		out.Variables = vars
+	}
+	return out, nil
+}
+
+// addSelection adds the field path to the selection tree, it returns true if the path goes through the list field.
+func (b *queryBuilder) addSelection(ctx context.Context, root *selectionNode, typeName, path string) (bool, error) {
+	parts := strings.Split(path, ".")
+	node, isList := root, false
+	for i, name := range parts {
+		ff, err := b.typeFields(ctx, typeName)
+		if err != nil {
+			return false, err
+		}
+		f, ok := ff[name]
+		if !ok {
+			return false, unknownNameError("field", strings.Join(parts[:i+1], "."), typeName, name, ff)
+		}
+		node = node.child(name)
+		last := i == len(parts)-1
+		isObject := f.FieldType != nil && f.FieldType.Kind == "OBJECT"
+		switch {
+		case last && isObject:
+			return false, fmt.Errorf("field %q is an object, select its fields with the dotted path (e.g. %s.<field>)", path, path)
+		case !last && !isObject:
+			return false, fmt.Errorf("field %q of type %s is not an object, it has no subfields", strings.Join(parts[:i+1], "."), f.Type)
+		}
+		isList = isList || f.IsList
+		typeName = f.Type
+	}
+	return isList, nil
+}
+
+// validateInput checks that the input object keys are the fields of the indexed input type.
+func (b *queryBuilder) validateInput(ctx context.Context, v any, typeName, path string) error {
+	switch v := v.(type) {
+	case []any:
+		for i, item := range v {
+			if err := b.validateInput(ctx, item, typeName, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		ff, err := b.typeFields(ctx, typeName)
+		if err != nil {
+			return err
+		}
+		if len(ff) == 0 {
+			// scalar (e.g. JSON) or not indexed type
+			return nil
+		}
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			f, ok := ff[k]
+			if !ok {
+				return unknownNameError("input field", path+"."+k, typeName, k, ff)
+			}
+			if err := b.validateInput(ctx, v[k], f.Type, path+"."+k); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func unknownNameError(kind, path, typeName, name string, ff map[string]indexer.Field) error {
+	err := fmt.Errorf("unknown %s %q (%s has no field %q)", kind, path, typeName, name)
+	if s := core.SuggestionList(name, slices.Collect(maps.Keys(ff))); len(s) != 0 {
+		err = fmt.Errorf("%w, did you mean %s?", err, core.QuotedOrList(s...))
+	}
+	return err
+}
+
+// selectionQuery returns the query of the data object selection nested into the modules.
+func selectionQuery(modules []string, selectQuery string, varDefs, args []string, nodes []*selectionNode) string {
+	var sb strings.Builder
+	sb.WriteString("query")
+	if len(varDefs) != 0 {
+		sb.WriteString(" (" + strings.Join(varDefs, ", ") + ")")
+	}
+	sb.WriteString(" {\n")
+	indent := "  "
+	for _, m := range modules {
+		sb.WriteString(indent + m + " {\n")
+		indent += "  "
+	}
+	sb.WriteString(indent + selectQuery)
+	if len(args) != 0 {
+		sb.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	writeSelectionSet(&sb, nodes, indent)
+	sb.WriteString("\n")
+	for range modules {
+		indent = indent[2:]
+		sb.WriteString(indent + "}\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func writeSelectionSet(sb *strings.Builder, nodes []*selectionNode, indent string) {
+	if len(nodes) == 0 {
+		return
+	}
+	sb.WriteString(" {\n")
+	for _, n := range nodes {
+		sb.WriteString(indent + "  " + n.name)
+		writeSelectionSet(sb, n.children, indent+"  ")
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + "}")
+}
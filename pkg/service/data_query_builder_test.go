@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+func TestBuildDataObjectQuery(t *testing.T) {
+	types := map[string][]indexer.Field{
+		"shop_orders": {
+			testScalar("id", "Int"),
+			testScalar("status", "String"),
+			testObject("customer", "shop_customers", false),
+			testObject("items", "shop_items", true),
+			{Name: "secret", Type: "String", Exclude: true},
+		},
+		"shop_customers": {testScalar("name", "String"), testScalar("category", "String")},
+		"shop_items":     {testScalar("quantity", "Int")},
+		"shop_orders_filter": {
+			testInput("status", "StringFilter"),
+			testInput("customer", "shop_customers_filter"),
+			testInput("_and", "shop_orders_filter"),
+		},
+		"shop_customers_filter": {testInput("category", "StringFilter")},
+		"StringFilter":          {testInput("eq", "String"), testInput("in", "String")},
+	}
+	info := &indexer.DataObjectQueriesInfo{
+		Name:       "shop_orders",
+		FilterType: "shop_orders_filter",
+		Module:     "shop.sales",
+		Queries: []indexer.DataObjectQueryInfo{
+			{Name: "orders_by_pk", Type: "select_one"},
+			{Name: "orders", Type: "select"},
+		},
+	}
+	newBuilder := func() *queryBuilder {
+		return testQueryBuilder(types)
+	}
+
+	t.Run("query", func(t *testing.T) {
+		out, err := newBuilder().build(context.Background(), info, &dataBuildQueryInput{
+			ObjectName: "shop_orders",
+			Fields:     []string{"id", "customer.name", "items.quantity", "customer.category"},
+			Filter: map[string]any{
+				"status":   map[string]any{"eq": "new"},
+				"customer": map[string]any{"category": map[string]any{"in": []any{"a"}}},
+				"_and":     []any{map[string]any{"status": map[string]any{"eq": "x"}}},
+			},
+			OrderBy:    []dataQueryOrderBy{{Field: "customer.name", Direction: "desc"}},
+			DistinctOn: []string{"id"},
+			Limit:      10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `query ($filter: shop_orders_filter) {
+  shop {
+    sales {
+      orders(filter: $filter, order_by: [{field: "customer.name", direction: DESC}], distinct_on: ["id"], limit: 10) {
+        id
+        customer {
+          name
+          category
+        }
+        items {
+          quantity
+        }
+      }
+    }
+  }
+}`
+		if out.Query != want {
+			t.Errorf("unexpected query:\n%s", out.Query)
+		}
+		if out.Path != "shop.sales.orders" {
+			t.Errorf("unexpected path: %s", out.Path)
+		}
+		if out.Variables["filter"] == nil {
+			t.Error("expected filter variable")
+		}
+	})
+
+	errs := []struct {
+		name string
+		req  dataBuildQueryInput
+		want string
+	}{
+		{name: "unknown field", req: dataBuildQueryInput{Fields: []string{"statsu"}}, want: `did you mean "status"?`},
+		{name: "excluded field", req: dataBuildQueryInput{Fields: []string{"secret"}}, want: "unknown field"},
+		{name: "object leaf", req: dataBuildQueryInput{Fields: []string{"customer"}}, want: "is an object"},
+		{name: "scalar path", req: dataBuildQueryInput{Fields: []string{"id.x"}}, want: "not an object"},
+		{name: "unknown filter", req: dataBuildQueryInput{Fields: []string{"id"}, Filter: map[string]any{"customer": map[string]any{"categori": map[string]any{}}}}, want: `"filter.customer.categori"`},
+		{name: "args", req: dataBuildQueryInput{Fields: []string{"id"}, Args: map[string]any{"a": 1}}, want: "does not accept arguments"},
+		{name: "order not selected", req: dataBuildQueryInput{Fields: []string{"id"}, OrderBy: []dataQueryOrderBy{{Field: "status"}}}, want: "should be selected"},
+		{name: "order list", req: dataBuildQueryInput{Fields: []string{"items.quantity"}, OrderBy: []dataQueryOrderBy{{Field: "items.quantity"}}}, want: "list relation"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newBuilder().build(context.Background(), info, &tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
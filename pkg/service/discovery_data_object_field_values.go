@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/hugr-lab/mcp/pkg/indexer"
@@ -11,19 +13,22 @@ import (
 )
 
 var discoveryDataObjectFieldValuesTool = mcp.NewTool("discovery-data_object_field_values",
-	mcp.WithDescription("Return field values stats for a specific field in a data object"),
+	mcp.WithDescription("Return field values stats for a specific field in a data object, optionally with the distributions: the top values with frequencies, the numeric histogram and the row counts per day, week or month. The fields of the referenced data objects and the JSON subfields are set by the dotted paths (e.g. patient.organization.state or attributes.color), they are aggregated through the references"),
 	mcp.WithInputSchema[schemaDataObjectFieldValuesInput](),
 	mcp.WithOutputSchema[DataObjectFieldValueStat](),
 )
 
 type schemaDataObjectFieldValuesInput struct {
 	ObjectName     string         `json:"object_name" jsonschema_description:"The name of the data object (GraphQL type) to query"`
-	FieldName      string         `json:"field_name" jsonschema_description:"The name of the field within the data object to get stats for"`
+	FieldName      string         `json:"field_name" jsonschema_description:"The name of the field within the data object to get stats for, the fields of the referenced objects and JSON subfields are set by the dotted path, e.g. customer.category or attributes.color"`
 	Offset         int            `json:"offset,omitempty" jsonschema_description:"The number of distinct values to skip before starting to collect the result set" jsonschema:"minimum=0,default=0"`
 	Limit          int            `json:"limit" jsonschema_description:"The number of top distinct values to return" jsonschema:"minimum=1,default=10,maximum=100"`
 	CalculateStats bool           `json:"calculate_stats,omitempty" jsonschema_description:"Whether to calculate and return statistical summaries (min, max, avg, distinct count, null count) for the field" jsonschema:"default=false"`
 	Filter         map[string]any `json:"filter,omitempty" jsonschema_description:"Optional filter to apply when querying field stats. The filter should be a JSON object that represents the GraphQL filter input for the data object. For example, to filter on a users table by age greater than 30, you might use: {\"age\": {\"gt\": 30}}"`
 	Args           map[string]any `json:"args,omitempty" jsonschema_description:"Optional arguments to pass to the data object (if it is parameterized view). The args should be a JSON object that represents the GraphQL arguments input for the data object."`
+	TopN           int            `json:"top_n,omitempty" jsonschema_description:"Return the N most frequent values with their row counts" jsonschema:"minimum=0,maximum=100"`
+	HistogramBins  int            `json:"histogram_bins,omitempty" jsonschema_description:"Return the equal-width histogram with the number of bins between the min and max values of the numeric field" jsonschema:"minimum=0,maximum=50"`
+	TimeBucket     string         `json:"time_bucket,omitempty" jsonschema_description:"Return the row counts per day, week or month of the Timestamp or Date field" jsonschema:"enum=day,enum=week,enum=month"`
 }
 
 type DataObjectFieldValueStat struct {
@@ -32,6 +37,23 @@ type DataObjectFieldValueStat struct {
 	Avg      any   `json:"avg,omitempty"`
 	Distinct int   `json:"distinct,omitempty"`
 	Values   []any `json:"values,omitempty"`
+	// ValuesTruncated is set if the distinct values of the nested field are over the limit
+	ValuesTruncated bool `json:"values_truncated,omitempty" jsonschema_description:"Whether the distinct values of the JSON subfield are truncated to the first 10000 before the paging, the values after them are not returned"`
+	// the resolved nested field path
+	Field         string                     `json:"field,omitempty" jsonschema_description:"The aggregated field of the last referenced data object"`
+	FieldType     string                     `json:"field_type,omitempty" jsonschema_description:"The aggregated field type"`
+	ReferencePath []DataObjectFieldReference `json:"reference_path,omitempty" jsonschema_description:"The references that were used to reach the field"`
+	JSONPath      string                     `json:"json_path,omitempty" jsonschema_description:"The path of the subfield in the JSON field"`
+	// the distributions
+	TopValues   []FieldValueCount   `json:"top_values,omitempty" jsonschema_description:"The most frequent values with the row counts"`
+	Histogram   []FieldHistogramBin `json:"histogram,omitempty" jsonschema_description:"The equal-width histogram of the numeric field"`
+	TimeBuckets []FieldValueCount   `json:"time_buckets,omitempty" jsonschema_description:"The row counts per time bucket, ordered by time"`
+}
+
+type DataObjectFieldReference struct {
+	Field  string `json:"field" jsonschema_description:"The reference (relation) field"`
+	Type   string `json:"type" jsonschema_description:"The referenced data object"`
+	IsList bool   `json:"is_list,omitempty" jsonschema_description:"Whether the reference returns many rows, the values are aggregated over all of them"`
 }
 
 func (s *Service) discoveryDataObjectFieldValuesHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
@@ -40,10 +62,6 @@ func (s *Service) discoveryDataObjectFieldValuesHandler(ctx context.Context, req
 	if err := request.BindArguments(input); err != nil {
 		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
 	}
-	if strings.Contains(input.FieldName, ".") {
-		return mcp.NewToolResultError("field_name should be a top-level field, nested fields are not supported"), nil
-	}
-
 	// get data object queries info
 	tqq, err := s.indexer.DataObjectQueriesInfo(ctx, input.ObjectName)
 	if err != nil {
@@ -52,11 +70,23 @@ func (s *Service) discoveryDataObjectFieldValuesHandler(ctx context.Context, req
 	if tqq == nil {
 		return mcp.NewToolResultError("data object not found"), nil
 	}
+	if perr := s.policy.checkDataObject(tqq.Module, dataObjectQueries(tqq, metainfo.QueryTypeAggregate, metainfo.QueryTypeAggregateBucket)...); perr != nil {
+		return perr.toolResult(), nil
+	}
 
 	// prepare query
-	stats, err := s.queryDataObjectFieldStats(ctx, tqq, *input)
-	if err != nil {
-		return mcp.NewToolResultErrorFromErr("failed to query field stats", err), nil
+	var stats DataObjectFieldValueStat
+	if input.CalculateStats || input.Limit > 0 || !input.distribution() {
+		stats, err = s.queryDataObjectFieldStats(ctx, tqq, *input)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to query field stats", err), nil
+		}
+	}
+	if input.distribution() {
+		err = s.queryFieldDistributions(ctx, tqq, *input, &stats)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to query field distribution", err), nil
+		}
 	}
 
 	out := mcp.NewToolResultStructuredOnly(stats)
@@ -67,6 +97,12 @@ const (
 	dataObjectFieldStatsMinMaxTemplate = `stats: %s %s @cache(ttl: $ttl) {field: %s {min, max, avg, distinct: count }}`
 	dataObjectFieldStatsOtherTemplate  = `stats: %s %s @cache(ttl: $ttl)  {field: %s { distinct: count }}`
 	dataObjectFieldValuesTemplate      = `values: %s(%s limit: $limit offset: $offset distinct_on: ["field"]) @cache(ttl: $ttl) { field: %s{ list }}`
+	// the nested fields are aggregated through the references, the distinct values of the JSON subfields are paged after the query
+	dataObjectNestedFieldStatsTemplate  = `stats: %s %s @cache(ttl: $ttl) {field: %s}`
+	dataObjectNestedFieldValuesTemplate = `values: %s %s @cache(ttl: $ttl) {field: %s}`
+
+	// maxNestedListValues limits the distinct values that are paged after the query
+	maxNestedListValues = 10000
 )
 
 func (s *Service) queryDataObjectFieldStats(ctx context.Context, info *indexer.DataObjectQueriesInfo, req schemaDataObjectFieldValuesInput) (DataObjectFieldValueStat, error) {
@@ -82,12 +118,15 @@ func (s *Service) queryDataObjectFieldStats(ctx context.Context, info *indexer.D
 	}
 
 	// field type to decide which stats to calculate
-	fieldType, err := s.indexer.DataObjectFieldType(ctx, req.ObjectName, req.FieldName)
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	fp, err := b.resolveFieldPath(ctx, req.ObjectName, req.FieldName)
 	if err != nil {
 		return DataObjectFieldValueStat{}, fmt.Errorf("get data object field type: %w", err)
 	}
-	if fieldType == "" {
-		return DataObjectFieldValueStat{}, fmt.Errorf("field %q not found in data object %q", req.FieldName, req.ObjectName)
+	fieldType := fp.fieldType
+	if fp.jsonPath != "" {
+		// the JSON subfield type is unknown
+		fieldType = "JSON"
 	}
 
 	if info.ArgsType == "" {
@@ -119,23 +158,65 @@ func (s *Service) queryDataObjectFieldStats(ctx context.Context, info *indexer.D
 		if args != "" {
 			args = "(" + args + ")"
 		}
+		var sel string
 		switch fieldType {
 		case "Int", "Float", "BigInt", "Timestamp", "Date", "Time":
-			query = fmt.Sprintf(dataObjectFieldStatsMinMaxTemplate, aggQuery, args, req.FieldName)
+			sel = "min, max, avg, distinct: count"
 		case "String", "JSON", "Boolean", "Geometry":
+			sel = "distinct: count" + fp.jsonPathArg("")
+		}
+		switch {
+		case sel == "":
+		case fp.nested():
+			query = fmt.Sprintf(dataObjectNestedFieldStatsTemplate, aggQuery, args, fp.selection(sel))
+		case sel == "distinct: count":
 			query = fmt.Sprintf(dataObjectFieldStatsOtherTemplate, aggQuery, args, req.FieldName)
+		default:
+			query = fmt.Sprintf(dataObjectFieldStatsMinMaxTemplate, aggQuery, args, req.FieldName)
 		}
 	}
-	if calcValues {
+	// the values of the reference fields are grouped and paged by the bucket aggregation query,
+	// the JSON subfields are aggregated to the distinct list that is paged after the query
+	bucketQuery := ""
+	if qq := dataObjectQueries(info, metainfo.QueryTypeAggregateBucket); len(qq) != 0 && fp.nested() && fp.jsonPath == "" {
+		bucketQuery = qq[0]
+	}
+	if calcValues && bucketQuery == "" {
 		if query != "" {
 			query += "\n"
 		}
-		query += fmt.Sprintf(dataObjectFieldValuesTemplate, aggQuery, args, req.FieldName)
+		if fp.nested() {
+			vargs := args
+			if vargs != "" {
+				vargs = "(" + vargs + ")"
+			}
+			query += fmt.Sprintf(dataObjectNestedFieldValuesTemplate, aggQuery, vargs, fp.selection("list"+fp.jsonPathArg("distinct: true")))
+		} else {
+			query += fmt.Sprintf(dataObjectFieldValuesTemplate, aggQuery, args, req.FieldName)
+		}
 	}
-	if query == "" {
+	if query == "" && (!calcValues || bucketQuery == "") {
 		return DataObjectFieldValueStat{}, fmt.Errorf("nothing to calculate for field type %q", fieldType)
 	}
 
+	var stats DataObjectFieldValueStat
+	if query != "" {
+		if err := s.queryFieldStatsValues(ctx, info, req, fp, query, calcValues && bucketQuery == "", &stats); err != nil {
+			return DataObjectFieldValueStat{}, err
+		}
+	}
+	stats.Field, stats.FieldType, stats.ReferencePath, stats.JSONPath = fp.field, fp.fieldType, fp.references, fp.jsonPath
+	if calcValues && bucketQuery != "" {
+		stats.Values, err = s.queryNestedFieldValues(ctx, info, fp, req, bucketQuery)
+		if err != nil {
+			return DataObjectFieldValueStat{}, fmt.Errorf("failed to query values: %w", err)
+		}
+	}
+	return stats, nil
+}
+
+// queryFieldStatsValues executes the stats and the values query of the field.
+func (s *Service) queryFieldStatsValues(ctx context.Context, info *indexer.DataObjectQueriesInfo, req schemaDataObjectFieldValuesInput, fp *fieldValuesPath, query string, calcValues bool, stats *DataObjectFieldValueStat) error {
 	// add module path if not core
 	pp := strings.Split(info.Module, ".")
 	var pre, post string
@@ -145,11 +226,14 @@ func (s *Service) queryDataObjectFieldStats(ctx context.Context, info *indexer.D
 	}
 	query = pre + " " + query + " " + post
 
-	args = "$ttl: Int! $limit: Int! $offset: Int!"
+	args := "$ttl: Int!"
 	vars := map[string]any{
-		"ttl":    s.cfg.ttl,
-		"limit":  req.Limit,
-		"offset": req.Offset,
+		"ttl": s.cfg.ttl,
+	}
+	if calcValues && !fp.nested() {
+		args += " $limit: Int! $offset: Int!"
+		vars["limit"] = req.Limit
+		vars["offset"] = req.Offset
 	}
 	if info.ArgsType != "" {
 		args += " $args: " + info.ArgsType + "!"
@@ -164,42 +248,183 @@ func (s *Service) queryDataObjectFieldStats(ctx context.Context, info *indexer.D
 	query = "query fieldValues(" + args + ") {\n" + query + "\n}"
 	res, err := s.hugr.Query(ctx, query, vars)
 	if err != nil {
-		return DataObjectFieldValueStat{}, err
+		return err
 	}
 	defer res.Close()
 	if res.Err() != nil {
-		return DataObjectFieldValueStat{}, res.Err()
+		return res.Err()
 	}
 
 	// parse result
-	var stats DataObjectFieldValueStat
 	path := ""
 	if info.Module != "" {
 		path = info.Module + "."
 	}
 	if req.CalculateStats {
 		var data struct {
-			Stats DataObjectFieldValueStat `json:"field"`
+			Stats map[string]any `json:"field"`
 		}
 
 		err = res.ScanData(path+"stats", &data)
 		if err != nil {
-			return DataObjectFieldValueStat{}, fmt.Errorf("failed to parse stats result: %w", err)
+			return fmt.Errorf("failed to parse stats result: %w", err)
+		}
+		if err := fp.scanLeaf(data.Stats, stats); err != nil {
+			return fmt.Errorf("failed to parse stats result: %w", err)
 		}
-		stats = data.Stats
 	}
 	if !calcValues {
-		return stats, nil
+		return nil
 	}
 	var vals struct {
-		Field struct {
-			List []any `json:"list"`
-		} `json:"field"`
+		Field map[string]any `json:"field"`
 	}
 	err = res.ScanData(path+"values", &vals)
 	if err != nil {
-		return DataObjectFieldValueStat{}, fmt.Errorf("failed to parse values result: %w", err)
+		return fmt.Errorf("failed to parse values result: %w", err)
 	}
-	stats.Values = vals.Field.List
-	return stats, nil
+	var list struct {
+		List []any `json:"list"`
+	}
+	if err := fp.scanLeaf(vals.Field, &list); err != nil {
+		return fmt.Errorf("failed to parse values result: %w", err)
+	}
+	stats.Values = list.List
+	if fp.nested() {
+		if len(stats.Values) > maxNestedListValues {
+			stats.Values, stats.ValuesTruncated = stats.Values[:maxNestedListValues], true
+		}
+		stats.Values = pageValues(stats.Values, req.Offset, req.Limit)
+	}
+	return nil
+}
+
+// queryNestedFieldValues returns the page of the distinct values of the field reached through the references,
+// the values are grouped by the bucket aggregation query and paged on the server.
+func (s *Service) queryNestedFieldValues(ctx context.Context, info *indexer.DataObjectQueriesInfo, fp *fieldValuesPath, req schemaDataObjectFieldValuesInput, bucketQuery string) ([]any, error) {
+	fq := newFieldStatsQuery(info, req, s.cfg.ttl)
+	fq.add(fmt.Sprintf("values: %s(%s order_by: [{field: %s, direction: ASC}] limit: %d offset: %d) @cache(ttl: $ttl) { key { %s } }",
+		bucketQuery, fq.args, strconv.Quote(fp.keyPath()), req.Limit, req.Offset, fp.bucketKey("")))
+
+	res, err := s.hugr.Query(ctx, fq.query(), fq.vars)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	var buckets []struct {
+		Key map[string]any `json:"key"`
+	}
+	if err := res.ScanData(fq.path("values"), &buckets); err != nil {
+		return nil, fmt.Errorf("failed to parse values result: %w", err)
+	}
+	values := make([]any, 0, len(buckets))
+	for _, b := range buckets {
+		values = append(values, fp.keyValue(b.Key))
+	}
+	return values, nil
+}
+
+// fieldValuesPath is the field path resolved through the references and the JSON field.
+type fieldValuesPath struct {
+	references []DataObjectFieldReference
+	field      string
+	fieldType  string
+	jsonPath   string
+}
+
+// resolveFieldPath resolves the dotted field path by the indexed fields of the data object and the referenced objects.
+func (b *queryBuilder) resolveFieldPath(ctx context.Context, objectName, path string) (*fieldValuesPath, error) {
+	parts := strings.Split(path, ".")
+	fp := &fieldValuesPath{}
+	typeName := objectName
+	for i, name := range parts {
+		ff, err := b.typeFields(ctx, typeName)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := ff[name]
+		if !ok {
+			return nil, unknownNameError("field", strings.Join(parts[:i+1], "."), typeName, name, ff)
+		}
+		last := i == len(parts)-1
+		isObject := f.FieldType != nil && f.FieldType.Kind == "OBJECT"
+		switch {
+		case last && isObject:
+			return nil, fmt.Errorf("field %q is a reference to %s, set its field with the dotted path (e.g. %s.<field>)", path, f.Type, path)
+		case last:
+			fp.field, fp.fieldType = name, f.Type
+			return fp, nil
+		case isObject:
+			fp.references = append(fp.references, DataObjectFieldReference{Field: name, Type: f.Type, IsList: f.IsList})
+			typeName = f.Type
+		case f.Type == "JSON":
+			fp.field, fp.fieldType = name, f.Type
+			fp.jsonPath = strings.Join(parts[i+1:], ".")
+			return fp, nil
+		default:
+			return nil, fmt.Errorf("field %q of type %s is not a reference or JSON, it has no subfields", strings.Join(parts[:i+1], "."), f.Type)
+		}
+	}
+	return fp, nil
+}
+
+// nested returns true if the field is not the top-level field of the data object.
+func (fp *fieldValuesPath) nested() bool {
+	return len(fp.references) != 0 || fp.jsonPath != ""
+}
+
+// jsonPathArg returns the arguments of the JSON aggregation function.
+func (fp *fieldValuesPath) jsonPathArg(args string) string {
+	if fp.jsonPath != "" {
+		if args != "" {
+			args = ", " + args
+		}
+		args = "path: " + strconv.Quote(fp.jsonPath) + args
+	}
+	if args == "" {
+		return ""
+	}
+	return "(" + args + ")"
+}
+
+// selection returns the aggregation selection of the field through the references.
+func (fp *fieldValuesPath) selection(aggs string) string {
+	sel := fp.field + " { " + aggs + " }"
+	for i := len(fp.references) - 1; i >= 0; i-- {
+		sel = fp.references[i].Field + " { " + sel + " }"
+	}
+	return sel
+}
+
+// scanLeaf decodes the aggregation of the field, the result is nested by the references
+// (the first reference or the field is aliased as the field).
+func (fp *fieldValuesPath) scanLeaf(data map[string]any, v any) error {
+	if len(fp.references) != 0 {
+		for _, r := range fp.references[1:] {
+			data, _ = data[r.Field].(map[string]any)
+		}
+		data, _ = data[fp.field].(map[string]any)
+	}
+	if data == nil {
+		return nil
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func pageValues(values []any, offset, limit int) []any {
+	if offset >= len(values) {
+		return nil
+	}
+	values = values[offset:]
+	if len(values) > limit {
+		values = values[:limit]
+	}
+	return values
 }
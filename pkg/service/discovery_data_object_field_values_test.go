@@ -1,6 +1,11 @@
 package service
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
 
 func TestDiscoveryDataObjectFieldValues(t *testing.T) {
 	s := New(testConfig)
@@ -30,3 +35,59 @@ func TestDiscoveryDataObjectFieldValues(t *testing.T) {
 	t.Logf("Stats: %+v", stats)
 
 }
+
+func TestResolveFieldPath(t *testing.T) {
+	types := map[string][]indexer.Field{
+		"visits":   {testScalar("id", "Int"), testObject("patient", "patients", false), testScalar("attributes", "JSON")},
+		"patients": {testScalar("name", "String"), testObject("organization", "orgs", false), testObject("visits", "visits", true)},
+		"orgs":     {testScalar("state", "String")},
+	}
+	b := testQueryBuilder(types)
+	ctx := context.Background()
+
+	fp, err := b.resolveFieldPath(ctx, "visits", "patient.organization.state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp.field != "state" || fp.fieldType != "String" || len(fp.references) != 2 || fp.references[1].Type != "orgs" {
+		t.Errorf("unexpected path %+v", fp)
+	}
+	if sel := fp.selection("list" + fp.jsonPathArg("distinct: true")); sel != "patient { organization { state { list(distinct: true) } } }" {
+		t.Errorf("unexpected selection %q", sel)
+	}
+	if key := fp.bucketKey(""); key != "patient { organization { state } }" || fp.keyPath() != "key.patient.organization.state" {
+		t.Errorf("unexpected bucket key %q %q", key, fp.keyPath())
+	}
+	var list struct {
+		List []any `json:"list"`
+	}
+	data := map[string]any{"organization": map[string]any{"state": map[string]any{"list": []any{"CA", "NY"}}}}
+	if err := fp.scanLeaf(data, &list); err != nil || len(list.List) != 2 {
+		t.Errorf("unexpected values %v: %v", list.List, err)
+	}
+
+	fp, err = b.resolveFieldPath(ctx, "visits", "patient.visits.attributes.color.name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fp.field != "attributes" || fp.jsonPath != "color.name" || !fp.references[1].IsList {
+		t.Errorf("unexpected path %+v", fp)
+	}
+	if sel := fp.selection("distinct: count" + fp.jsonPathArg("")); sel != `patient { visits { attributes { distinct: count(path: "color.name") } } }` {
+		t.Errorf("unexpected selection %q", sel)
+	}
+
+	fp, err = b.resolveFieldPath(ctx, "visits", "id")
+	if err != nil || fp.nested() {
+		t.Errorf("unexpected path %+v: %v", fp, err)
+	}
+
+	for _, path := range []string{"patient", "patient.nmae", "id.value"} {
+		if _, err := b.resolveFieldPath(ctx, "visits", path); err == nil {
+			t.Errorf("%s: expected error", path)
+		}
+	}
+	if v := pageValues([]any{1, 2, 3}, 1, 1); len(v) != 1 || v[0] != 2 {
+		t.Errorf("unexpected page %v", v)
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/query-engine/pkg/compiler"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkt"
+	"github.com/paulmach/orb/geojson"
+)
+
+// The geometry field shape: the extent is calculated by the aggregation query, the geometry types are read
+// from the sample rows, the SRID is guessed from the extent coordinates and the H3 coverage is calculated
+// by the h3 root query that aggregates the data object by the cells of the resolution.
+
+const (
+	defaultGeometrySampleSize = 100
+	maxGeometrySampleSize     = 1000
+	maxH3Resolution           = 15
+	h3TopCells                = 10
+	// maxH3Cells limits the estimated number of the cells of the H3 coverage query
+	maxH3Cells = 50000
+)
+
+// h3AvgCellArea is the average area of the H3 cells in km² by the resolution.
+var h3AvgCellArea = [maxH3Resolution + 1]float64{
+	4357449.416078381, 609788.441794133, 86801.780398997, 12393.434655088,
+	1770.347654491, 252.903858182, 36.129062164, 5.161293360,
+	0.737327598, 0.105332513, 0.015047502, 0.002149643,
+	0.000307092, 0.000043870, 0.000006267, 0.000000895,
+}
+
+var discoveryDataObjectGeometryTool = mcp.NewTool("discovery-data_object_geometry",
+	mcp.WithDescription("Return the shape of a geometry field of a data object: the extent and bounding box, the geometry types of the sample rows, the SRID hint and optionally the H3 cells coverage of the resolution (the number of cells and the rows per cell). Use it before the spatial filters and the H3 aggregations to choose the area, the spatial operations and the H3 resolution"),
+	mcp.WithInputSchema[dataObjectGeometryInput](),
+	mcp.WithOutputSchema[DataObjectGeometryInfo](),
+)
+
+type dataObjectGeometryInput struct {
+	ObjectName    string         `json:"object_name" jsonschema_description:"The name of the data object (GraphQL type) to query"`
+	FieldName     string         `json:"field_name,omitempty" jsonschema_description:"The geometry field, the first geometry field of the data object if omitted"`
+	Filter        map[string]any `json:"filter,omitempty" jsonschema_description:"Optional filter, the JSON object that represents the GraphQL filter input of the data object"`
+	Args          map[string]any `json:"args,omitempty" jsonschema_description:"Optional arguments of the parameterized data object (view)"`
+	SampleSize    int            `json:"sample_size,omitempty" jsonschema_description:"The number of rows to read the geometry types from" jsonschema:"minimum=1,maximum=1000,default=100"`
+	H3Resolution  *int           `json:"h3_resolution,omitempty" jsonschema_description:"Calculate the H3 cells coverage of the resolution (0-15), the higher resolutions return more cells and are slower" jsonschema:"minimum=0,maximum=15"`
+	TransformFrom int            `json:"transform_from,omitempty" jsonschema_description:"The SRID to reproject the geometry from to calculate the H3 coverage, set it if the geometry is not in EPSG:4326 and the field SRID is not declared in the schema"`
+}
+
+type DataObjectGeometryInfo struct {
+	ObjectName     string              `json:"object_name" jsonschema_description:"The data object name"`
+	Field          string              `json:"field" jsonschema_description:"The geometry field"`
+	GeometryFields []string            `json:"geometry_fields,omitempty" jsonschema_description:"All geometry fields of the data object"`
+	RowsCount      int                 `json:"rows_count" jsonschema_description:"The number of rows"`
+	Count          int                 `json:"count" jsonschema_description:"The number of rows with the not null geometry"`
+	BBox           []float64           `json:"bbox,omitempty" jsonschema_description:"The bounding box of the geometries: [min_x, min_y, max_x, max_y]"`
+	Extent         any                 `json:"extent,omitempty" jsonschema_description:"The extent of the geometries as GeoJSON geometry"`
+	Types          []GeometryTypeCount `json:"geometry_types,omitempty" jsonschema_description:"The geometry types of the sample rows (not of all rows), the rare types can be missing"`
+	SampleSize     int                 `json:"sample_size" jsonschema_description:"The number of the sample rows with the not null geometry"`
+	SRID           int                 `json:"srid_hint,omitempty" jsonschema_description:"The probable SRID of the geometry, guessed by the coordinates range"`
+	SRIDNote       string              `json:"srid_note,omitempty" jsonschema_description:"The SRID hint explanation"`
+	H3             *H3Coverage         `json:"h3,omitempty" jsonschema_description:"The H3 cells coverage"`
+}
+
+type GeometryTypeCount struct {
+	Type  string `json:"type" jsonschema_description:"The GeoJSON geometry type"`
+	Count int    `json:"count" jsonschema_description:"The number of the sample rows"`
+}
+
+type H3Coverage struct {
+	Resolution  int           `json:"resolution" jsonschema_description:"The H3 resolution"`
+	Requested   int           `json:"requested_resolution,omitempty" jsonschema_description:"The requested resolution if it is lowered to limit the number of cells"`
+	Note        string        `json:"note,omitempty" jsonschema_description:"Why the resolution is lowered"`
+	Query       string        `json:"query" jsonschema_description:"The H3 data query field of the data object"`
+	Cells       int           `json:"cells" jsonschema_description:"The number of cells that contain rows"`
+	RowsCount   int           `json:"rows_count" jsonschema_description:"The sum of the rows over the cells, the geometries that cross the cells are counted in each of them"`
+	MaxCellRows int           `json:"max_cell_rows" jsonschema_description:"The maximum number of rows in a cell"`
+	AvgCellRows float64       `json:"avg_cell_rows" jsonschema_description:"The average number of rows in a cell"`
+	TopCells    []H3CellCount `json:"top_cells,omitempty" jsonschema_description:"The cells with the most rows"`
+}
+
+type H3CellCount struct {
+	Cell  any `json:"cell" jsonschema_description:"The H3 cell"`
+	Count int `json:"count" jsonschema_description:"The number of rows"`
+}
+
+func (s *Service) discoveryDataObjectGeometryHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &dataObjectGeometryInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.H3Resolution != nil && (*input.H3Resolution < 0 || *input.H3Resolution > maxH3Resolution) {
+		return mcp.NewToolResultError("h3_resolution should be between 0 and 15"), nil
+	}
+	if input.SampleSize <= 0 {
+		input.SampleSize = defaultGeometrySampleSize
+	}
+	input.SampleSize = min(input.SampleSize, maxGeometrySampleSize)
+
+	info, err := s.indexer.DataObjectQueriesInfo(ctx, input.ObjectName)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get data object info", err), nil
+	}
+	if info == nil {
+		return mcp.NewToolResultError("data object not found"), nil
+	}
+	if perr := s.policy.checkDataObject(info.Module, dataObjectQueries(info, metainfo.QueryTypeAggregate, metainfo.QueryTypeSelect)...); perr != nil {
+		return perr.toolResult(), nil
+	}
+	if info.ArgsType == "" {
+		input.Args = nil
+	}
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	fields, err := b.geometryFields(ctx, info.Name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get data object fields", err), nil
+	}
+	if len(fields) == 0 {
+		return mcp.NewToolResultError("data object has no geometry fields"), nil
+	}
+	out := &DataObjectGeometryInfo{
+		ObjectName:     info.Name,
+		Field:          input.FieldName,
+		GeometryFields: fields,
+	}
+	if out.Field == "" {
+		out.Field = fields[0]
+	}
+	if !slices.Contains(fields, out.Field) {
+		return mcp.NewToolResultError(fmt.Sprintf("field %q is not a geometry field, the geometry fields: %s", out.Field, strings.Join(fields, ", "))), nil
+	}
+
+	if err := s.queryGeometryShape(ctx, info, input, out); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to query geometry extent", err), nil
+	}
+	out.SRID, out.SRIDNote = sridHint(out.BBox)
+	if input.H3Resolution != nil {
+		res := h3MaxResolution(out, input.TransformFrom, *input.H3Resolution)
+		out.H3, err = s.queryH3Coverage(ctx, info, input, out.Field, res)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to query H3 coverage", err), nil
+		}
+		if res != *input.H3Resolution {
+			out.H3.Requested = *input.H3Resolution
+			out.H3.Note = fmt.Sprintf("the resolution is lowered to cover the extent by at most %d cells", maxH3Cells)
+		}
+	}
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+// geometryFields returns the not list geometry fields of the data object.
+func (b *queryBuilder) geometryFields(ctx context.Context, objectName string) ([]string, error) {
+	ff, err := b.typeFields(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	var fields []string
+	for _, name := range slices.Sorted(maps.Keys(ff)) {
+		if f := ff[name]; f.Type == compiler.GeometryTypeName && !f.IsList {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// queryGeometryShape queries the extent and the sample geometries of the field.
+func (s *Service) queryGeometryShape(ctx context.Context, info *indexer.DataObjectQueriesInfo, input *dataObjectGeometryInput, out *DataObjectGeometryInfo) error {
+	var aggQuery, selectQuery string
+	for _, q := range info.Queries {
+		switch {
+		case q.Type == string(metainfo.QueryTypeAggregate) && aggQuery == "":
+			aggQuery = q.Name
+		case q.Type == string(metainfo.QueryTypeSelect) && selectQuery == "":
+			selectQuery = q.Name
+		}
+	}
+	if aggQuery == "" || selectQuery == "" {
+		return fmt.Errorf("data object %q does not support aggregation and select queries", info.Name)
+	}
+
+	fq := newFieldStatsQuery(info, schemaDataObjectFieldValuesInput{Filter: input.Filter, Args: input.Args}, s.cfg.ttl)
+	fq.add(fmt.Sprintf("stats: %s %s @cache(ttl: $ttl) { _rows_count field: %s { count extent } }", aggQuery, fq.parenArgs(), out.Field))
+	sampleArgs := "limit: " + strconv.Itoa(input.SampleSize)
+	if fq.args != "" {
+		sampleArgs = fq.args + " " + sampleArgs
+	}
+	fq.add(fmt.Sprintf("sample: %s(%s) @cache(ttl: $ttl) { geom: %s }", selectQuery, sampleArgs, out.Field))
+
+	res, err := s.hugr.Query(ctx, fq.query(), fq.vars)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return res.Err()
+	}
+	var stats struct {
+		RowsCount int `json:"_rows_count"`
+		Field     struct {
+			Count  int `json:"count"`
+			Extent any `json:"extent"`
+		} `json:"field"`
+	}
+	if err := res.ScanData(fq.path("stats"), &stats); err != nil {
+		return fmt.Errorf("failed to parse extent result: %w", err)
+	}
+	out.RowsCount, out.Count = stats.RowsCount, stats.Field.Count
+	if stats.Field.Extent != nil {
+		extent, err := parseGeometry(stats.Field.Extent)
+		if err != nil {
+			return fmt.Errorf("failed to parse extent: %w", err)
+		}
+		out.Extent = geojson.NewGeometry(extent)
+		bound := extent.Bound()
+		out.BBox = []float64{bound.Min.X(), bound.Min.Y(), bound.Max.X(), bound.Max.Y()}
+	}
+
+	var sample []struct {
+		Geom any `json:"geom"`
+	}
+	if err := res.ScanData(fq.path("sample"), &sample); err != nil {
+		return fmt.Errorf("failed to parse sample result: %w", err)
+	}
+	// the sample rows without the geometry are skipped
+	geoms := make([]any, 0, len(sample))
+	for _, r := range sample {
+		if r.Geom != nil {
+			geoms = append(geoms, r.Geom)
+		}
+	}
+	out.SampleSize = len(geoms)
+	out.Types, err = geometryTypes(geoms)
+	return err
+}
+
+// queryH3Coverage counts the rows of the data object in the H3 cells of the resolution.
+func (s *Service) queryH3Coverage(ctx context.Context, info *indexer.DataObjectQueriesInfo, input *dataObjectGeometryInput, field string, resolution int) (*H3Coverage, error) {
+	h3Query, err := s.indexer.DataObjectH3Query(ctx, info.Name)
+	if err != nil {
+		return nil, err
+	}
+	if h3Query == "" {
+		return nil, fmt.Errorf("data object %q does not support H3 aggregation queries", info.Name)
+	}
+	fq := newFieldStatsQuery(info, schemaDataObjectFieldValuesInput{Filter: input.Filter, Args: input.Args}, s.cfg.ttl)
+	// the h3 query is the root query, the data object query is the field of the cell data
+	fq.modules = nil
+	args := []string{"field: " + strconv.Quote(field), "divide_values: false"}
+	if input.TransformFrom != 0 {
+		args = append(args, "transform_from: "+strconv.Itoa(input.TransformFrom))
+	}
+	if fq.args != "" {
+		args = append(args, fq.args)
+	}
+	fq.add(fmt.Sprintf("h3(resolution: %d) @cache(ttl: $ttl) { cell data { rows: %s(%s) { _rows_count } } }",
+		resolution, h3Query, strings.Join(args, " ")))
+
+	res, err := s.hugr.Query(ctx, fq.query(), fq.vars)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	var cells []struct {
+		Cell any `json:"cell"`
+		Data struct {
+			Rows struct {
+				RowsCount int `json:"_rows_count"`
+			} `json:"rows"`
+		} `json:"data"`
+	}
+	if err := res.ScanData("h3", &cells); err != nil {
+		return nil, fmt.Errorf("failed to parse H3 result: %w", err)
+	}
+	counts := make([]H3CellCount, 0, len(cells))
+	for _, c := range cells {
+		if c.Data.Rows.RowsCount != 0 {
+			counts = append(counts, H3CellCount{Cell: c.Cell, Count: c.Data.Rows.RowsCount})
+		}
+	}
+	cov := h3CoverageSummary(counts)
+	cov.Resolution, cov.Query = resolution, h3Query
+	return cov, nil
+}
+
+// h3CoverageSummary summarizes the row counts of the cells.
+func h3CoverageSummary(counts []H3CellCount) *H3Coverage {
+	cov := &H3Coverage{Cells: len(counts)}
+	for _, c := range counts {
+		cov.RowsCount += c.Count
+		cov.MaxCellRows = max(cov.MaxCellRows, c.Count)
+	}
+	if cov.Cells != 0 {
+		cov.AvgCellRows = math.Round(float64(cov.RowsCount)/float64(cov.Cells)*100) / 100
+	}
+	top := slices.Clone(counts)
+	slices.SortStableFunc(top, func(a, b H3CellCount) int {
+		return b.Count - a.Count
+	})
+	if len(top) > h3TopCells {
+		top = top[:h3TopCells]
+	}
+	cov.TopCells = top
+	return cov
+}
+
+// h3MaxResolution returns the highest resolution up to the requested one that covers the geometries by at most
+// maxH3Cells cells. The cells are estimated by the bounding box area and, for the points, by the number of rows,
+// the resolution is not lowered if the coordinate units are unknown.
+func h3MaxResolution(info *DataObjectGeometryInfo, transformFrom, requested int) int {
+	srid := info.SRID
+	if transformFrom != 0 {
+		srid = transformFrom
+	}
+	area, known := bboxArea(info.BBox, srid)
+	points := len(info.Types) != 0 && !slices.ContainsFunc(info.Types, func(t GeometryTypeCount) bool {
+		return t.Type != "Point"
+	})
+	if !known && !points {
+		return requested
+	}
+	for res := requested; res > 0; res-- {
+		cells := math.Inf(1)
+		if known {
+			cells = area / h3AvgCellArea[res]
+		}
+		if points {
+			cells = min(cells, float64(info.Count))
+		}
+		if cells <= maxH3Cells {
+			return res
+		}
+	}
+	return 0
+}
+
+// bboxArea returns the approximate area of the bounding box in km², false if the coordinate units are unknown.
+func bboxArea(bbox []float64, srid int) (float64, bool) {
+	if len(bbox) != 4 {
+		return 0, false
+	}
+	dx, dy := bbox[2]-bbox[0], bbox[3]-bbox[1]
+	switch srid {
+	case 4326:
+		lat := (bbox[1] + bbox[3]) / 2 * math.Pi / 180
+		return dx * 111.32 * math.Cos(lat) * dy * 110.57, true
+	case 3857:
+		// the area is overestimated out of the equator
+		return dx * dy / 1e6, true
+	}
+	return 0, false
+}
+
+// geometryTypes counts the geometry types, ordered by the count.
+func geometryTypes(geoms []any) ([]GeometryTypeCount, error) {
+	counts := map[string]int{}
+	for _, v := range geoms {
+		g, err := parseGeometry(v)
+		if err != nil {
+			return nil, err
+		}
+		counts[g.GeoJSONType()]++
+	}
+	types := make([]GeometryTypeCount, 0, len(counts))
+	for _, t := range slices.Sorted(maps.Keys(counts)) {
+		types = append(types, GeometryTypeCount{Type: t, Count: counts[t]})
+	}
+	slices.SortStableFunc(types, func(a, b GeometryTypeCount) int {
+		return b.Count - a.Count
+	})
+	return types, nil
+}
+
+// parseGeometry parses the geometry value of the query result: the GeoJSON object or the WKT string.
+func parseGeometry(v any) (orb.Geometry, error) {
+	var b []byte
+	switch v := v.(type) {
+	case map[string]any:
+		var err error
+		b, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	case string:
+		if !strings.HasPrefix(strings.TrimSpace(v), "{") {
+			return wkt.Unmarshal(v)
+		}
+		b = []byte(v)
+	default:
+		return nil, fmt.Errorf("unsupported geometry value type %T", v)
+	}
+	g, err := geojson.UnmarshalGeometry(b)
+	if err != nil {
+		return nil, err
+	}
+	if g.Geometry() == nil {
+		return nil, errors.New("empty geometry")
+	}
+	return g.Geometry(), nil
+}
+
+// webMercatorBound is the maximum coordinate of the EPSG:3857 projection.
+const webMercatorBound = 20037508.35
+
+// sridHint guesses the SRID by the bounding box coordinates.
+func sridHint(bbox []float64) (int, string) {
+	if len(bbox) != 4 {
+		return 0, ""
+	}
+	within := func(bound float64) bool {
+		return math.Abs(bbox[0]) <= bound && math.Abs(bbox[2]) <= bound
+	}
+	switch {
+	case within(180) && math.Abs(bbox[1]) <= 90 && math.Abs(bbox[3]) <= 90:
+		return 4326, "the coordinates are in the longitude/latitude range, the geometry is probably in EPSG:4326 (WGS 84)"
+	case within(webMercatorBound) && math.Abs(bbox[1]) <= webMercatorBound && math.Abs(bbox[3]) <= webMercatorBound:
+		return 3857, "the coordinates are outside of the longitude/latitude range, the geometry is in a projected CRS, e.g. EPSG:3857 (Web Mercator) or a local projection; set transform_from for the H3 queries if the field SRID is not declared in the schema"
+	}
+	return 0, "the coordinates are outside of the longitude/latitude and Web Mercator ranges, the geometry is in a local projected CRS"
+}
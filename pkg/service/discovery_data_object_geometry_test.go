@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+func TestGeometryTypes(t *testing.T) {
+	types, err := geometryTypes([]any{
+		map[string]any{"type": "Point", "coordinates": []any{1.0, 2.0}},
+		"POINT (3 4)",
+		`{"type": "LineString", "coordinates": [[0, 0], [1, 1]]}`,
+		"POLYGON ((0 0, 1 0, 1 1, 0 0))",
+		map[string]any{"type": "Point", "coordinates": []any{5.0, 6.0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []GeometryTypeCount{{Type: "Point", Count: 3}, {Type: "LineString", Count: 1}, {Type: "Polygon", Count: 1}}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("unexpected geometry types %v", types)
+	}
+	if _, err := geometryTypes([]any{42}); err == nil {
+		t.Error("expected error of the unsupported geometry value")
+	}
+
+	g, err := parseGeometry(map[string]any{"type": "Polygon", "coordinates": []any{
+		[]any{[]any{10.0, 50.0}, []any{12.0, 50.0}, []any{12.0, 52.0}, []any{10.0, 50.0}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := g.Bound(); b.Min.X() != 10 || b.Min.Y() != 50 || b.Max.X() != 12 || b.Max.Y() != 52 {
+		t.Errorf("unexpected bound %v", b)
+	}
+}
+
+func TestSRIDHint(t *testing.T) {
+	tests := []struct {
+		bbox []float64
+		srid int
+	}{
+		{nil, 0},
+		{[]float64{-73.9, 40.5, -73.7, 40.9}, 4326},
+		{[]float64{-8238310, 4969803, -8218310, 4989803}, 3857},
+		{[]float64{3e7, 5e6, 3.1e7, 5.1e6}, 0},
+	}
+	for _, tt := range tests {
+		srid, note := sridHint(tt.bbox)
+		if srid != tt.srid || (tt.bbox != nil && note == "") {
+			t.Errorf("%v: unexpected SRID hint %d %q", tt.bbox, srid, note)
+		}
+	}
+}
+
+func TestH3CoverageSummary(t *testing.T) {
+	var counts []H3CellCount
+	for i := range 12 {
+		counts = append(counts, H3CellCount{Cell: i, Count: i + 1})
+	}
+	cov := h3CoverageSummary(counts)
+	if cov.Cells != 12 || cov.RowsCount != 78 || cov.MaxCellRows != 12 || cov.AvgCellRows != 6.5 {
+		t.Errorf("unexpected coverage %+v", cov)
+	}
+	if len(cov.TopCells) != h3TopCells || cov.TopCells[0].Cell != 11 || cov.TopCells[9].Cell != 2 {
+		t.Errorf("unexpected top cells %v", cov.TopCells)
+	}
+	if cov := h3CoverageSummary(nil); cov.Cells != 0 || cov.AvgCellRows != 0 {
+		t.Errorf("unexpected empty coverage %+v", cov)
+	}
+	// the counts are not reordered
+	if counts[0].Cell != 0 {
+		t.Errorf("the cell counts are changed: %v", counts)
+	}
+}
+
+func TestH3MaxResolution(t *testing.T) {
+	polygons := &DataObjectGeometryInfo{
+		Count: 1000,
+		BBox:  []float64{-10, 35, 30, 70},
+		SRID:  4326,
+		Types: []GeometryTypeCount{{Type: "Polygon", Count: 10}},
+	}
+	res := h3MaxResolution(polygons, 0, 9)
+	if res >= 9 || bboxCells(polygons.BBox, res) > maxH3Cells || bboxCells(polygons.BBox, res+1) <= maxH3Cells {
+		t.Errorf("unexpected resolution %d", res)
+	}
+	if res := h3MaxResolution(polygons, 0, 3); res != 3 {
+		t.Errorf("unexpected resolution %d for the low requested resolution", res)
+	}
+	// the points are covered by the cells not more than their number
+	points := &DataObjectGeometryInfo{Count: 100, BBox: polygons.BBox, SRID: 4326, Types: []GeometryTypeCount{{Type: "Point", Count: 100}}}
+	if res := h3MaxResolution(points, 0, 15); res != 15 {
+		t.Errorf("unexpected resolution %d for the points", res)
+	}
+	// the units of the local projection are unknown
+	local := &DataObjectGeometryInfo{Count: 1000, BBox: []float64{3e7, 5e6, 3.1e7, 5.1e6}, Types: polygons.Types}
+	if res := h3MaxResolution(local, 0, 12); res != 12 {
+		t.Errorf("unexpected resolution %d for the local projection", res)
+	}
+}
+
+func bboxCells(bbox []float64, res int) float64 {
+	area, _ := bboxArea(bbox, 4326)
+	return area / h3AvgCellArea[res]
+}
+
+func TestGeometryFields(t *testing.T) {
+	tracks, hidden := testScalar("tracks", "Geometry"), testScalar("hidden", "Geometry")
+	tracks.IsList, hidden.Exclude = true, true
+	b := testQueryBuilder(map[string][]indexer.Field{
+		"roads": {testScalar("id", "Int"), testScalar("geom", "Geometry"), testScalar("area", "Geometry"), tracks, hidden},
+	})
+	fields, err := b.geometryFields(context.Background(), "roads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fields, []string{"area", "geom"}) {
+		t.Errorf("unexpected geometry fields %v", fields)
+	}
+}
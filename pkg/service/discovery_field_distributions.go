@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+)
+
+// The field distributions show the data shape: the most frequent values and the counts per time bucket
+// are calculated by the bucket aggregation query, the equal-width histogram bins by the aggregation queries
+// with the bin range filters. The numeric fields have no bucket key arguments (only the Timestamp and Date
+// fields are truncated by the bucket), so the bins can't be the bucket aggregation keys.
+
+const (
+	maxTopN          = 100
+	maxHistogramBins = 50
+	maxTimeBuckets   = 1000
+)
+
+type FieldValueCount struct {
+	Value any `json:"value" jsonschema_description:"The field value or the time bucket start"`
+	Count int `json:"count" jsonschema_description:"The number of rows"`
+}
+
+type FieldHistogramBin struct {
+	From  float64 `json:"from" jsonschema_description:"The bin lower bound (inclusive)"`
+	To    float64 `json:"to" jsonschema_description:"The bin upper bound (exclusive, inclusive for the last bin)"`
+	Count int     `json:"count" jsonschema_description:"The number of rows"`
+}
+
+func (req *schemaDataObjectFieldValuesInput) distribution() bool {
+	return req.TopN > 0 || req.HistogramBins > 0 || req.TimeBucket != ""
+}
+
+// queryFieldDistributions adds the requested distributions of the field to the stats.
+func (s *Service) queryFieldDistributions(ctx context.Context, info *indexer.DataObjectQueriesInfo, req schemaDataObjectFieldValuesInput, stats *DataObjectFieldValueStat) error {
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	fp, err := b.resolveFieldPath(ctx, req.ObjectName, req.FieldName)
+	if err != nil {
+		return fmt.Errorf("get data object field type: %w", err)
+	}
+	if fp.jsonPath != "" {
+		return errors.New("the distributions are not supported for the JSON subfields")
+	}
+	for _, r := range fp.references {
+		if r.IsList {
+			return fmt.Errorf("the distributions are not supported through the list reference %q", r.Field)
+		}
+	}
+	stats.Field, stats.FieldType, stats.ReferencePath = fp.field, fp.fieldType, fp.references
+	if info.ArgsType == "" {
+		req.Args = nil
+	}
+
+	if req.TopN > 0 {
+		stats.TopValues, err = s.queryFieldBuckets(ctx, info, fp, req, "", min(req.TopN, maxTopN))
+		if err != nil {
+			return fmt.Errorf("top values: %w", err)
+		}
+	}
+	if req.TimeBucket != "" {
+		switch req.TimeBucket {
+		case "day", "week", "month":
+		default:
+			return fmt.Errorf("unknown time bucket %q, should be day, week or month", req.TimeBucket)
+		}
+		if fp.fieldType != "Timestamp" && fp.fieldType != "Date" {
+			return fmt.Errorf("the time buckets are calculated for the Timestamp and Date fields, the field type is %s", fp.fieldType)
+		}
+		stats.TimeBuckets, err = s.queryFieldBuckets(ctx, info, fp, req, req.TimeBucket, maxTimeBuckets)
+		if err != nil {
+			return fmt.Errorf("time buckets: %w", err)
+		}
+	}
+	if req.HistogramBins > 0 {
+		stats.Histogram, err = s.queryFieldHistogram(ctx, info, fp, req, stats)
+		if err != nil {
+			return fmt.Errorf("histogram: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryFieldBuckets returns the row counts by the field values (ordered by the count) or by the time buckets (ordered by the time).
+func (s *Service) queryFieldBuckets(ctx context.Context, info *indexer.DataObjectQueriesInfo, fp *fieldValuesPath, req schemaDataObjectFieldValuesInput, timeBucket string, limit int) ([]FieldValueCount, error) {
+	bucketQuery := ""
+	for _, q := range info.Queries {
+		if q.Type == string(metainfo.QueryTypeAggregateBucket) {
+			bucketQuery = q.Name
+			break
+		}
+	}
+	if bucketQuery == "" {
+		return nil, fmt.Errorf("data object %q does not support bucket aggregation queries", info.Name)
+	}
+
+	fq := newFieldStatsQuery(info, req, s.cfg.ttl)
+	orderBy := `{field: "aggregations._rows_count", direction: DESC}`
+	if timeBucket != "" {
+		orderBy = `{field: ` + strconv.Quote(fp.keyPath()) + `, direction: ASC}`
+	}
+	fq.add(fmt.Sprintf("buckets: %s(%s order_by: [%s] limit: %d) @cache(ttl: $ttl) { key { %s } aggregations { _rows_count } }",
+		bucketQuery, fq.args, orderBy, limit, fp.bucketKey(timeBucket)))
+
+	res, err := s.hugr.Query(ctx, fq.query(), fq.vars)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	var buckets []struct {
+		Key          map[string]any `json:"key"`
+		Aggregations struct {
+			RowsCount int `json:"_rows_count"`
+		} `json:"aggregations"`
+	}
+	if err := res.ScanData(fq.path("buckets"), &buckets); err != nil {
+		return nil, fmt.Errorf("failed to parse buckets result: %w", err)
+	}
+	out := make([]FieldValueCount, 0, len(buckets))
+	for _, b := range buckets {
+		out = append(out, FieldValueCount{Value: fp.keyValue(b.Key), Count: b.Aggregations.RowsCount})
+	}
+	return out, nil
+}
+
+// queryFieldHistogram counts the rows in the equal-width bins between the field min and max values.
+func (s *Service) queryFieldHistogram(ctx context.Context, info *indexer.DataObjectQueriesInfo, fp *fieldValuesPath, req schemaDataObjectFieldValuesInput, stats *DataObjectFieldValueStat) ([]FieldHistogramBin, error) {
+	switch fp.fieldType {
+	case "Int", "BigInt", "Float":
+	default:
+		return nil, fmt.Errorf("the histogram is calculated for the numeric fields, the field type is %s", fp.fieldType)
+	}
+	if info.FilterType == "" {
+		return nil, fmt.Errorf("data object %q does not support filters", info.Name)
+	}
+	aggQuery := ""
+	for _, q := range info.Queries {
+		if q.Type == string(metainfo.QueryTypeAggregate) {
+			aggQuery = q.Name
+			break
+		}
+	}
+	if aggQuery == "" {
+		return nil, fmt.Errorf("data object %q does not support aggregation queries", info.Name)
+	}
+
+	minV, minOK := toFloat(stats.Min)
+	maxV, maxOK := toFloat(stats.Max)
+	if !minOK || !maxOK {
+		// the range is not calculated by the stats
+		fq := newFieldStatsQuery(info, req, s.cfg.ttl)
+		fq.add(fmt.Sprintf("stats: %s %s @cache(ttl: $ttl) {field: %s}", aggQuery, fq.parenArgs(), fp.selection("min, max")))
+		var r struct {
+			Min any `json:"min"`
+			Max any `json:"max"`
+		}
+		if err := s.scanFieldStatsQuery(ctx, fq, "stats", fp, &r); err != nil {
+			return nil, err
+		}
+		minV, minOK = toFloat(r.Min)
+		maxV, maxOK = toFloat(r.Max)
+		if !minOK || !maxOK {
+			// no rows
+			return nil, nil
+		}
+	}
+
+	bins := histogramBins(minV, maxV, min(req.HistogramBins, maxHistogramBins))
+	// the bins are filtered by the request filter and the bin range
+	binReq := req
+	binReq.Filter = nil
+	fq := newFieldStatsQuery(info, binReq, s.cfg.ttl)
+	var binArgs []string
+	if fq.args != "" {
+		binArgs = append(binArgs, fq.args)
+	}
+	for i := range bins {
+		name := "bin" + strconv.Itoa(i)
+		filter := fp.filter(histogramBinCondition(bins, i))
+		if len(req.Filter) != 0 {
+			filter = map[string]any{"_and": []any{req.Filter, filter}}
+		}
+		fq.varDefs += " $" + name + ": " + info.FilterType + "!"
+		fq.vars[name] = filter
+		fq.add(fmt.Sprintf("%s: %s(%s) @cache(ttl: $ttl) { _rows_count }", name, aggQuery, strings.Join(append(binArgs, "filter: $"+name), " ")))
+	}
+
+	res, err := s.hugr.Query(ctx, fq.query(), fq.vars)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	for i := range bins {
+		var r struct {
+			RowsCount int `json:"_rows_count"`
+		}
+		if err := res.ScanData(fq.path("bin"+strconv.Itoa(i)), &r); err != nil {
+			return nil, fmt.Errorf("failed to parse histogram result: %w", err)
+		}
+		bins[i].Count = r.RowsCount
+	}
+	return bins, nil
+}
+
+func (s *Service) scanFieldStatsQuery(ctx context.Context, fq *fieldStatsQuery, alias string, fp *fieldValuesPath, v any) error {
+	res, err := s.hugr.Query(ctx, fq.query(), fq.vars)
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	if res.Err() != nil {
+		return res.Err()
+	}
+	var data struct {
+		Field map[string]any `json:"field"`
+	}
+	if err := res.ScanData(fq.path(alias), &data); err != nil {
+		return fmt.Errorf("failed to parse %s result: %w", alias, err)
+	}
+	return fp.scanLeaf(data.Field, v)
+}
+
+// histogramBins splits the range to the equal-width bins, the empty range is a single bin.
+func histogramBins(minV, maxV float64, n int) []FieldHistogramBin {
+	if n <= 0 || maxV <= minV {
+		return []FieldHistogramBin{{From: minV, To: maxV}}
+	}
+	width := (maxV - minV) / float64(n)
+	bins := make([]FieldHistogramBin, n)
+	for i := range bins {
+		bins[i].From = minV + float64(i)*width
+		bins[i].To = minV + float64(i+1)*width
+	}
+	bins[n-1].To = maxV
+	return bins
+}
+
+// histogramBinCondition returns the field filter condition of the bin, the last bin includes the upper bound.
+func histogramBinCondition(bins []FieldHistogramBin, i int) map[string]any {
+	if i == len(bins)-1 {
+		return map[string]any{"gte": bins[i].From, "lte": bins[i].To}
+	}
+	return map[string]any{"gte": bins[i].From, "lt": bins[i].To}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, !math.IsNaN(v)
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// bucketKey returns the bucket aggregation key selection of the field through the references.
+func (fp *fieldValuesPath) bucketKey(timeBucket string) string {
+	key := fp.field
+	if timeBucket != "" {
+		key += "(bucket: " + timeBucket + ")"
+	}
+	for i := len(fp.references) - 1; i >= 0; i-- {
+		key = fp.references[i].Field + " { " + key + " }"
+	}
+	return key
+}
+
+// keyPath returns the bucket aggregation order_by path of the field key.
+func (fp *fieldValuesPath) keyPath() string {
+	parts := []string{"key"}
+	for _, r := range fp.references {
+		parts = append(parts, r.Field)
+	}
+	return strings.Join(append(parts, fp.field), ".")
+}
+
+// keyValue returns the field value from the bucket key.
+func (fp *fieldValuesPath) keyValue(key map[string]any) any {
+	for _, r := range fp.references {
+		key, _ = key[r.Field].(map[string]any)
+	}
+	return key[fp.field]
+}
+
+// filter returns the data object filter of the field condition through the references.
+func (fp *fieldValuesPath) filter(cond map[string]any) map[string]any {
+	f := map[string]any{fp.field: cond}
+	for i := len(fp.references) - 1; i >= 0; i-- {
+		f = map[string]any{fp.references[i].Field: f}
+	}
+	return f
+}
+
+// fieldStatsQuery is the aggregation query of the data object fields nested into the module.
+type fieldStatsQuery struct {
+	modules []string
+	args    string // the data object query arguments
+	varDefs string
+	vars    map[string]any
+	fields  []string
+}
+
+func newFieldStatsQuery(info *indexer.DataObjectQueriesInfo, req schemaDataObjectFieldValuesInput, ttl int) *fieldStatsQuery {
+	fq := &fieldStatsQuery{
+		varDefs: "$ttl: Int!",
+		vars:    map[string]any{"ttl": ttl},
+	}
+	if info.Module != "" {
+		fq.modules = strings.Split(info.Module, ".")
+	}
+	var args []string
+	if info.ArgsType != "" && len(req.Args) != 0 {
+		args = append(args, "args: $args")
+		fq.varDefs += " $args: " + info.ArgsType + "!"
+		fq.vars["args"] = req.Args
+	}
+	if info.FilterType != "" && len(req.Filter) != 0 {
+		args = append(args, "filter: $filter")
+		fq.varDefs += " $filter: " + info.FilterType + "!"
+		fq.vars["filter"] = req.Filter
+	}
+	fq.args = strings.Join(args, " ")
+	return fq
+}
+
+func (fq *fieldStatsQuery) parenArgs() string {
+	if fq.args == "" {
+		return ""
+	}
+	return "(" + fq.args + ")"
+}
+
+func (fq *fieldStatsQuery) add(field string) {
+	fq.fields = append(fq.fields, field)
+}
+
+func (fq *fieldStatsQuery) query() string {
+	q := strings.Join(fq.fields, "\n")
+	for i := len(fq.modules) - 1; i >= 0; i-- {
+		q = fq.modules[i] + " { " + q + " }"
+	}
+	return "query fieldDistribution(" + fq.varDefs + ") {\n" + q + "\n}"
+}
+
+// path returns the result path of the query field.
+func (fq *fieldStatsQuery) path(alias string) string {
+	return strings.Join(append(slices.Clone(fq.modules), alias), ".")
+}
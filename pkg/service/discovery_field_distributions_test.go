@@ -0,0 +1,75 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+func TestHistogramBins(t *testing.T) {
+	bins := histogramBins(0, 10, 4)
+	expected := []FieldHistogramBin{{From: 0, To: 2.5}, {From: 2.5, To: 5}, {From: 5, To: 7.5}, {From: 7.5, To: 10}}
+	if !reflect.DeepEqual(bins, expected) {
+		t.Errorf("unexpected bins %v", bins)
+	}
+	if bins := histogramBins(3, 3, 10); len(bins) != 1 || bins[0].From != 3 || bins[0].To != 3 {
+		t.Errorf("unexpected bins of the empty range %v", bins)
+	}
+
+	// the bins share the edges, the last bin ends at the max value
+	bins = histogramBins(0.1, 0.7, 3)
+	for i := 1; i < len(bins); i++ {
+		if bins[i].From != bins[i-1].To {
+			t.Errorf("the bins %v and %v have the gap", bins[i-1], bins[i])
+		}
+	}
+	if bins[0].From != 0.1 || bins[2].To != 0.7 {
+		t.Errorf("unexpected bins range %v", bins)
+	}
+	if c := histogramBinCondition(bins, 0); !reflect.DeepEqual(c, map[string]any{"gte": 0.1, "lt": bins[0].To}) {
+		t.Errorf("unexpected first bin condition %v", c)
+	}
+	if c := histogramBinCondition(bins, 2); !reflect.DeepEqual(c, map[string]any{"gte": bins[2].From, "lte": 0.7}) {
+		t.Errorf("unexpected last bin condition %v", c)
+	}
+	// the single bin of the empty range counts the rows with the min value
+	if c := histogramBinCondition(histogramBins(3, 3, 10), 0); !reflect.DeepEqual(c, map[string]any{"gte": 3.0, "lte": 3.0}) {
+		t.Errorf("unexpected condition of the empty range %v", c)
+	}
+}
+
+func TestFieldDistributionQuery(t *testing.T) {
+	fp := &fieldValuesPath{
+		references: []DataObjectFieldReference{{Field: "patient", Type: "patients"}, {Field: "organization", Type: "orgs"}},
+		field:      "founded",
+		fieldType:  "Date",
+	}
+	if p := fp.keyPath(); p != "key.patient.organization.founded" {
+		t.Errorf("unexpected key path %q", p)
+	}
+	key := map[string]any{"patient": map[string]any{"organization": map[string]any{"founded": "2020-01-01"}}}
+	if v := fp.keyValue(key); v != "2020-01-01" {
+		t.Errorf("unexpected key value %v", v)
+	}
+	filter := fp.filter(map[string]any{"gte": 1})
+	expected := map[string]any{"patient": map[string]any{"organization": map[string]any{"founded": map[string]any{"gte": 1}}}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("unexpected filter %v", filter)
+	}
+
+	info := &indexer.DataObjectQueriesInfo{Name: "visits", Module: "med.core", FilterType: "visits_filter"}
+	fq := newFieldStatsQuery(info, schemaDataObjectFieldValuesInput{
+		Filter: map[string]any{"id": map[string]any{"gt": 1}},
+		Args:   map[string]any{"x": 1},
+	}, 60)
+	fq.add("stats: visits_aggregation" + fq.parenArgs() + " { _rows_count }")
+	q := "query fieldDistribution($ttl: Int! $filter: visits_filter!) {\n" +
+		"med { core { stats: visits_aggregation(filter: $filter) { _rows_count } } }\n}"
+	if fq.query() != q {
+		t.Errorf("unexpected query:\n%s", fq.query())
+	}
+	if fq.path("stats") != "med.core.stats" || fq.vars["ttl"] != 60 || fq.vars["args"] != nil {
+		t.Errorf("unexpected path %q or variables %v", fq.path("stats"), fq.vars)
+	}
+}
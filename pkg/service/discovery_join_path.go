@@ -0,0 +1,346 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The join path is the shortest chain of the reference and subquery fields from the source data object to the target,
+// it is found by the breadth-first search over the indexed relation fields of all modules. If the objects are not
+// related, they are joined by the _join query on the inferred fields.
+
+const (
+	defaultJoinPathHops = 4
+	maxJoinPathHops     = 6
+	joinPathLimit       = 10
+	joinPathValue       = "<value>"
+
+	joinHopReference = "reference"
+	joinHopJoin      = "join"
+)
+
+var discoveryJoinPathTool = mcp.NewTool("discovery-join_path",
+	mcp.WithDescription("Find the shortest path between two data objects: the chain of the reference and subquery fields (also across the modules and data sources), or the _join query if they are not related. Returns the hops, the nested selection query of the target fields through the path and the query that filters the source by the target field through the path"),
+	mcp.WithInputSchema[joinPathInput](),
+	mcp.WithOutputSchema[joinPathOutput](),
+)
+
+type joinPathInput struct {
+	Source  string `json:"source" jsonschema_description:"The source data object (GraphQL type)"`
+	Target  string `json:"target" jsonschema_description:"The target data object (GraphQL type)"`
+	MaxHops int    `json:"max_hops,omitempty" jsonschema_description:"The maximum number of the reference hops" jsonschema:"minimum=1,maximum=6,default=4"`
+}
+
+type joinPathOutput struct {
+	Source          string         `json:"source" jsonschema_description:"The source data object"`
+	Target          string         `json:"target" jsonschema_description:"The target data object"`
+	Hops            []JoinPathHop  `json:"hops" jsonschema_description:"The hops from the source to the target"`
+	CrossModule     bool           `json:"cross_module,omitempty" jsonschema_description:"Whether the path goes through the data objects of the different modules"`
+	CrossDataSource bool           `json:"cross_data_source,omitempty" jsonschema_description:"Whether the path goes through the data objects of the different data sources"`
+	Selection       string         `json:"selection" jsonschema_description:"The query of the source rows with the target fields selected through the path"`
+	Filter          string         `json:"filter,omitempty" jsonschema_description:"The query of the source rows filtered by the target field through the path"`
+	FilterVariables map[string]any `json:"filter_variables,omitempty" jsonschema_description:"The filter query variables, replace the <value> placeholder"`
+	Note            string         `json:"note,omitempty" jsonschema_description:"The path notes, e.g. why the filter query is not available"`
+}
+
+type JoinPathHop struct {
+	Kind           string   `json:"kind" jsonschema_description:"The hop kind: reference (the reference or subquery field) or join (the _join query)"`
+	From           string   `json:"from" jsonschema_description:"The data object of the hop start"`
+	To             string   `json:"to" jsonschema_description:"The data object of the hop end"`
+	Field          string   `json:"field" jsonschema_description:"The Hugr field to select the related rows: the reference field or the _join query field"`
+	IsList         bool     `json:"is_list,omitempty" jsonschema_description:"Whether the hop returns many rows, the filter uses any_of for it"`
+	FromModule     string   `json:"from_module,omitempty"`
+	ToModule       string   `json:"to_module,omitempty"`
+	FromDataSource string   `json:"from_data_source,omitempty"`
+	ToDataSource   string   `json:"to_data_source,omitempty"`
+	SourceFields   []string `json:"source_fields,omitempty" jsonschema_description:"The _join fields of the hop start, inferred by the field names"`
+	TargetFields   []string `json:"target_fields,omitempty" jsonschema_description:"The _join fields of the hop end, inferred by the field names"`
+}
+
+func (s *Service) discoveryJoinPathHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &joinPathInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.Source == "" || input.Target == "" {
+		return mcp.NewToolResultError("source and target are required"), nil
+	}
+	if input.Source == input.Target {
+		return mcp.NewToolResultError("source and target should be the different data objects"), nil
+	}
+	if input.MaxHops <= 0 {
+		input.MaxHops = defaultJoinPathHops
+	}
+	input.MaxHops = min(input.MaxHops, maxJoinPathHops)
+
+	info, err := s.indexer.DataObjectQueriesInfo(ctx, input.Source)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get source data object info", err), nil
+	}
+	if info == nil {
+		return mcp.NewToolResultError("source data object not found"), nil
+	}
+	relations, err := s.indexer.DataObjectRelations(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get data object relations", err), nil
+	}
+	b := &queryBuilder{fields: s.indexer.TypeFields}
+	out := &joinPathOutput{Source: input.Source, Target: input.Target}
+
+	out.Hops = findJoinPath(relations, input.Source, input.Target, input.MaxHops)
+	if out.Hops == nil {
+		hop, err := s.joinHop(ctx, b, input.Source, input.Target)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to find join path", err), nil
+		}
+		if hop == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("no path from %s to %s within %d hops and the objects can't be joined by the _join query", input.Source, input.Target, input.MaxHops)), nil
+		}
+		out.Hops = []JoinPathHop{*hop}
+	}
+	for _, hop := range out.Hops {
+		out.CrossModule = out.CrossModule || hop.FromModule != hop.ToModule
+		out.CrossDataSource = out.CrossDataSource || hop.FromDataSource != hop.ToDataSource
+	}
+
+	if err := b.joinPathQueries(ctx, info, out); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to build join path queries", err), nil
+	}
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+// findJoinPath returns the shortest path of the relations from the source to the target, nil if there is no path.
+func findJoinPath(relations []indexer.DataObjectRelation, source, target string, maxHops int) []JoinPathHop {
+	next := map[string][]indexer.DataObjectRelation{}
+	for _, r := range relations {
+		next[r.Object] = append(next[r.Object], r)
+	}
+	// the hop that reaches the object first
+	prev := map[string]indexer.DataObjectRelation{}
+	visited := map[string]bool{source: true}
+	level := []string{source}
+	for range maxHops {
+		var nextLevel []string
+		for _, object := range level {
+			for _, r := range next[object] {
+				if visited[r.Target] {
+					continue
+				}
+				visited[r.Target] = true
+				prev[r.Target] = r
+				nextLevel = append(nextLevel, r.Target)
+			}
+		}
+		if visited[target] {
+			break
+		}
+		level = nextLevel
+	}
+	if !visited[target] {
+		return nil
+	}
+	var hops []JoinPathHop
+	for object := target; object != source; object = prev[object].Object {
+		r := prev[object]
+		hops = append(hops, JoinPathHop{
+			Kind:           joinHopReference,
+			From:           r.Object,
+			To:             r.Target,
+			Field:          r.Field,
+			IsList:         r.IsList,
+			FromModule:     r.ObjectModule,
+			ToModule:       r.TargetModule,
+			FromDataSource: r.ObjectCatalog,
+			ToDataSource:   r.TargetCatalog,
+		})
+	}
+	slices.Reverse(hops)
+	return hops
+}
+
+// joinHop returns the _join hop from the source to the target, nil if the source has no _join field
+// or the target has no _join query.
+func (s *Service) joinHop(ctx context.Context, b *queryBuilder, source, target string) (*JoinPathHop, error) {
+	sf, err := b.typeFields(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sf["_join"]; !ok {
+		return nil, nil
+	}
+	joinQuery, err := s.indexer.DataObjectJoinQuery(ctx, target)
+	if err != nil || joinQuery == "" {
+		return nil, err
+	}
+	tf, err := b.typeFields(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	hop := &JoinPathHop{Kind: joinHopJoin, From: source, To: target, Field: joinQuery, IsList: true}
+	hop.SourceFields, hop.TargetFields = inferJoinFields(sf, target, tf)
+	return hop, nil
+}
+
+// inferJoinFields returns the source fields named by the target name and its primary key fields,
+// e.g. customer_id for the target shop_customers with the primary key id.
+func inferJoinFields(sf map[string]indexer.Field, target string, tf map[string]indexer.Field) ([]string, []string) {
+	pk := primaryKeyFields(tf)
+	name := target
+	if i := strings.LastIndex(name, "_"); i != -1 {
+		name = name[i+1:]
+	}
+	for _, prefix := range []string{name, strings.TrimSuffix(name, "s"), target} {
+		var source []string
+		for _, f := range pk {
+			if sfield, ok := sf[prefix+"_"+f]; ok && sfield.Type == tf[f].Type {
+				source = append(source, prefix+"_"+f)
+			}
+		}
+		if len(pk) != 0 && len(source) == len(pk) {
+			return source, pk
+		}
+	}
+	return nil, nil
+}
+
+func primaryKeyFields(ff map[string]indexer.Field) []string {
+	var pk []string
+	for _, name := range slices.Sorted(maps.Keys(ff)) {
+		if ff[name].IsPrimaryKey {
+			pk = append(pk, name)
+		}
+	}
+	return pk
+}
+
+// keyFields returns the fields to select from the data object: the primary key or the first scalar fields.
+func (b *queryBuilder) keyFields(ctx context.Context, objectName string) ([]string, error) {
+	ff, err := b.typeFields(ctx, objectName)
+	if err != nil {
+		return nil, err
+	}
+	if pk := primaryKeyFields(ff); len(pk) != 0 {
+		return pk, nil
+	}
+	var fields []string
+	for _, name := range slices.Sorted(maps.Keys(ff)) {
+		if f := ff[name]; f.FieldType != nil && f.FieldType.Kind == "SCALAR" && !f.IsList && !strings.HasPrefix(name, "_") {
+			fields = append(fields, name)
+		}
+		if len(fields) == 3 {
+			break
+		}
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("data object %q has no scalar fields", objectName)
+	}
+	return fields, nil
+}
+
+// joinPathQueries builds the selection and the filter queries of the path.
+func (b *queryBuilder) joinPathQueries(ctx context.Context, info *indexer.DataObjectQueriesInfo, out *joinPathOutput) error {
+	sourceFields, err := b.keyFields(ctx, out.Source)
+	if err != nil {
+		return err
+	}
+	targetFields, err := b.keyFields(ctx, out.Target)
+	if err != nil {
+		return err
+	}
+
+	if out.Hops[0].Kind == joinHopJoin {
+		selectQuery := ""
+		for _, q := range info.Queries {
+			if q.Type == string(metainfo.QueryTypeSelect) {
+				selectQuery = q.Name
+				break
+			}
+		}
+		if selectQuery == "" {
+			return fmt.Errorf("data object %q does not support select queries", info.Name)
+		}
+		hop := out.Hops[0]
+		root := &selectionNode{}
+		for _, f := range sourceFields {
+			root.child(f)
+		}
+		join := root.child("_join(fields: " + quotedList(hop.SourceFields, "<source_field>") + ")").
+			child(hop.Field + "(fields: " + quotedList(hop.TargetFields, "<target_field>") + ")")
+		for _, f := range targetFields {
+			join.child(f)
+		}
+		var modules []string
+		if info.Module != "" {
+			modules = strings.Split(info.Module, ".")
+		}
+		out.Selection = selectionQuery(modules, selectQuery, nil, []string{"limit: " + strconv.Itoa(joinPathLimit)}, root.children)
+		out.Note = "the objects are not related by the references, they are joined by the _join query"
+		if len(hop.SourceFields) == 0 {
+			out.Note += ", set the join fields of the both objects"
+		} else {
+			out.Note += ", check the inferred join fields"
+		}
+		out.Note += "; the rows can't be filtered through the _join query"
+		return nil
+	}
+
+	var path []string
+	for _, hop := range out.Hops {
+		path = append(path, hop.Field)
+	}
+	prefix := strings.Join(path, ".") + "."
+	fields := slices.Clone(sourceFields)
+	for _, f := range targetFields {
+		fields = append(fields, prefix+f)
+	}
+	q, err := b.build(ctx, info, &dataBuildQueryInput{ObjectName: info.Name, Fields: fields, Limit: joinPathLimit})
+	if err != nil {
+		return err
+	}
+	out.Selection = q.Query
+
+	q, err = b.build(ctx, info, &dataBuildQueryInput{
+		ObjectName: info.Name,
+		Fields:     sourceFields,
+		Filter:     joinPathFilter(out.Hops, targetFields[0]),
+		Limit:      joinPathLimit,
+	})
+	if err != nil {
+		// the subqueries can't be used in the filters
+		out.Note = "the rows can't be filtered through the path: " + err.Error()
+		return nil
+	}
+	out.Filter, out.FilterVariables = q.Query, q.Variables
+	return nil
+}
+
+// joinPathFilter returns the filter of the target field through the path hops, the list hops are filtered by any_of.
+func joinPathFilter(hops []JoinPathHop, field string) map[string]any {
+	filter := map[string]any{field: map[string]any{"eq": joinPathValue}}
+	for i := len(hops) - 1; i >= 0; i-- {
+		if hops[i].IsList {
+			filter = map[string]any{"any_of": filter}
+		}
+		filter = map[string]any{hops[i].Field: filter}
+	}
+	return filter
+}
+
+func quotedList(items []string, placeholder string) string {
+	if len(items) == 0 {
+		items = []string{placeholder}
+	}
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+func TestFindJoinPath(t *testing.T) {
+	relations := []indexer.DataObjectRelation{
+		{Object: "orders", ObjectModule: "shop", Field: "customer", Target: "customers", TargetModule: "shop"},
+		{Object: "orders", ObjectModule: "shop", Field: "items", Target: "order_items", TargetModule: "shop", IsList: true},
+		{Object: "order_items", ObjectModule: "shop", Field: "product", Target: "products", TargetModule: "catalog", TargetCatalog: "pg"},
+		{Object: "customers", ObjectModule: "shop", Field: "orders", Target: "orders", TargetModule: "shop", IsList: true},
+		{Object: "products", ObjectModule: "catalog", Field: "supplier", Target: "suppliers", TargetModule: "catalog"},
+	}
+
+	hops := findJoinPath(relations, "customers", "products", 4)
+	var fields []string
+	for _, h := range hops {
+		fields = append(fields, h.Field)
+	}
+	if !reflect.DeepEqual(fields, []string{"orders", "items", "product"}) {
+		t.Fatalf("unexpected path %+v", hops)
+	}
+	if hops[2].FromModule != "shop" || hops[2].ToModule != "catalog" || hops[2].ToDataSource != "pg" || !hops[1].IsList {
+		t.Errorf("unexpected hops %+v", hops)
+	}
+	if hops := findJoinPath(relations, "customers", "suppliers", 3); hops != nil {
+		t.Errorf("unexpected path over the max hops %+v", hops)
+	}
+	if hops := findJoinPath(relations, "suppliers", "orders", 4); hops != nil {
+		t.Errorf("unexpected path %+v", hops)
+	}
+
+	expected := map[string]any{"orders": map[string]any{"any_of": map[string]any{"items": map[string]any{"any_of": map[string]any{
+		"product": map[string]any{"id": map[string]any{"eq": joinPathValue}},
+	}}}}}
+	if filter := joinPathFilter(hops, "id"); !reflect.DeepEqual(filter, expected) {
+		t.Errorf("unexpected filter %v", filter)
+	}
+}
+
+func TestJoinPathQueries(t *testing.T) {
+	types := map[string][]indexer.Field{
+		"shop_orders":           {testKey("id", "Int"), testScalar("customer_id", "Int"), testObject("customer", "shop_customers", false), testObject("_join", "_join", false)},
+		"shop_customers":        {testKey("id", "Int"), testScalar("name", "String")},
+		"shop_stores":           {testScalar("code", "String"), testScalar("city", "String")},
+		"shop_orders_filter":    {testInput("id", "IntFilter"), testInput("customer", "shop_customers_filter")},
+		"shop_customers_filter": {testInput("id", "IntFilter")},
+		"IntFilter":             {testInput("eq", "Int")},
+	}
+	b := testQueryBuilder(types)
+	info := &indexer.DataObjectQueriesInfo{
+		Name:       "shop_orders",
+		FilterType: "shop_orders_filter",
+		Module:     "shop",
+		Queries:    []indexer.DataObjectQueryInfo{{Name: "orders", Type: "select"}},
+	}
+	ctx := context.Background()
+
+	out := &joinPathOutput{Source: "shop_orders", Target: "shop_customers", Hops: []JoinPathHop{
+		{Kind: joinHopReference, From: "shop_orders", To: "shop_customers", Field: "customer"},
+	}}
+	if err := b.joinPathQueries(ctx, info, out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Selection, "orders(limit: 10) {\n      id\n      customer {\n        id\n      }") {
+		t.Errorf("unexpected selection:\n%s", out.Selection)
+	}
+	if !strings.Contains(out.Filter, "orders(filter: $filter, limit: 10)") ||
+		!reflect.DeepEqual(out.FilterVariables["filter"], map[string]any{"customer": map[string]any{"id": map[string]any{"eq": joinPathValue}}}) {
+		t.Errorf("unexpected filter %s %v", out.Filter, out.FilterVariables)
+	}
+
+	// the join fields are inferred by the target name and primary key
+	ff, _ := b.typeFields(ctx, "shop_orders")
+	tf, _ := b.typeFields(ctx, "shop_customers")
+	if s, t2 := inferJoinFields(ff, "shop_customers", tf); !reflect.DeepEqual(s, []string{"customer_id"}) || !reflect.DeepEqual(t2, []string{"id"}) {
+		t.Errorf("unexpected join fields %v %v", s, t2)
+	}
+
+	// the _join hop without the inferred fields, the target has no primary key
+	out = &joinPathOutput{Source: "shop_orders", Target: "shop_stores", Hops: []JoinPathHop{
+		{Kind: joinHopJoin, From: "shop_orders", To: "shop_stores", Field: "shop_stores", IsList: true},
+	}}
+	if err := b.joinPathQueries(ctx, info, out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.Selection, `_join(fields: ["<source_field>"]) {`+"\n"+`        shop_stores(fields: ["<target_field>"]) {`+"\n          city\n          code\n") ||
+		out.Filter != "" || out.Note == "" {
+		t.Errorf("unexpected join selection:\n%s\n%s", out.Selection, out.Note)
+	}
+}
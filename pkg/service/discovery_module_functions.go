@@ -19,6 +19,10 @@ func (s *Service) discoveryModuleFunctionsHandler(ctx context.Context, request m
 	if err := request.BindArguments(input); err != nil {
 		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
 	}
+	if _, ok := request.GetArguments()["module"]; !ok {
+		// the module selected in the session
+		input.Module = s.sessionModule(ctx)
+	}
 
 	functions, err := s.indexer.SearchModuleFunctions(ctx, input)
 	if err != nil {
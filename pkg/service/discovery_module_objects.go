@@ -19,6 +19,10 @@ func (s *Service) discoveryModuleObjectsHandler(ctx context.Context, request mcp
 	if err := request.BindArguments(input); err != nil {
 		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
 	}
+	if _, ok := request.GetArguments()["module"]; !ok {
+		// the module selected in the session
+		input.Module = s.sessionModule(ctx)
+	}
 
 	dataObjects, err := s.indexer.SearchModuleDataObjects(ctx, input)
 	if err != nil {
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hugr-lab/mcp/pkg/summary"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultRelationshipDepth = 2
+	maxRelationshipDepth     = 4
+)
+
+var discoveryRelationshipGraphTool = mcp.NewTool("discovery-relationship_graph",
+	mcp.WithDescription("Return the relationship graph of a data object or of all data objects of a module: the related data objects and functions (nodes) and the relations between them (edges) up to the depth. Each edge has the kind (reference, subquery or function call), the cardinality, the join fields and the Hugr fields to traverse it in the nested selections, filters and aggregations. Use it to plan the multi-object queries"),
+	mcp.WithInputSchema[relationshipGraphInput](),
+	mcp.WithOutputSchema[relationshipGraphOutput](),
+)
+
+type relationshipGraphInput struct {
+	ObjectName string `json:"object_name,omitempty" jsonschema_description:"The data object (GraphQL type) to start the graph from"`
+	Module     string `json:"module,omitempty" jsonschema_description:"The module to build the graph of all its data objects, used if object_name is omitted"`
+	Depth      int    `json:"depth,omitempty" jsonschema_description:"The number of the relation hops from the start data objects" jsonschema:"minimum=1,maximum=4,default=2"`
+}
+
+type relationshipGraphOutput struct {
+	ObjectName string                `json:"object_name,omitempty" jsonschema_description:"The start data object"`
+	Module     string                `json:"module,omitempty" jsonschema_description:"The module of the start data objects"`
+	Depth      int                   `json:"depth" jsonschema_description:"The graph depth"`
+	Nodes      []summary.RelatedNode `json:"nodes" jsonschema_description:"The data objects (table, view) and the functions (function, the name is prefixed by fc:) of the graph"`
+	Edges      []summary.RelatedEdge `json:"edges" jsonschema_description:"The relations: kind, cardinality (the target rows per source row), the Hugr field to select, aggregate or filter through the relation, and the join fields inferred by the field names (omitted if unknown)"`
+}
+
+func (s *Service) discoveryRelationshipGraphHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &relationshipGraphInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.ObjectName == "" && input.Module == "" {
+		return mcp.NewToolResultError("object_name or module is required"), nil
+	}
+	if input.Depth <= 0 {
+		input.Depth = defaultRelationshipDepth
+	}
+	input.Depth = min(input.Depth, maxRelationshipDepth)
+
+	schema, err := s.indexer.SchemaSummary(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get schema summary", err), nil
+	}
+	objects, err := graphStartObjects(schema, input)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	g, err := summary.NewRelatedGraph(schema, input.Depth, objects...)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to build relationship graph", err), nil
+	}
+	return mcp.NewToolResultStructuredOnly(&relationshipGraphOutput{
+		ObjectName: input.ObjectName,
+		Module:     input.Module,
+		Depth:      g.MaxDepth,
+		Nodes:      g.Nodes,
+		Edges:      g.Edges,
+	}), nil
+}
+
+// graphStartObjects returns the data object or the module data objects to start the graph from.
+func graphStartObjects(schema *metainfo.SchemaInfo, input *relationshipGraphInput) ([]*metainfo.DataObjectInfo, error) {
+	if input.ObjectName != "" {
+		for _, object := range schema.DataObjects() {
+			if object.Name == input.ObjectName {
+				input.Module = object.Module
+				return []*metainfo.DataObjectInfo{object}, nil
+			}
+		}
+		return nil, fmt.Errorf("data object %q not found", input.ObjectName)
+	}
+	m := schema.Module(input.Module)
+	if m == nil {
+		return nil, fmt.Errorf("module %q not found", input.Module)
+	}
+	var objects []*metainfo.DataObjectInfo
+	for i := range m.Tables {
+		objects = append(objects, &m.Tables[i])
+	}
+	for i := range m.Views {
+		objects = append(objects, &m.Views[i])
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("module %q has no data objects", input.Module)
+	}
+	return objects, nil
+}
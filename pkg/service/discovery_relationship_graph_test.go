@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+)
+
+func TestGraphStartObjects(t *testing.T) {
+	schema := &metainfo.SchemaInfo{RootModule: metainfo.ModuleInfo{SubModules: []metainfo.ModuleInfo{{
+		Name:   "shop",
+		Tables: []metainfo.DataObjectInfo{{Name: "orders", Module: "shop"}},
+		Views:  []metainfo.DataObjectInfo{{Name: "sales", Module: "shop"}},
+	}}}}
+
+	input := &relationshipGraphInput{ObjectName: "sales"}
+	objects, err := graphStartObjects(schema, input)
+	if err != nil || len(objects) != 1 || objects[0].Name != "sales" || input.Module != "shop" {
+		t.Fatalf("unexpected objects %v: %v", objects, err)
+	}
+	objects, err = graphStartObjects(schema, &relationshipGraphInput{Module: "shop"})
+	if err != nil || len(objects) != 2 {
+		t.Fatalf("unexpected module objects %v: %v", objects, err)
+	}
+	if _, err := graphStartObjects(schema, &relationshipGraphInput{ObjectName: "missing"}); err == nil {
+		t.Error("expected data object not found error")
+	}
+	if _, err := graphStartObjects(schema, &relationshipGraphInput{Module: "hr"}); err == nil {
+		t.Error("expected module not found error")
+	}
+}
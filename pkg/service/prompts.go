@@ -0,0 +1,161 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"text/template"
+
+	"github.com/hugr-lab/query-engine/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The prompts guide the common workflows, they are pre-filled with the indexed summaries
+// (the same Markdown as the schema resources) and the hugr query conventions.
+
+//go:embed prompts/*.md
+var promptsFS embed.FS
+
+var promptTemplates = template.Must(template.ParseFS(promptsFS, "prompts/*.md"))
+
+var exploreModulePrompt = mcp.NewPrompt("explore_module",
+	mcp.WithPromptDescription("Explore a module: its submodules, data objects, functions and the questions it can answer"),
+	mcp.WithArgument("module", mcp.RequiredArgument(), mcp.ArgumentDescription("The module name, e.g. sales.crm")),
+)
+
+var answerQuestionPrompt = mcp.NewPrompt("answer_question",
+	mcp.WithPromptDescription("Answer a question with a hugr GraphQL query"),
+	mcp.WithArgument("question", mcp.RequiredArgument(), mcp.ArgumentDescription("The question in natural language")),
+	mcp.WithArgument("module", mcp.ArgumentDescription("Optional module to start the search from")),
+)
+
+var buildAggregationPrompt = mcp.NewPrompt("build_aggregation",
+	mcp.WithPromptDescription("Build an aggregation or bucket aggregation query for a data object"),
+	mcp.WithArgument("data_object", mcp.RequiredArgument(), mcp.ArgumentDescription("The data object name")),
+	mcp.WithArgument("measures", mcp.ArgumentDescription("Optional measures to aggregate, e.g. total amount, number of orders")),
+	mcp.WithArgument("group_by", mcp.ArgumentDescription("Optional fields or relations to group by")),
+)
+
+var investigateFieldValuesPrompt = mcp.NewPrompt("investigate_field_values",
+	mcp.WithPromptDescription("Investigate the values and distribution of a data object field"),
+	mcp.WithArgument("data_object", mcp.RequiredArgument(), mcp.ArgumentDescription("The data object name")),
+	mcp.WithArgument("field", mcp.RequiredArgument(), mcp.ArgumentDescription("The field name")),
+)
+
+type promptData struct {
+	Name     string // module or data object name
+	Summary  string // indexed summary in Markdown
+	Question string
+	Measures string
+	GroupBy  string
+	Field    string
+}
+
+func (s *Service) exploreModulePromptHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	name := request.Params.Arguments["module"]
+	summary, err := s.modulePromptSummary(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return promptResult("Explore the module "+name, "explore_module.md", promptData{
+		Name:    name,
+		Summary: summary,
+	})
+}
+
+func (s *Service) answerQuestionPromptHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	if args["question"] == "" {
+		return nil, errors.New("question is required")
+	}
+	data := promptData{
+		Name:     args["module"],
+		Question: args["question"],
+	}
+	if data.Name != "" {
+		summary, err := s.modulePromptSummary(ctx, data.Name)
+		if err != nil {
+			return nil, err
+		}
+		data.Summary = summary
+	}
+	return promptResult("Answer the question with a hugr query", "answer_question.md", data)
+}
+
+func (s *Service) buildAggregationPromptHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	summary, err := s.dataObjectPromptSummary(ctx, args["data_object"])
+	if err != nil {
+		return nil, err
+	}
+	return promptResult("Build an aggregation for "+args["data_object"], "build_aggregation.md", promptData{
+		Name:     args["data_object"],
+		Summary:  summary,
+		Measures: args["measures"],
+		GroupBy:  args["group_by"],
+	})
+}
+
+func (s *Service) investigateFieldValuesPromptHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	args := request.Params.Arguments
+	if args["field"] == "" {
+		return nil, errors.New("field is required")
+	}
+	summary, err := s.dataObjectPromptSummary(ctx, args["data_object"])
+	if err != nil {
+		return nil, err
+	}
+	return promptResult("Investigate the values of "+args["data_object"]+"."+args["field"], "field_values.md", promptData{
+		Name:    args["data_object"],
+		Summary: summary,
+		Field:   args["field"],
+	})
+}
+
+func (s *Service) modulePromptSummary(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("module is required")
+	}
+	module, err := s.indexer.ModuleDetails(ctx, name)
+	if errors.Is(err, types.ErrNoData) {
+		return "", fmt.Errorf("module %q not found", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return renderModule(module), nil
+}
+
+func (s *Service) dataObjectPromptSummary(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("data_object is required")
+	}
+	object, err := s.indexer.DataObjectDetails(ctx, name)
+	if errors.Is(err, types.ErrNoData) {
+		return "", fmt.Errorf("data object %q not found", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return renderDataObject(object), nil
+}
+
+func promptResult(description, name string, data promptData) (*mcp.GetPromptResult, error) {
+	text, err := renderPrompt(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	}), nil
+}
+
+func renderPrompt(name string, data promptData) (string, error) {
+	var buf bytes.Buffer
+	if err := promptTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("render prompt %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderPrompts(t *testing.T) {
+	tests := []struct {
+		name string
+		data promptData
+		want []string
+	}{
+		{
+			name: "explore_module.md",
+			data: promptData{Name: "sales", Summary: "# Module sales\n"},
+			want: []string{"module `sales`", "# Module sales\n", "`<object>_bucket_aggregation` groups rows"},
+		},
+		{
+			name: "answer_question.md",
+			data: promptData{Question: "How many orders?"},
+			want: []string{"> How many orders?", "**discovery-search_modules**"},
+		},
+		{
+			name: "build_aggregation.md",
+			data: promptData{Name: "orders", Measures: "total amount", GroupBy: "status"},
+			want: []string{"`orders`.\nMeasures: total amount.\nGroup by: status.\n"},
+		},
+		{
+			name: "field_values.md",
+			data: promptData{Name: "orders", Field: "status"},
+			want: []string{"`filter: { status: {eq: ...} }`"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := renderPrompt(tt.name, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("prompt does not contain %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+)
+
+// The indexed fields fixtures of the query builder tests.
+
+func testScalar(name, typ string) indexer.Field {
+	return indexer.Field{Name: name, Type: typ, FieldType: &indexer.Type{Name: typ, Kind: "SCALAR"}}
+}
+
+// testKey returns the primary key scalar field.
+func testKey(name, typ string) indexer.Field {
+	f := testScalar(name, typ)
+	f.IsPrimaryKey = true
+	return f
+}
+
+func testObject(name, typ string, list bool) indexer.Field {
+	return indexer.Field{Name: name, Type: typ, IsList: list, FieldType: &indexer.Type{Name: typ, Kind: "OBJECT"}}
+}
+
+func testInput(name, typ string) indexer.Field {
+	return indexer.Field{Name: name, Type: typ, FieldType: &indexer.Type{Name: typ, Kind: "INPUT_OBJECT"}}
+}
+
+// testQueryBuilder returns the query builder that reads the fields of the types from the map.
+func testQueryBuilder(types map[string][]indexer.Field) *queryBuilder {
+	return &queryBuilder{fields: func(_ context.Context, name string) ([]indexer.Field, error) {
+		return types[name], nil
+	}}
+}
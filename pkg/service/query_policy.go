@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// queryPolicy checks the free-form GraphQL queries of the data tools: only the read-only operations are allowed,
+// the selected root fields and modules are limited by the allowlist (all are allowed if it is empty).
+// The allowlist items are the dotted paths of the root fields, e.g. shop (the module with its submodules),
+// shop.sales.orders (the module query) or function.shop (the module functions).
+// The data object queries and mutations built by the tools are checked by their names in the module,
+// e.g. shop.sales.insert_orders allows to insert the orders.
+type queryPolicy struct {
+	allowed []string
+}
+
+const (
+	queryPolicyInvalid      = "invalid_query"
+	queryPolicyMutation     = "mutation"
+	queryPolicySubscription = "subscription"
+	queryPolicyNotAllowed   = "not_allowed"
+)
+
+type queryPolicyError struct {
+	Reason  string   `json:"reason" jsonschema_description:"The reason of the blocked request: invalid_query, mutation, subscription or not_allowed"`
+	Message string   `json:"message" jsonschema_description:"The error message"`
+	Path    string   `json:"path,omitempty" jsonschema_description:"The blocked root field path"`
+	Allowed []string `json:"allowed,omitempty" jsonschema_description:"The allowed root fields and modules"`
+}
+
+func (e *queryPolicyError) Error() string {
+	return e.Message
+}
+
+// toolResult returns the structured error result of the blocked request.
+func (e *queryPolicyError) toolResult() *mcp.CallToolResult {
+	res := mcp.NewToolResultStructured(e, "the query is blocked: "+e.Message)
+	res.IsError = true
+	return res
+}
+
+func (p queryPolicy) check(query string) *queryPolicyError {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return &queryPolicyError{Reason: queryPolicyInvalid, Message: err.Error()}
+	}
+	for _, op := range doc.Operations {
+		switch op.Operation {
+		case ast.Mutation:
+			return &queryPolicyError{
+				Reason:  queryPolicyMutation,
+				Message: "mutations are not allowed, the tool executes the read-only queries",
+			}
+		case ast.Subscription:
+			return &queryPolicyError{
+				Reason:  queryPolicySubscription,
+				Message: "subscriptions are not allowed, the tool executes the read-only queries",
+			}
+		}
+	}
+	if len(p.allowed) == 0 {
+		return nil
+	}
+	for _, op := range doc.Operations {
+		if err := p.checkSelection(doc, op.SelectionSet, "", map[string]bool{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDataObject checks the root fields of the data object queries and mutations that are built by the tools,
+// the fields are the query or mutation names in the data object module.
+func (p queryPolicy) checkDataObject(module string, fields ...string) *queryPolicyError {
+	if len(p.allowed) == 0 {
+		return nil
+	}
+	for _, f := range fields {
+		if f == "" {
+			continue
+		}
+		path := f
+		if module != "" {
+			path = module + "." + f
+		}
+		if allowed, _ := p.match(path); !allowed {
+			return &queryPolicyError{
+				Reason:  queryPolicyNotAllowed,
+				Message: fmt.Sprintf("the root field %q is not allowed", path),
+				Path:    path,
+				Allowed: p.allowed,
+			}
+		}
+	}
+	return nil
+}
+
+// dataObjectQueries returns the names of the data object queries of the types.
+func dataObjectQueries(info *indexer.DataObjectQueriesInfo, types ...metainfo.QueryType) []string {
+	var names []string
+	for _, q := range info.Queries {
+		if slices.Contains(types, metainfo.QueryType(q.Type)) {
+			names = append(names, q.Name)
+		}
+	}
+	return names
+}
+
+func (p queryPolicy) checkSelection(doc *ast.QueryDocument, set ast.SelectionSet, prefix string, fragments map[string]bool) *queryPolicyError {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name, "__") {
+				// the introspection fields
+				continue
+			}
+			path := sel.Name
+			if prefix != "" {
+				path = prefix + "." + path
+			}
+			allowed, partial := p.match(path)
+			if allowed {
+				continue
+			}
+			if !partial || len(sel.SelectionSet) == 0 {
+				return &queryPolicyError{
+					Reason:  queryPolicyNotAllowed,
+					Message: fmt.Sprintf("the root field %q is not allowed", path),
+					Path:    path,
+					Allowed: p.allowed,
+				}
+			}
+			if err := p.checkSelection(doc, sel.SelectionSet, path, fragments); err != nil {
+				return err
+			}
+		case *ast.InlineFragment:
+			if err := p.checkSelection(doc, sel.SelectionSet, prefix, fragments); err != nil {
+				return err
+			}
+		case *ast.FragmentSpread:
+			if fragments[sel.Name] {
+				continue
+			}
+			fragments[sel.Name] = true
+			f := doc.Fragments.ForName(sel.Name)
+			if f == nil {
+				return &queryPolicyError{Reason: queryPolicyInvalid, Message: fmt.Sprintf("fragment %q is not defined", sel.Name)}
+			}
+			if err := p.checkSelection(doc, f.SelectionSet, prefix, fragments); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// match returns true if the path is allowed by the allowlist item or its prefix,
+// partial is true if the path is a prefix of the allowlist item (the nested fields should be checked).
+func (p queryPolicy) match(path string) (allowed, partial bool) {
+	for _, a := range p.allowed {
+		if a == path || strings.HasPrefix(path, a+".") {
+			return true, false
+		}
+		if strings.HasPrefix(a, path+".") {
+			partial = true
+		}
+	}
+	return false, partial
+}
@@ -0,0 +1,66 @@
+package service
+
+import "testing"
+
+func TestQueryPolicy(t *testing.T) {
+	open := queryPolicy{}
+	limited := queryPolicy{allowed: []string{"shop.sales", "core.mcp.modules", "function.shop"}}
+
+	tests := []struct {
+		name   string
+		policy queryPolicy
+		query  string
+		reason string
+		path   string
+	}{
+		{"query", open, `{ shop { orders { id } } }`, "", ""},
+		{"invalid", open, `{ shop { orders { id }`, queryPolicyInvalid, ""},
+		{"mutation", open, `mutation { shop { delete_orders(filter: {}) { success } } }`, queryPolicyMutation, ""},
+		{"mutation operation", open, `query q { shop { orders { id } } } mutation m { shop { delete_orders { success } } }`, queryPolicyMutation, ""},
+		{"subscription", open, `subscription { shop { orders { id } } }`, queryPolicySubscription, ""},
+		{"allowed module", limited, `{ shop { sales { orders { id } customers { name } } } }`, "", ""},
+		{"allowed query", limited, `{ core { mcp { modules { name } } } __typename }`, "", ""},
+		{"not allowed submodule", limited, `{ shop { sales { orders { id } } hr { employees { id } } } }`, queryPolicyNotAllowed, "shop.hr"},
+		{"not allowed root", limited, `{ other { orders { id } } }`, queryPolicyNotAllowed, "other"},
+		{"not allowed alias", limited, `{ sales: core { mcp { types { name } } } }`, queryPolicyNotAllowed, "core.mcp.types"},
+		{"fragment", limited, `{ ...F } fragment F on Query { shop { hr { employees { id } } } }`, queryPolicyNotAllowed, "shop.hr"},
+		{"inline fragment", limited, `{ ... on Query { function { shop { total } } } }`, "", ""},
+		{"partial leaf", limited, `{ shop }`, queryPolicyNotAllowed, "shop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.check(tt.query)
+			if tt.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected %s error", tt.reason)
+			}
+			if err.Reason != tt.reason || err.Path != tt.path {
+				t.Errorf("unexpected error %+v", err)
+			}
+		})
+	}
+
+	// the data object queries and mutations built by the tools
+	if err := open.checkDataObject("hr", "employees"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := limited.checkDataObject("shop.sales", "insert_orders", ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := limited.checkDataObject("shop.hr", "employees_aggregation"); err == nil || err.Path != "shop.hr.employees_aggregation" {
+		t.Errorf("unexpected error %+v", err)
+	}
+	if err := limited.checkDataObject("", "shop"); err == nil || err.Reason != queryPolicyNotAllowed {
+		t.Errorf("unexpected error %+v", err)
+	}
+
+	res := (&queryPolicyError{Reason: queryPolicyMutation, Message: "blocked"}).toolResult()
+	if !res.IsError || res.StructuredContent == nil {
+		t.Errorf("expected structured error result: %+v", res)
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/query-engine/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// The schema resources render the stored summaries from the index.
+// Each resource is returned in two contents with the same URI: Markdown (text/markdown) and JSON (application/json).
+
+const (
+	resourceOverviewURI = "hugr://overview"
+	resourceURIPrefix   = "hugr://"
+
+	mimeMarkdown = "text/markdown"
+	mimeJSON     = "application/json"
+)
+
+var overviewResource = mcp.NewResource(resourceOverviewURI, "Schema overview",
+	mcp.WithResourceDescription("The data sources and modules of the hugr schema with their summaries"),
+	mcp.WithMIMEType(mimeMarkdown),
+)
+
+var moduleResourceTemplate = mcp.NewResourceTemplate(resourceURIPrefix+"module/{name}", "Module",
+	mcp.WithTemplateDescription("The module summary with its submodules, data objects and functions"),
+	mcp.WithTemplateMIMEType(mimeMarkdown),
+)
+
+var dataObjectResourceTemplate = mcp.NewResourceTemplate(resourceURIPrefix+"data-object/{name}", "Data object",
+	mcp.WithTemplateDescription("The data object (table or view) summary with its fields, queries and arguments"),
+	mcp.WithTemplateMIMEType(mimeMarkdown),
+)
+
+var functionResourceTemplate = mcp.NewResourceTemplate(resourceURIPrefix+"function/{module}/{name}", "Function",
+	mcp.WithTemplateDescription("The module function summary with its arguments and the return type, the module is empty for the root module functions (hugr://function//name)"),
+	mcp.WithTemplateMIMEType(mimeMarkdown),
+)
+
+func (s *Service) overviewResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	overview, err := s.indexer.SchemaOverview(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resourceContents(request.Params.URI, renderOverview(overview), overview)
+}
+
+func (s *Service) moduleResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name := resourceArgument(request, "name")
+	module, err := s.indexer.ModuleDetails(ctx, name)
+	if errors.Is(err, types.ErrNoData) {
+		return nil, fmt.Errorf("module %q not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resourceContents(request.Params.URI, renderModule(module), module)
+}
+
+func (s *Service) dataObjectResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	name := resourceArgument(request, "name")
+	object, err := s.indexer.DataObjectDetails(ctx, name)
+	if errors.Is(err, types.ErrNoData) {
+		return nil, fmt.Errorf("data object %q not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resourceContents(request.Params.URI, renderDataObject(object), object)
+}
+
+func (s *Service) functionResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	module, name := resourceArgument(request, "module"), resourceArgument(request, "name")
+	function, err := s.indexer.FunctionDetails(ctx, module, name)
+	if errors.Is(err, types.ErrNoData) {
+		return nil, fmt.Errorf("function %q not found in module %q", name, module)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resourceContents(request.Params.URI, renderFunction(function), function)
+}
+
+// resourceArgument returns the URI template variable value.
+func resourceArgument(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return ""
+}
+
+func resourceContents(uri, md string, v any) ([]mcp.ResourceContents, error) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{URI: uri, MIMEType: mimeMarkdown, Text: md},
+		mcp.TextResourceContents{URI: uri, MIMEType: mimeJSON, Text: string(b)},
+	}, nil
+}
+
+func moduleURI(name string) string {
+	return resourceURIPrefix + "module/" + name
+}
+
+func dataObjectURI(name string) string {
+	return resourceURIPrefix + "data-object/" + name
+}
+
+// functionURI returns the function resource URI, the module segment of the root module functions is empty.
+func functionURI(module, name string) string {
+	return resourceURIPrefix + "function/" + module + "/" + name
+}
+
+func renderOverview(o *indexer.SchemaOverview) string {
+	var sb strings.Builder
+	sb.WriteString("# Schema overview\n")
+	if len(o.DataSources) != 0 {
+		sb.WriteString("\n## Data sources\n\n")
+		for _, ds := range o.DataSources {
+			fmt.Fprintf(&sb, "- **%s** (%s", ds.Name, ds.Type)
+			if ds.ReadOnly {
+				sb.WriteString(", read only")
+			}
+			sb.WriteString(")")
+			writeDescription(&sb, ds.Description)
+		}
+	}
+	if len(o.Modules) != 0 {
+		sb.WriteString("\n## Modules\n\n")
+		for _, m := range o.Modules {
+			if m.Name == "" {
+				continue
+			}
+			fmt.Fprintf(&sb, "- [%s](%s)", m.Name, moduleURI(m.Name))
+			writeDescription(&sb, m.Description)
+		}
+	}
+	return sb.String()
+}
+
+func renderModule(m *indexer.ModuleDetails) string {
+	var sb strings.Builder
+	title := m.Name
+	if title == "" {
+		title = "root"
+	}
+	fmt.Fprintf(&sb, "# Module %s\n", title)
+	writeSummary(&sb, m.Description, m.LongDescription)
+	if len(m.SubModules) != 0 {
+		sb.WriteString("\n## Submodules\n\n")
+		for _, sm := range m.SubModules {
+			fmt.Fprintf(&sb, "- [%s](%s)", sm.Name, moduleURI(sm.Name))
+			writeDescription(&sb, sm.Description)
+		}
+	}
+	if len(m.DataObjects) != 0 {
+		sb.WriteString("\n## Data objects\n\n")
+		for _, t := range m.DataObjects {
+			fmt.Fprintf(&sb, "- [%s](%s) (%s)", t.Name, dataObjectURI(t.Name), t.HugrType)
+			writeDescription(&sb, t.Description)
+		}
+	}
+	writeModuleFunctions(&sb, "Functions", m.Name, m.Functions)
+	writeModuleFunctions(&sb, "Mutation functions", m.Name, m.MutFunctions)
+	return sb.String()
+}
+
+func writeModuleFunctions(sb *strings.Builder, title, module string, ff []indexer.Field) {
+	if len(ff) == 0 {
+		return
+	}
+	fmt.Fprintf(sb, "\n## %s\n\n", title)
+	for _, f := range ff {
+		fmt.Fprintf(sb, "- [%s](%s): %s", f.Name, functionURI(module, f.Name), fieldTypeString(f))
+		writeDescription(sb, f.Description)
+	}
+}
+
+func renderDataObject(o *indexer.DataObjectDetails) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Data object %s\n\n", o.Name)
+	fmt.Fprintf(&sb, "- Type: %s\n", o.HugrType)
+	if o.Module != "" {
+		fmt.Fprintf(&sb, "- Module: [%s](%s)\n", o.Module, moduleURI(o.Module))
+	}
+	if o.Catalog != "" {
+		fmt.Fprintf(&sb, "- Data source: %s\n", o.Catalog)
+	}
+	if o.FilterType != "" {
+		fmt.Fprintf(&sb, "- Filter type: %s\n", o.FilterType)
+	}
+	if o.ArgsType != "" {
+		fmt.Fprintf(&sb, "- Arguments type: %s\n", o.ArgsType)
+	}
+	writeSummary(&sb, o.Description, o.Long)
+	if len(o.Fields) != 0 {
+		sb.WriteString("\n## Fields\n\n")
+		sb.WriteString("| Name | Type | Kind | Description |\n|---|---|---|---|\n")
+		for _, f := range o.Fields {
+			name := f.Name
+			if f.IsPrimaryKey {
+				name += " (PK)"
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", name, fieldTypeString(f), f.HugrType, tableCell(f.Description))
+		}
+	}
+	if len(o.Queries) != 0 {
+		sb.WriteString("\n## Queries\n")
+		for _, q := range o.Queries {
+			fmt.Fprintf(&sb, "\n### %s (%s)\n", q.Name, q.QueryType)
+			writeDescription(&sb, q.Description)
+			writeArguments(&sb, q.Arguments)
+		}
+	}
+	return sb.String()
+}
+
+func renderFunction(f *indexer.FunctionDetails) string {
+	var sb strings.Builder
+	kind := "Function"
+	if f.IsMutation {
+		kind = "Mutation function"
+	}
+	fmt.Fprintf(&sb, "# %s %s\n\n", kind, f.Name)
+	if f.Module != "" {
+		fmt.Fprintf(&sb, "- Module: [%s](%s)\n", f.Module, moduleURI(f.Module))
+	}
+	if f.Catalog != "" {
+		fmt.Fprintf(&sb, "- Data source: %s\n", f.Catalog)
+	}
+	fmt.Fprintf(&sb, "- Returns: %s\n", fieldTypeString(f.Field))
+	writeSummary(&sb, f.Description, "")
+	if len(f.Arguments) != 0 {
+		sb.WriteString("\n## Arguments\n")
+		writeArguments(&sb, f.Arguments)
+	}
+	if f.FieldType != nil && len(f.FieldType.Fields) != 0 {
+		fmt.Fprintf(&sb, "\n## Return type %s\n\n", f.FieldType.Name)
+		sb.WriteString("| Name | Type | Description |\n|---|---|---|\n")
+		for _, rf := range f.FieldType.Fields {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", rf.Name, fieldTypeString(rf), tableCell(rf.Description))
+		}
+	}
+	return sb.String()
+}
+
+func writeArguments(sb *strings.Builder, args []indexer.Argument) {
+	if len(args) == 0 {
+		return
+	}
+	sb.WriteString("\n| Argument | Type | Description |\n|---|---|---|\n")
+	for _, a := range args {
+		fmt.Fprintf(sb, "| %s | %s | %s |\n", a.Name, typeString(a.Type, a.IsList, a.IsNotNull), tableCell(a.Description))
+	}
+}
+
+func writeSummary(sb *strings.Builder, desc, long string) {
+	if desc != "" {
+		fmt.Fprintf(sb, "\n%s\n", desc)
+	}
+	if long != "" && long != desc {
+		fmt.Fprintf(sb, "\n%s\n", long)
+	}
+}
+
+func writeDescription(sb *strings.Builder, desc string) {
+	if desc != "" {
+		sb.WriteString(" — ")
+		sb.WriteString(strings.Join(strings.Fields(desc), " "))
+	}
+	sb.WriteString("\n")
+}
+
+func fieldTypeString(f indexer.Field) string {
+	return typeString(f.Type, f.IsList, f.IsNotNull)
+}
+
+// typeString returns the GraphQL type notation, the non-null flag of the list fields relates to the list.
+func typeString(name string, isList, isNonNull bool) string {
+	if isList {
+		name = "[" + name + "]"
+	}
+	if isNonNull {
+		name += "!"
+	}
+	return name
+}
+
+func tableCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", "\\|")
+}
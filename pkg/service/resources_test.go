@@ -0,0 +1,69 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/query-engine/pkg/compiler/base"
+)
+
+func TestRenderResources(t *testing.T) {
+	module := renderModule(&indexer.ModuleDetails{
+		Module:     indexer.Module{Name: "sales", Description: "Sales data"},
+		SubModules: []indexer.Module{{Name: "sales.crm", Description: "CRM"}},
+		DataObjects: []indexer.Type{
+			{Name: "sales_orders", HugrType: base.HugrTypeTable, Description: "Orders"},
+		},
+		Functions: []indexer.Field{
+			{Name: "top_customers", Type: "customer", IsList: true, IsNotNull: true},
+		},
+	})
+	for _, want := range []string{
+		"# Module sales\n",
+		"- [sales.crm](hugr://module/sales.crm) — CRM\n",
+		"- [sales_orders](hugr://data-object/sales_orders) (table) — Orders\n",
+		"- [top_customers](hugr://function/sales/top_customers): [customer]!\n",
+	} {
+		if !strings.Contains(module, want) {
+			t.Errorf("module resource does not contain %q:\n%s", want, module)
+		}
+	}
+
+	object := renderDataObject(&indexer.DataObjectDetails{
+		Type: indexer.Type{Name: "sales_orders", HugrType: base.HugrTypeTable, Module: "sales", Fields: []indexer.Field{
+			{Name: "id", Type: "Int", IsNotNull: true, IsPrimaryKey: true, Description: "Order | id"},
+		}},
+		Queries: []indexer.DataObjectQueryDetails{
+			{Name: "sales_orders", QueryType: "select", Arguments: []indexer.Argument{{Name: "limit", Type: "Int"}}},
+		},
+	})
+	for _, want := range []string{
+		"- Module: [sales](hugr://module/sales)\n",
+		"| id (PK) | Int! |  | Order \\| id |\n",
+		"### sales_orders (select)\n",
+		"| limit | Int |  |\n",
+	} {
+		if !strings.Contains(object, want) {
+			t.Errorf("data object resource does not contain %q:\n%s", want, object)
+		}
+	}
+}
+
+func TestFunctionURI(t *testing.T) {
+	for _, tc := range []struct{ module, name, uri string }{
+		{"sales", "top_customers", "hugr://function/sales/top_customers"},
+		{"sales.crm", "leads", "hugr://function/sales.crm/leads"},
+		{"", "now", "hugr://function//now"},
+	} {
+		uri := functionURI(tc.module, tc.name)
+		if uri != tc.uri {
+			t.Errorf("unexpected uri %q, want %q", uri, tc.uri)
+		}
+		// the uri is read by the function resource template
+		values := functionResourceTemplate.URITemplate.Match(uri)
+		if values == nil || values.Get("module").String() != tc.module || values.Get("name").String() != tc.name {
+			t.Errorf("%s: unexpected template values %v", uri, values)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/query-engine/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var savedQueriesSearchTool = mcp.NewTool("saved_queries-search",
+	mcp.WithDescription("Return top-K saved (vetted) GraphQL queries relevant to a natural-language query. Check the saved queries before building a new query"),
+	mcp.WithInputSchema[indexer.SearchSavedQueriesRequest](),
+	mcp.WithOutputSchema[indexer.SearchResult[indexer.SavedQuery]](),
+)
+
+var savedQueriesInspectTool = mcp.NewTool("saved_queries-inspect",
+	mcp.WithDescription("Return the saved query by name: the GraphQL text, the variables JSON schema, description, tags, module and author"),
+	mcp.WithInputSchema[savedQueryInspectInput](),
+	mcp.WithOutputSchema[indexer.SavedQuery](),
+)
+
+var savedQueriesExecuteTool = mcp.NewTool("saved_queries-execute",
+	mcp.WithDescription("Execute the saved query with the variables validated against its variables schema (optionally apply a jq transform) and inline a small JSON result directly in the response"),
+	mcp.WithInputSchema[savedQueryExecuteInput](),
+	mcp.WithOutputSchema[simpleGraphQLResponse](),
+)
+
+type savedQueryInspectInput struct {
+	Name string `json:"name" jsonschema_description:"The name of the saved query"`
+}
+
+type savedQueryExecuteInput struct {
+	Name          string         `json:"name" jsonschema_description:"The name of the saved query"`
+	Variables     map[string]any `json:"variables,omitempty" jsonschema_description:"The query variables, they should match the variables schema of the saved query. Defaults from the schema are applied to the missing variables."`
+	JQTransform   string         `json:"jq_transform,omitempty" jsonschema_description:"Optional jq transform to apply to the JSON result of the query" jsonschema:"default="`
+	MaxResultSize int            `json:"max_result_size,omitempty" jsonschema_description:"The maximum size (in bytes) of the JSON result after applying the jq transform, the result is omitted if it exceeds this size" jsonschema:"minimum=100,maximum=5000,default=1000"`
+}
+
+func (s *Service) savedQueriesSearchHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &indexer.SearchSavedQueriesRequest{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+
+	queries, err := s.indexer.SearchSavedQueries(ctx, input)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to search saved queries", err), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(queries), nil
+}
+
+func (s *Service) savedQueriesInspectHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &savedQueryInspectInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+
+	q, err := s.savedQuery(ctx, input.Name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get saved query", err), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(q), nil
+}
+
+func (s *Service) savedQueriesExecuteHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &savedQueryExecuteInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+
+	q, err := s.savedQuery(ctx, input.Name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get saved query", err), nil
+	}
+	// the saved query could be stored before the policy is changed
+	if perr := s.policy.check(q.Query); perr != nil {
+		return perr.toolResult(), nil
+	}
+	vars, err := validateVariables(q.VariablesSchema, input.Variables)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid variables", err), nil
+	}
+
+	out, err := s.inlineGraphQLResult(ctx, &simpleGraphQLRequest{
+		OperationName: q.OperationName,
+		Query:         q.Query,
+		Variables:     vars,
+		JQTransform:   input.JQTransform,
+		MaxResultSize: input.MaxResultSize,
+	})
+	s.recordQuery(ctx, savedQueriesExecuteTool.Name, q.Query, vars, err)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to execute query", err), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(out), nil
+}
+
+func (s *Service) savedQuery(ctx context.Context, name string) (*indexer.SavedQuery, error) {
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	q, err := s.indexer.SavedQuery(ctx, name)
+	if errors.Is(err, types.ErrNoData) {
+		return nil, fmt.Errorf("saved query %q not found", name)
+	}
+	return q, err
+}
+
+// validateVariables checks the variables against the JSON schema of the saved query and applies the defaults.
+// It supports the subset of the JSON schema used for the GraphQL variables:
+// type, properties, required, items, enum, minimum, maximum and default.
+func validateVariables(schema, vars map[string]any) (map[string]any, error) {
+	if len(schema) == 0 {
+		return vars, nil
+	}
+	if vars == nil {
+		vars = map[string]any{}
+	}
+	v, err := validateValue(schema, vars, "variables")
+	if err != nil {
+		return nil, err
+	}
+	out, _ := v.(map[string]any)
+	return out, nil
+}
+
+func validateValue(schema map[string]any, v any, path string) (any, error) {
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool {
+		return fmt.Sprint(e) == fmt.Sprint(v)
+	}) {
+		return nil, fmt.Errorf("%s: value %v is not one of %v", path, v, enum)
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected object", path)
+		}
+		return validateObject(schema, obj, path)
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected array", path)
+		}
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			return arr, nil
+		}
+		out := make([]any, len(arr))
+		for i, item := range arr {
+			iv, err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = iv
+		}
+		return out, nil
+	case "string":
+		if _, ok := v.(string); !ok {
+			return nil, fmt.Errorf("%s: expected string", path)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return nil, fmt.Errorf("%s: expected boolean", path)
+		}
+	case "number", "integer":
+		n, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected %s", path, schema["type"])
+		}
+		if schema["type"] == "integer" && n != math.Trunc(n) {
+			return nil, fmt.Errorf("%s: expected integer", path)
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return nil, fmt.Errorf("%s: value %v is less than minimum %v", path, n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			return nil, fmt.Errorf("%s: value %v is greater than maximum %v", path, n, max)
+		}
+	}
+	return v, nil
+}
+
+func validateObject(schema, obj map[string]any, path string) (map[string]any, error) {
+	props, _ := schema["properties"].(map[string]any)
+	out := maps.Clone(obj)
+	for name, p := range props {
+		ps, _ := p.(map[string]any)
+		if _, ok := out[name]; !ok {
+			if def, ok := ps["default"]; ok {
+				out[name] = def
+			}
+		}
+	}
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := out[name]; !ok {
+				return nil, fmt.Errorf("%s: %s is required", path, name)
+			}
+		}
+	}
+	if schema["additionalProperties"] == false {
+		var unknown []string
+		for name := range out {
+			if _, ok := props[name]; !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) != 0 {
+			slices.Sort(unknown)
+			return nil, fmt.Errorf("%s: unknown properties %s", path, strings.Join(unknown, ", "))
+		}
+	}
+	for name, v := range out {
+		ps, _ := props[name].(map[string]any)
+		if ps == nil || v == nil {
+			continue
+		}
+		nv, err := validateValue(ps, v, path+"."+name)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = nv
+	}
+	return out, nil
+}
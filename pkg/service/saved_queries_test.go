@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateVariables(t *testing.T) {
+	var schema map[string]any
+	err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["from"],
+		"properties": {
+			"from": {"type": "string"},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 100, "default": 10},
+			"status": {"type": "array", "items": {"type": "string", "enum": ["new", "done"]}}
+		}
+	}`), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		vars    string
+		want    string
+		wantErr bool
+	}{
+		{name: "defaults", vars: `{"from": "2024-01-01"}`, want: `{"from": "2024-01-01", "limit": 10}`},
+		{name: "valid", vars: `{"from": "2024-01-01", "limit": 5, "status": ["new"]}`, want: `{"from": "2024-01-01", "limit": 5, "status": ["new"]}`},
+		{name: "required", vars: `{}`, wantErr: true},
+		{name: "wrong type", vars: `{"from": 1}`, wantErr: true},
+		{name: "not integer", vars: `{"from": "x", "limit": 1.5}`, wantErr: true},
+		{name: "maximum", vars: `{"from": "x", "limit": 101}`, wantErr: true},
+		{name: "enum", vars: `{"from": "x", "status": ["old"]}`, wantErr: true},
+		{name: "unknown", vars: `{"from": "x", "to": "y"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vars map[string]any
+			if err := json.Unmarshal([]byte(tt.vars), &vars); err != nil {
+				t.Fatal(err)
+			}
+			got, err := validateVariables(schema, vars)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want map[string]any
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("variables = %v, want %v", got, want)
+			}
+		})
+	}
+}
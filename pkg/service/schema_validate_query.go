@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var schemaValidateQueryTool = mcp.NewTool("schema-validate_query",
+	mcp.WithDescription("Validate a GraphQL query against the hugr schema without executing it. Returns the errors with line/column, the offending path and suggestions for the unknown fields and arguments"),
+	mcp.WithInputSchema[schemaValidateQueryInput](),
+	mcp.WithOutputSchema[indexer.ValidationResult](),
+)
+
+type schemaValidateQueryInput struct {
+	Query string `json:"query" jsonschema_description:"The GraphQL query to validate"`
+}
+
+func (s *Service) schemaValidateQueryHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &schemaValidateQueryInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.Query == "" {
+		return mcp.NewToolResultError("query is required"), nil
+	}
+
+	res, err := s.indexer.ValidateQuery(ctx, input.Query)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to validate query", err), nil
+	}
+
+	return mcp.NewToolResultStructuredOnly(res), nil
+}
@@ -3,12 +3,18 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/hugr-lab/mcp/pkg/artifacts"
 	"github.com/hugr-lab/mcp/pkg/auth"
 	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/mcp/pkg/jobs"
+	"github.com/hugr-lab/mcp/pkg/sessions"
 	hugr "github.com/hugr-lab/query-engine"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -19,39 +25,119 @@ const (
 )
 
 type Config struct {
+	// hugr connection for the user data queries, the user token replaces the secret if it is provided
 	URL          string
 	Secret       string
 	SecretHeader string
-	TTL          time.Duration
-	ttl          int // in seconds, for data queries
+	Headers      map[string]string
+	// Admin is the privileged hugr connection for the index maintenance and schema introspection,
+	// empty fields are taken from the user connection
+	Admin ClientConfig
+	TTL   time.Duration
+	ttl   int // in seconds, for data queries
+
+	// Auth enables OIDC bearer token validation of the MCP requests
+	Auth auth.OIDCConfig
+	// CORSOrigins allowed origins (empty - any origin)
+	CORSOrigins []string
+	// AdminSecret enables the admin API (/admin/), the secret is passed in the x-mcp-admin-secret header
+	AdminSecret string
+	// ReadOnly disables the data mutations through the MCP tools
+	ReadOnly bool
+	// AllowedQueryRoots limits the root fields and modules of the free-form data queries and of the data object
+	// queries and mutations built by the tools (dotted paths, empty - all)
+	AllowedQueryRoots []string
 
 	Indexer indexer.Config
+	// Artifacts is the store of the exported query results
+	Artifacts artifacts.Config
+	// Sessions enables the stateful MCP sessions
+	Sessions SessionsConfig
 }
 
-func (c *Config) hugrOptions() (opts []hugr.Option) {
-	if c.Secret == "" && c.SecretHeader == "" {
-		return
+type ClientConfig struct {
+	URL          string
+	Secret       string
+	SecretHeader string
+	Headers      map[string]string
+}
+
+func (c *Config) userClient() ClientConfig {
+	return ClientConfig{
+		URL:          c.URL,
+		Secret:       c.Secret,
+		SecretHeader: c.SecretHeader,
+		Headers:      c.Headers,
 	}
-	return append(opts, hugr.WithTransport(
-		auth.New(auth.WithSecretHeaderName(c.SecretHeader))),
-		hugr.WithApiKey(c.Secret),
-	)
+}
+
+func (c *Config) adminClient() ClientConfig {
+	cc := c.Admin
+	if cc.URL == "" {
+		cc.URL = c.URL
+	}
+	if cc.Secret == "" {
+		cc.Secret = c.Secret
+	}
+	if cc.SecretHeader == "" {
+		cc.SecretHeader = c.SecretHeader
+	}
+	if cc.Headers == nil {
+		cc.Headers = c.Headers
+	}
+	return cc
+}
+
+func (c ClientConfig) hugrOptions(privileged bool) (opts []hugr.Option) {
+	topts := []auth.Option{
+		auth.WithSecretHeaderName(c.SecretHeader),
+		auth.WithHeaders(c.Headers),
+	}
+	if privileged {
+		topts = append(topts, auth.WithPrivileged())
+	}
+	// the transport replaces the api key with the user token if it is in the request context (except the privileged client)
+	opts = append(opts, hugr.WithTransport(auth.New(topts...)))
+	if c.Secret == "" {
+		return opts
+	}
+	header := c.SecretHeader
+	if header == "" {
+		header = "x-hugr-api-key"
+	}
+	return append(opts, hugr.WithApiKeyCustomHeader(c.Secret, header))
 }
 
 type Service struct {
 	cfg Config
 
-	hugr    *hugr.Client
-	mcp     *server.MCPServer
-	s       *server.StreamableHTTPServer
-	indexer *indexer.Service
+	hugr      *hugr.Client // user data queries
+	mcp       *server.MCPServer
+	s         *server.StreamableHTTPServer
+	indexer   *indexer.Service
+	artifacts *artifacts.Service
+	sessions  *sessions.Manager
+	// sessionIDs issues the MCP session ids of the HTTP clients
+	sessionIDs *sessionIDManager
+	// stdioSession is the session of the stdio process, the stdio clients are not sharing the sessions
+	stdioSession string
+	oidc         *auth.OIDC
+	// confirmTokens signs the dry-run mutations
+	confirmTokens *confirmTokens
+	// policy checks the free-form data queries
+	policy queryPolicy
+	// cursors keeps the positions of the paged query results
+	cursors *resultCursors
+	jobs    *jobs.Manager
+	admin   http.Handler
 }
 
 func New(cfg Config) *Service {
-	hugr := hugr.NewClient(cfg.URL,
-		cfg.hugrOptions()...,
-	)
-	indexer := indexer.New(cfg.Indexer, hugr)
+	uc, ac := cfg.userClient(), cfg.adminClient()
+	hugr, admin := hugr.NewClient(uc.URL, uc.hugrOptions(false)...),
+		hugr.NewClient(ac.URL, ac.hugrOptions(true)...)
+	// the indexer reads and writes the mcp tables and introspects the schema with the privileged client
+	indexer := indexer.New(cfg.Indexer, admin)
 
 	if cfg.TTL <= 0 {
 		cfg.TTL = 60 * time.Second
@@ -67,37 +153,117 @@ func New(cfg Config) *Service {
 		server.WithRecovery(),
 		server.WithResourceRecovery(),
 		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
 	)
 
-	s := server.NewStreamableHTTPServer(mcp, server.WithStateLess(true))
+	svc := &Service{cfg: cfg, hugr: hugr, mcp: mcp, indexer: indexer, confirmTokens: newConfirmTokens(confirmTokenTTL)}
+	svc.policy = queryPolicy{allowed: cfg.AllowedQueryRoots}
+	svc.cursors = newResultCursors(resultCursorTTL)
+	if !cfg.Sessions.Enabled {
+		svc.s = server.NewStreamableHTTPServer(mcp, server.WithStateLess(true))
+		return svc
+	}
+	// the sessions store is created on Init
+	svc.sessionIDs = &sessionIDManager{}
+	svc.s = server.NewStreamableHTTPServer(mcp, server.WithSessionIdManager(svc.sessionIDs))
+	return svc
+}
 
-	return &Service{cfg: cfg, hugr: hugr, mcp: mcp, s: s, indexer: indexer}
+// Indexer returns the schema index service.
+func (s *Service) Indexer() *indexer.Service {
+	return s.indexer
 }
 
 func (s *Service) Init(ctx context.Context) error {
+	if s.cfg.Auth.Enabled() {
+		oidc, err := auth.NewOIDC(s.cfg.Auth)
+		if err != nil {
+			return fmt.Errorf("failed to initialize authentication: %w", err)
+		}
+		s.oidc = oidc
+	}
+
+	if s.cfg.Sessions.Enabled {
+		if err := s.initSessions(); err != nil {
+			return fmt.Errorf("failed to initialize sessions: %w", err)
+		}
+	}
+
 	// Initialize indexer
 	if err := s.indexer.Init(ctx); err != nil {
 		return fmt.Errorf("failed to initialize indexer: %w", err)
 	}
 
+	as, err := artifacts.New(s.cfg.Artifacts)
+	if err != nil {
+		return fmt.Errorf("failed to initialize artifacts store: %w", err)
+	}
+	s.artifacts = as
+	go s.artifacts.Cleanup(ctx, 0)
+
+	if s.cfg.Indexer.Watch.Interval > 0 {
+		go s.indexer.Watch(auth.CtxWithAdmin(ctx))
+	}
+
+	if s.sessions != nil {
+		go s.sessions.Cleanup(ctx, 0)
+	}
+
+	if s.cfg.AdminSecret != "" {
+		s.jobs = jobs.New(ctx, 0)
+		s.admin = s.adminHandler()
+	}
+
 	//s.mcp.AddTool(testTool, s.testToolHandler)
 	s.mcp.AddTool(discoveryModulesTool, s.discoveryModulesHandler)
 	s.mcp.AddTool(discoveryDataSourcesTool, s.discoveryDataSourcesHandler)
 	s.mcp.AddTool(discoveryModuleObjectsTool, s.discoveryModuleObjectsHandler)
 	s.mcp.AddTool(discoveryModuleFunctionsTool, s.discoveryModuleFunctionsHandler)
 	s.mcp.AddTool(discoveryDataObjectFieldValuesTool, s.discoveryDataObjectFieldValuesHandler)
+	s.mcp.AddTool(discoveryDataObjectGeometryTool, s.discoveryDataObjectGeometryHandler)
+	s.mcp.AddTool(discoveryRelationshipGraphTool, s.discoveryRelationshipGraphHandler)
+	s.mcp.AddTool(discoveryJoinPathTool, s.discoveryJoinPathHandler)
 	s.mcp.AddTool(schemaTypeInfoTool, s.schemaTypeInfoHandler)
 	s.mcp.AddTool(schemaTypeFieldsTool, s.schemaTypeFieldsHandler)
 	s.mcp.AddTool(schemaEnumValuesTool, s.schemaEnumValuesHandler)
+	s.mcp.AddTool(schemaValidateQueryTool, s.schemaValidateQueryHandler)
 	s.mcp.AddTool(dataInlineGraphQLResultTool, s.dataInlineGraphQLResultHandler)
+	s.mcp.AddTool(dataBuildQueryTool, s.dataBuildQueryHandler)
+	s.mcp.AddTool(dataQueryPageTool, s.dataQueryPageHandler)
+	s.mcp.AddTool(dataExportArtifactTool, s.dataExportArtifactHandler)
+	if !s.cfg.ReadOnly {
+		s.mcp.AddTool(dataMutateTool, s.dataMutateHandler)
+	}
+	s.mcp.AddTool(savedQueriesSearchTool, s.savedQueriesSearchHandler)
+	s.mcp.AddTool(savedQueriesInspectTool, s.savedQueriesInspectHandler)
+	s.mcp.AddTool(savedQueriesExecuteTool, s.savedQueriesExecuteHandler)
+
+	if s.sessions != nil {
+		s.mcp.AddTool(sessionGetContextTool, s.sessionGetContextHandler)
+		s.mcp.AddTool(sessionUpdateContextTool, s.sessionUpdateContextHandler)
+	}
+
+	s.mcp.AddResource(overviewResource, s.overviewResourceHandler)
+	s.mcp.AddResourceTemplate(moduleResourceTemplate, s.moduleResourceHandler)
+	s.mcp.AddResourceTemplate(dataObjectResourceTemplate, s.dataObjectResourceHandler)
+	s.mcp.AddResourceTemplate(functionResourceTemplate, s.functionResourceHandler)
+	s.mcp.AddResourceTemplate(artifactResourceTemplate, s.artifactResourceHandler)
+
+	s.mcp.AddPrompt(exploreModulePrompt, s.exploreModulePromptHandler)
+	s.mcp.AddPrompt(answerQuestionPrompt, s.answerQuestionPromptHandler)
+	s.mcp.AddPrompt(buildAggregationPrompt, s.buildAggregationPromptHandler)
+	s.mcp.AddPrompt(investigateFieldValuesPrompt, s.investigateFieldValuesPromptHandler)
 
 	return nil
 }
 
 func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	if s.admin != nil && strings.HasPrefix(r.URL.Path, "/admin/") {
+		s.admin.ServeHTTP(w, r)
+		return
+	}
+	s.setCORSHeaders(w, r)
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -105,5 +271,49 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Printf("MCP request: %s %s", r.Method, r.URL.Path)
+	if s.oidc != nil {
+		s.oidc.Middleware(s.s).ServeHTTP(w, r)
+		return
+	}
+	// pass the user token to the hugr queries
+	if token, ok := auth.TokenFromRequest(r); ok {
+		r = r.WithContext(auth.CtxWithToken(r.Context(), token))
+	}
 	s.s.ServeHTTP(w, r)
 }
+
+// ServeStdio serves the MCP tools over the stdio streams until the context is canceled or the input is closed.
+// The hugr queries are run with the user client credentials, the admin API is not available.
+func (s *Service) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	if s.sessions != nil {
+		ss, err := s.sessions.Create(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create stdio session: %w", err)
+		}
+		s.stdioSession = ss.ID
+	}
+	ss := server.NewStdioServer(s.mcp)
+	ss.SetErrorLogger(log.Default())
+	return ss.Listen(ctx, in, out)
+}
+
+func (s *Service) setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Sessions.Enabled {
+		// the browser clients pass and read the session id and terminate the session
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Mcp-Session-Id")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
+	} else {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	}
+	if len(s.cfg.CORSOrigins) == 0 {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	origin := r.Header.Get("Origin")
+	w.Header().Add("Vary", "Origin")
+	if slices.Contains(s.cfg.CORSOrigins, origin) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+}
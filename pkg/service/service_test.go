@@ -51,6 +51,19 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+func TestAdminClient(t *testing.T) {
+	cfg := &Config{URL: "http://hugr", Secret: "s", SecretHeader: "x-key", Headers: map[string]string{"x-tenant": "t1"}}
+	ac := cfg.adminClient()
+	if ac.URL != cfg.URL || ac.Secret != "s" || ac.SecretHeader != "x-key" || ac.Headers["x-tenant"] != "t1" {
+		t.Errorf("the admin client doesn't inherit the user connection: %+v", ac)
+	}
+	cfg.Admin = ClientConfig{Secret: "admin", Headers: map[string]string{"x-role": "admin"}}
+	ac = cfg.adminClient()
+	if ac.URL != cfg.URL || ac.Secret != "admin" || len(ac.Headers) != 1 || ac.Headers["x-role"] != "admin" {
+		t.Errorf("unexpected admin client: %+v", ac)
+	}
+}
+
 func testGetEnvInt(name string, defaultValue int) int {
 	v := os.Getenv(name)
 	if v == "" {
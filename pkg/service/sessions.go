@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/artifacts"
+	"github.com/hugr-lab/mcp/pkg/auth"
+	"github.com/hugr-lab/mcp/pkg/indexer"
+	"github.com/hugr-lab/mcp/pkg/sessions"
+	"github.com/hugr-lab/query-engine/pkg/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// The stateful sessions keep the context of the analysis conversation between the tool calls:
+// the selected module, the notes, the recent queries and artifacts.
+// The session id is issued on the MCP initialize request and is passed by the client in the Mcp-Session-Id header.
+
+type SessionsConfig struct {
+	Enabled bool
+	// TTL is the session idle timeout (default 1h)
+	TTL time.Duration
+	// Store is the sessions store: memory (default) or db (the MCP index database)
+	Store string
+}
+
+const (
+	sessionStoreMemory = "memory"
+	sessionStoreDB     = "db"
+
+	// mcpStdioSessionID is the session id of all stdio clients in mcp-go,
+	// it is replaced by the random session id of the process.
+	mcpStdioSessionID = "stdio"
+)
+
+var errSessionOwner = errors.New("the session belongs to another user")
+
+var sessionGetContextTool = mcp.NewTool("session-get_context",
+	mcp.WithDescription("Return the context of the current session: the selected module, the saved notes, the recent queries and the query result artifacts. Check it before re-discovering the schema in a long conversation"),
+	mcp.WithOutputSchema[sessions.Session](),
+)
+
+var sessionUpdateContextTool = mcp.NewTool("session-update_context",
+	mcp.WithDescription("Update the context of the current session: select the module (it is used by the module discovery tools if the module is not set), save or remove the notes (chosen data objects, filters, findings) and clear the history"),
+	mcp.WithInputSchema[sessionUpdateContextInput](),
+	mcp.WithOutputSchema[sessions.Session](),
+)
+
+type sessionUpdateContextInput struct {
+	Module string            `json:"module,omitempty" jsonschema_description:"The module to select"`
+	Notes  map[string]string `json:"notes,omitempty" jsonschema_description:"The notes to save by the key, the empty value removes the note"`
+	Clear  []string          `json:"clear,omitempty" jsonschema_description:"The parts of the context to clear" jsonschema:"enum=module,enum=notes,enum=history,enum=artifacts"`
+}
+
+func newSessionStore(cfg SessionsConfig, idx *indexer.Service) (sessions.Store, error) {
+	switch cfg.Store {
+	case "", sessionStoreMemory:
+		return sessions.NewMemoryStore(), nil
+	case sessionStoreDB:
+		return idx.SessionStore(), nil
+	}
+	return nil, fmt.Errorf("unknown sessions store %q", cfg.Store)
+}
+
+// initSessions creates the sessions manager with the configured store.
+func (s *Service) initSessions() error {
+	store, err := newSessionStore(s.cfg.Sessions, s.indexer)
+	if err != nil {
+		return err
+	}
+	s.sessions = sessions.NewManager(store, s.cfg.Sessions.TTL)
+	if s.sessionIDs != nil {
+		s.sessionIDs.m = s.sessions
+	}
+	return nil
+}
+
+// sessionIDManager issues and validates the MCP session ids by the sessions in the store.
+type sessionIDManager struct {
+	m *sessions.Manager
+}
+
+var _ server.SessionIdManager = (*sessionIDManager)(nil)
+
+func (sm *sessionIDManager) Generate() string {
+	s, err := sm.m.Create(context.Background())
+	if err != nil {
+		// the client works without the session context
+		log.Printf("sessions: %v", err)
+		return ""
+	}
+	return s.ID
+}
+
+func (sm *sessionIDManager) Validate(sessionID string) (isTerminated bool, err error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	if sessionID == mcpStdioSessionID {
+		// the shared stdio id is never a valid session
+		return true, nil
+	}
+	_, err = sm.m.Get(context.Background(), sessionID)
+	if errors.Is(err, sessions.ErrNotFound) {
+		// the client should start the new session
+		return true, nil
+	}
+	return false, err
+}
+
+func (sm *sessionIDManager) Terminate(sessionID string) (isNotAllowed bool, err error) {
+	return false, sm.m.Delete(context.Background(), sessionID)
+}
+
+// sessionID returns the MCP session id of the request, it is empty if the sessions are disabled.
+func (s *Service) sessionID(ctx context.Context) string {
+	if s.sessions == nil {
+		return ""
+	}
+	cs := server.ClientSessionFromContext(ctx)
+	if cs == nil {
+		return ""
+	}
+	if cs.SessionID() == mcpStdioSessionID {
+		return s.stdioSession
+	}
+	return cs.SessionID()
+}
+
+// updateSession applies the changes to the request session, the session is bound to the first authenticated user.
+func (s *Service) updateSession(ctx context.Context, fn func(ss *sessions.Session) error) (*sessions.Session, error) {
+	id := s.sessionID(ctx)
+	if id == "" {
+		return nil, errors.New("the sessions are not enabled or the client has no session")
+	}
+	return s.sessions.Update(ctx, id, func(ss *sessions.Session) error {
+		if user := auth.UserInfoFromCtx(ctx); user != nil && user.UserID != "" {
+			if ss.UserID != "" && ss.UserID != user.UserID {
+				return errSessionOwner
+			}
+			ss.UserID = user.UserID
+		}
+		return fn(ss)
+	})
+}
+
+// sessionModule returns the selected module of the request session.
+func (s *Service) sessionModule(ctx context.Context) string {
+	id := s.sessionID(ctx)
+	if id == "" {
+		return ""
+	}
+	ss, err := s.sessions.Get(ctx, id)
+	if err != nil {
+		return ""
+	}
+	if user := auth.UserInfoFromCtx(ctx); user != nil && ss.UserID != "" && ss.UserID != user.UserID {
+		return ""
+	}
+	return ss.Module
+}
+
+// recordQuery adds the executed query to the session history, the failures are logged only.
+func (s *Service) recordQuery(ctx context.Context, tool, query string, vars map[string]any, qerr error) {
+	if s.sessionID(ctx) == "" {
+		return
+	}
+	q := sessions.Query{Tool: tool, Query: query, Variables: vars, At: time.Now().UTC()}
+	if qerr != nil {
+		q.Error = qerr.Error()
+	}
+	_, err := s.updateSession(ctx, func(ss *sessions.Session) error {
+		ss.History = append(ss.History, q)
+		return nil
+	})
+	if err != nil {
+		log.Printf("sessions: failed to record query: %v", err)
+	}
+}
+
+// recordArtifact adds the created artifact to the session.
+func (s *Service) recordArtifact(ctx context.Context, a *artifacts.Artifact) {
+	if s.sessionID(ctx) == "" {
+		return
+	}
+	_, err := s.updateSession(ctx, func(ss *sessions.Session) error {
+		ss.Artifacts = append(ss.Artifacts, sessions.Artifact{
+			ID:        a.ID,
+			URI:       artifactURI(a.ID),
+			Format:    string(a.Format),
+			Rows:      a.Rows,
+			Query:     a.Query,
+			CreatedAt: a.CreatedAt,
+		})
+		return nil
+	})
+	if err != nil {
+		log.Printf("sessions: failed to record artifact: %v", err)
+	}
+}
+
+func (s *Service) sessionGetContextHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// the update extends the session expiration
+	ss, err := s.updateSession(ctx, func(*sessions.Session) error { return nil })
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to get session context", err), nil
+	}
+	return mcp.NewToolResultStructuredOnly(ss), nil
+}
+
+func (s *Service) sessionUpdateContextHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	input := &sessionUpdateContextInput{}
+	if err := request.BindArguments(input); err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid input", err), nil
+	}
+	if input.Module != "" {
+		_, err := s.indexer.ModuleDetails(ctx, input.Module)
+		if errors.Is(err, types.ErrNoData) {
+			return mcp.NewToolResultError(fmt.Sprintf("module %q not found", input.Module)), nil
+		}
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to check module", err), nil
+		}
+	}
+
+	ss, err := s.updateSession(ctx, func(ss *sessions.Session) error {
+		applySessionUpdate(ss, input)
+		return nil
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to update session context", err), nil
+	}
+	return mcp.NewToolResultStructuredOnly(ss), nil
+}
+
+func applySessionUpdate(ss *sessions.Session, input *sessionUpdateContextInput) {
+	for _, c := range input.Clear {
+		switch c {
+		case "module":
+			ss.Module = ""
+		case "notes":
+			ss.Notes = nil
+		case "history":
+			ss.History = nil
+		case "artifacts":
+			ss.Artifacts = nil
+		}
+	}
+	if input.Module != "" {
+		ss.Module = input.Module
+	}
+	for k, v := range input.Notes {
+		if v == "" {
+			delete(ss.Notes, k)
+			continue
+		}
+		if ss.Notes == nil {
+			ss.Notes = map[string]string{}
+		}
+		ss.Notes[k] = v
+	}
+}
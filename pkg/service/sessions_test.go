@@ -0,0 +1,74 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hugr-lab/mcp/pkg/sessions"
+)
+
+func TestSessionIDManager(t *testing.T) {
+	m := &sessionIDManager{m: sessions.NewManager(sessions.NewMemoryStore(), time.Minute)}
+
+	id := m.Generate()
+	if id == "" {
+		t.Fatal("expected session id")
+	}
+	if terminated, err := m.Validate(id); terminated || err != nil {
+		t.Errorf("expected active session, got %v, %v", terminated, err)
+	}
+	if terminated, err := m.Validate(""); terminated || err != nil {
+		t.Errorf("expected no session to be allowed, got %v, %v", terminated, err)
+	}
+	if terminated, err := m.Validate(mcpStdioSessionID); !terminated || err != nil {
+		t.Errorf("expected the shared stdio id to be rejected, got %v, %v", terminated, err)
+	}
+	if _, err := m.Terminate(id); err != nil {
+		t.Fatal(err)
+	}
+	if terminated, err := m.Validate(id); !terminated || err != nil {
+		t.Errorf("expected terminated session, got %v, %v", terminated, err)
+	}
+}
+
+func TestApplySessionUpdate(t *testing.T) {
+	ss := &sessions.Session{
+		Module:  "shop",
+		Notes:   map[string]string{"orders": "shop.orders", "old": "x"},
+		History: []sessions.Query{{Query: "{ q }"}},
+	}
+	applySessionUpdate(ss, &sessionUpdateContextInput{
+		Module: "crm",
+		Notes:  map[string]string{"old": "", "filter": "status = new"},
+		Clear:  []string{"history"},
+	})
+	if ss.Module != "crm" || len(ss.History) != 0 {
+		t.Errorf("unexpected session: %+v", ss)
+	}
+	if len(ss.Notes) != 2 || ss.Notes["filter"] != "status = new" || ss.Notes["orders"] != "shop.orders" {
+		t.Errorf("unexpected notes: %v", ss.Notes)
+	}
+
+	applySessionUpdate(ss, &sessionUpdateContextInput{Clear: []string{"module", "notes"}})
+	if ss.Module != "" || ss.Notes != nil {
+		t.Errorf("unexpected session: %+v", ss)
+	}
+}
+
+func TestSessionCORSHeaders(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		s := &Service{cfg: Config{Sessions: SessionsConfig{Enabled: enabled}}}
+		w := httptest.NewRecorder()
+		s.setCORSHeaders(w, httptest.NewRequest(http.MethodOptions, "/mcp", nil))
+		h := w.Header()
+		hasSession := strings.Contains(h.Get("Access-Control-Allow-Headers"), "Mcp-Session-Id") &&
+			strings.Contains(h.Get("Access-Control-Allow-Methods"), "DELETE") &&
+			h.Get("Access-Control-Expose-Headers") == "Mcp-Session-Id"
+		if hasSession != enabled {
+			t.Errorf("sessions %v: unexpected CORS headers %v", enabled, h)
+		}
+	}
+}
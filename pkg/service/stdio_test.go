@@ -0,0 +1,60 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeStdio(t *testing.T) {
+	s := New(Config{URL: "http://localhost:1"})
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0.0.1"}}}` + "\n")
+	var out bytes.Buffer
+	if err := s.ServeStdio(t.Context(), in, &out); err != nil {
+		t.Fatalf("serve stdio: %v", err)
+	}
+	var resp struct {
+		ID     int `json:"id"`
+		Result struct {
+			ServerInfo struct {
+				Name string `json:"name"`
+			} `json:"serverInfo"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response %q: %v", out.String(), err)
+	}
+	if resp.ID != 1 || resp.Result.ServerInfo.Name != mcpServerName {
+		t.Errorf("response = %s", out.String())
+	}
+}
+
+func TestServeStdioSession(t *testing.T) {
+	cfg := Config{URL: "http://localhost:1", Sessions: SessionsConfig{Enabled: true, TTL: time.Minute}}
+	var ids []string
+	for range 2 {
+		s := New(cfg)
+		if err := s.initSessions(); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ServeStdio(t.Context(), strings.NewReader(""), &bytes.Buffer{}); err != nil {
+			t.Fatalf("serve stdio: %v", err)
+		}
+		if s.stdioSession == "" || s.stdioSession == mcpStdioSessionID {
+			t.Fatalf("unexpected stdio session %q", s.stdioSession)
+		}
+		ids = append(ids, s.stdioSession)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("the stdio processes share the session %q", ids[0])
+	}
+}
+
+func TestInitSessionsStore(t *testing.T) {
+	s := New(Config{URL: "http://localhost:1", Sessions: SessionsConfig{Enabled: true, Store: "redis"}})
+	if err := s.initSessions(); err == nil {
+		t.Error("expected error for the unknown sessions store")
+	}
+}
@@ -0,0 +1,65 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps the sessions in the process memory, the sessions are lost on restart.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: map[string][]byte{}}
+}
+
+// the sessions are stored encoded, the callers never share the session values
+func (m *MemoryStore) Get(_ context.Context, id string) (*Session, error) {
+	m.mu.Lock()
+	b, ok := m.sessions[id]
+	m.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (m *MemoryStore) Put(_ context.Context, s *Session) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.sessions[s.ID] = b
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MemoryStore) DeleteExpired(_ context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, b := range m.sessions {
+		var s struct {
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		if err := json.Unmarshal(b, &s); err != nil || s.ExpiresAt.Before(before) {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
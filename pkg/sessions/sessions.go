@@ -0,0 +1,172 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxHistory is the number of the recent queries kept in the session
+	MaxHistory = 20
+	// MaxArtifacts is the number of the recent artifacts kept in the session
+	MaxArtifacts = 20
+)
+
+var ErrNotFound = errors.New("session not found")
+
+// Session is the per-session context of the analysis conversation.
+type Session struct {
+	ID        string            `json:"id" jsonschema_description:"The session id"`
+	UserID    string            `json:"user_id,omitempty" jsonschema_description:"The session owner"`
+	Module    string            `json:"module,omitempty" jsonschema_description:"The selected module"`
+	Notes     map[string]string `json:"notes,omitempty" jsonschema_description:"The notes (findings, chosen data objects, filters) saved by the agent"`
+	Artifacts []Artifact        `json:"artifacts,omitempty" jsonschema_description:"The recent query result artifacts, the latest last"`
+	History   []Query           `json:"history,omitempty" jsonschema_description:"The recent queries, the latest last"`
+	CreatedAt time.Time         `json:"created_at" jsonschema_description:"The session creation time"`
+	UpdatedAt time.Time         `json:"updated_at" jsonschema_description:"The session last update time"`
+	ExpiresAt time.Time         `json:"expires_at" jsonschema_description:"The session expiration time, it is extended on each update"`
+}
+
+type Artifact struct {
+	ID        string    `json:"id" jsonschema_description:"The artifact id"`
+	URI       string    `json:"uri" jsonschema_description:"The artifact resource URI"`
+	Format    string    `json:"format" jsonschema_description:"The artifact file format"`
+	Rows      int64     `json:"rows" jsonschema_description:"The number of rows"`
+	Query     string    `json:"query,omitempty" jsonschema_description:"The GraphQL query of the artifact"`
+	CreatedAt time.Time `json:"created_at" jsonschema_description:"The artifact creation time"`
+}
+
+type Query struct {
+	Tool      string         `json:"tool" jsonschema_description:"The tool that executed the query"`
+	Query     string         `json:"query" jsonschema_description:"The GraphQL query or the saved query name"`
+	Variables map[string]any `json:"variables,omitempty" jsonschema_description:"The query variables"`
+	Error     string         `json:"error,omitempty" jsonschema_description:"The query error"`
+	At        time.Time      `json:"at" jsonschema_description:"The execution time"`
+}
+
+// Store keeps the sessions.
+type Store interface {
+	// Get returns ErrNotFound if the session does not exist.
+	Get(ctx context.Context, id string) (*Session, error)
+	Put(ctx context.Context, s *Session) error
+	Delete(ctx context.Context, id string) error
+	// DeleteExpired removes the sessions expired before the time.
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// Manager creates the sessions and updates them in the store, the session expiration is extended on each update.
+type Manager struct {
+	store Store
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu sync.Mutex // serializes the read-modify-write updates
+}
+
+func NewManager(store Store, ttl time.Duration) *Manager {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &Manager{store: store, ttl: ttl, now: time.Now}
+}
+
+// Create starts the new session with the generated id.
+func (m *Manager) Create(ctx context.Context) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return m.create(ctx, id)
+}
+
+func (m *Manager) create(ctx context.Context, id string) (*Session, error) {
+	now := m.now().UTC()
+	s := &Session{ID: id, CreatedAt: now, UpdatedAt: now, ExpiresAt: now.Add(m.ttl)}
+	if err := m.store.Put(ctx, s); err != nil {
+		return nil, fmt.Errorf("create session: %w", err)
+	}
+	return s, nil
+}
+
+// Get returns the active session, it returns ErrNotFound if the session does not exist or is expired.
+func (m *Manager) Get(ctx context.Context, id string) (*Session, error) {
+	s, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !s.ExpiresAt.After(m.now()) {
+		return nil, ErrNotFound
+	}
+	return s, nil
+}
+
+// Load returns the active session or starts the new one with the id (e.g. the expired stdio session).
+func (m *Manager) Load(ctx context.Context, id string) (*Session, error) {
+	s, err := m.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return m.create(ctx, id)
+	}
+	return s, err
+}
+
+// Update applies the changes to the session, extends its expiration and trims the history.
+func (m *Manager) Update(ctx context.Context, id string, fn func(s *Session) error) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(s); err != nil {
+		return nil, err
+	}
+	if n := len(s.History); n > MaxHistory {
+		s.History = s.History[n-MaxHistory:]
+	}
+	if n := len(s.Artifacts); n > MaxArtifacts {
+		s.Artifacts = s.Artifacts[n-MaxArtifacts:]
+	}
+	s.UpdatedAt = m.now().UTC()
+	s.ExpiresAt = s.UpdatedAt.Add(m.ttl)
+	if err := m.store.Put(ctx, s); err != nil {
+		return nil, fmt.Errorf("update session: %w", err)
+	}
+	return s, nil
+}
+
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	return m.store.Delete(ctx, id)
+}
+
+// Cleanup removes the expired sessions from the store periodically until the context is canceled.
+func (m *Manager) Cleanup(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = m.ttl
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := m.store.DeleteExpired(ctx, m.now()); err != nil {
+				log.Printf("sessions: failed to delete expired sessions: %v", err)
+			}
+		}
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session id: %w", err)
+	}
+	return "mcp-session-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,73 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	m := NewManager(store, time.Minute)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	s, err := m.Create(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ExpiresAt != now.Add(time.Minute) {
+		t.Errorf("unexpected expiration: %v", s.ExpiresAt)
+	}
+
+	now = now.Add(30 * time.Second)
+	_, err = m.Update(ctx, s.ID, func(s *Session) error {
+		s.Module = "shop"
+		for i := range MaxHistory + 5 {
+			s.History = append(s.History, Query{Tool: "test", Query: strconv.Itoa(i)})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := m.Get(ctx, s.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Module != "shop" || len(got.History) != MaxHistory || got.History[0].Query != "5" {
+		t.Errorf("unexpected session: %+v", got)
+	}
+	if got.ExpiresAt != now.Add(time.Minute) {
+		t.Errorf("expiration is not extended: %v", got.ExpiresAt)
+	}
+
+	// the failed update does not change the session
+	failed := errors.New("failed")
+	if _, err := m.Update(ctx, s.ID, func(s *Session) error { s.Module = "x"; return failed }); !errors.Is(err, failed) {
+		t.Errorf("expected update error, got %v", err)
+	}
+	if got, _ := m.Get(ctx, s.ID); got.Module != "shop" {
+		t.Errorf("unexpected module %q", got.Module)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := m.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired session, got %v", err)
+	}
+	if err := store.DeleteExpired(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, s.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected deleted session, got %v", err)
+	}
+
+	// the unknown session is started on load
+	s, err = m.Load(ctx, "stdio")
+	if err != nil || s.ID != "stdio" {
+		t.Errorf("unexpected session %+v: %v", s, err)
+	}
+}
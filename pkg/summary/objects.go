@@ -346,11 +346,21 @@ func (g *RelatedGraph) addDataObject(schema *metainfo.SchemaInfo, object *metain
 		}
 
 		if !g.edgeExists(object.Name + ":" + ref.FieldDataQuery) {
+			cardinality := RelatedCardinalityMany
+			if kind == RelatedEdgeKindManyToOne {
+				cardinality = RelatedCardinalityOne
+			}
 			g.Edges = append(g.Edges, RelatedEdge{
-				Name: object.Name + ":" + ref.FieldDataQuery,
-				From: object.Name,
-				To:   ref.DataObject,
-				Kind: kind,
+				Name:                   object.Name + ":" + ref.FieldDataQuery,
+				From:                   object.Name,
+				To:                     ref.DataObject,
+				Kind:                   kind,
+				Cardinality:            cardinality,
+				Field:                  ref.FieldDataQuery,
+				AggregationField:       ref.FieldAggQuery,
+				BucketAggregationField: ref.FieldBucketAggQuery,
+				M2MTable:               ref.M2MTable,
+				JoinFields:             referenceJoinFields(schema, object, &ref, refObject),
 			})
 		}
 	}
@@ -369,11 +379,20 @@ func (g *RelatedGraph) addDataObject(schema *metainfo.SchemaInfo, object *metain
 			return err
 		}
 		if !g.edgeExists(object.Name + ":" + subq.FieldDataQuery) {
+			// only the subqueries that return the list of rows have the aggregation queries
+			cardinality := RelatedCardinalityOne
+			if subq.FieldAggQuery != "" {
+				cardinality = RelatedCardinalityMany
+			}
 			g.Edges = append(g.Edges, RelatedEdge{
-				Name: object.Name + ":" + subq.FieldDataQuery,
-				From: object.Name,
-				To:   sqObject.Name,
-				Kind: RelatedEdgeKindSubquery,
+				Name:                   object.Name + ":" + subq.FieldDataQuery,
+				From:                   object.Name,
+				To:                     sqObject.Name,
+				Kind:                   RelatedEdgeKindSubquery,
+				Cardinality:            cardinality,
+				Field:                  subq.FieldDataQuery,
+				AggregationField:       subq.FieldAggQuery,
+				BucketAggregationField: subq.FieldBucketAggQuery,
 			})
 		}
 	}
@@ -385,11 +404,17 @@ func (g *RelatedGraph) addDataObject(schema *metainfo.SchemaInfo, object *metain
 			return err
 		}
 		if !g.edgeExists(object.Name + "->" + "fc:" + fnCall.FieldName) {
+			cardinality := RelatedCardinalityOne
+			if fnCall.ReturnsArray {
+				cardinality = RelatedCardinalityMany
+			}
 			g.Edges = append(g.Edges, RelatedEdge{
-				Name: object.Name + "->" + "fc:" + fnCall.FieldName,
-				From: object.Name,
-				To:   "fc:" + fnCall.Name,
-				Kind: RelatedEdgeKindFunctionCall,
+				Name:        object.Name + "->" + "fc:" + fnCall.FieldName,
+				From:        object.Name,
+				To:          "fc:" + fnCall.Name,
+				Kind:        RelatedEdgeKindFunctionCall,
+				Cardinality: cardinality,
+				Field:       fnCall.FieldName,
 			})
 		}
 	}
@@ -411,6 +436,70 @@ func (g *RelatedGraph) addFunctionCall(fnCall *metainfo.FunctionCallInfo, depth
 	return nil
 }
 
+// NewRelatedGraph builds the graph of the data objects and the objects related to them up to the max depth.
+func NewRelatedGraph(schema *metainfo.SchemaInfo, maxDepth int, objects ...*metainfo.DataObjectInfo) (*RelatedGraph, error) {
+	g := &RelatedGraph{
+		Nodes:    []RelatedNode{},
+		Edges:    []RelatedEdge{},
+		MaxDepth: maxDepth,
+	}
+	for _, object := range objects {
+		if err := g.addDataObject(schema, object, 0); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// referenceJoinFields infers the join fields of the reference by the field names: the many-to-one reference field
+// "customer" to the object with the primary key "id" is joined by the field "customer_id",
+// the one-to-many reference is joined by the fields of the back many-to-one reference.
+// It returns nil if the join fields are not found or ambiguous.
+func referenceJoinFields(schema *metainfo.SchemaInfo, object *metainfo.DataObjectInfo, ref *metainfo.SubqueryInfo, refObject *metainfo.DataObjectInfo) []RelatedJoinField {
+	switch ref.Type {
+	case metainfo.ReferenceTypeManyToOne:
+		return manyToOneJoinFields(object, ref.FieldDataQuery, refObject)
+	case metainfo.ReferenceTypeOneToMany:
+		var found []RelatedJoinField
+		for _, back := range refObject.References {
+			if back.Type != metainfo.ReferenceTypeManyToOne || back.DataObject != object.Name || back.Module != object.Module {
+				continue
+			}
+			ff := manyToOneJoinFields(refObject, back.FieldDataQuery, object)
+			if ff == nil {
+				continue
+			}
+			if found != nil {
+				return nil
+			}
+			for _, f := range ff {
+				found = append(found, RelatedJoinField{From: f.To, To: f.From})
+			}
+		}
+		return found
+	}
+	return nil
+}
+
+func manyToOneJoinFields(object *metainfo.DataObjectInfo, field string, refObject *metainfo.DataObjectInfo) []RelatedJoinField {
+	columns := map[string]string{}
+	for _, col := range object.Columns {
+		columns[col.Name] = col.Type
+	}
+	var ff []RelatedJoinField
+	for _, pk := range refObject.Columns {
+		if !pk.IsPrimaryKey {
+			continue
+		}
+		name := field + "_" + pk.Name
+		if t, ok := columns[name]; !ok || t != pk.Type {
+			return nil
+		}
+		ff = append(ff, RelatedJoinField{From: name, To: pk.Name})
+	}
+	return ff
+}
+
 // Related graph (deduped, bounded depth) for recursive awareness
 type RelatedNode struct {
 	Type       string `json:"type"` // "table" | "view" | "function"
@@ -430,11 +519,31 @@ const (
 	RelatedEdgeKindFunctionCall RelatedEdgeKind = "function_call"
 )
 
+type RelatedCardinality string
+
+const (
+	RelatedCardinalityOne  RelatedCardinality = "one"
+	RelatedCardinalityMany RelatedCardinality = "many"
+)
+
 type RelatedEdge struct {
-	Name string          `json:"name"` // edge name
-	From string          `json:"from"` // node name or qualified id
-	To   string          `json:"to"`
-	Kind RelatedEdgeKind `json:"kind"` // "fk:one-to-many" | "fk:many-to-one" | "fk:many-to-many" | "subquery" | "function_call"
+	Name        string             `json:"name"` // edge name
+	From        string             `json:"from"` // node name or qualified id
+	To          string             `json:"to"`
+	Kind        RelatedEdgeKind    `json:"kind"`                  // "fk:one-to-many" | "fk:many-to-one" | "fk:many-to-many" | "subquery" | "function_call"
+	Cardinality RelatedCardinality `json:"cardinality,omitempty"` // the number of the target rows per source row: "one" | "many"
+	// the hugr fields to traverse the edge
+	Field                  string `json:"field,omitempty"`
+	AggregationField       string `json:"aggregation_field,omitempty"`
+	BucketAggregationField string `json:"bucket_aggregation_field,omitempty"`
+	M2MTable               string `json:"m2m_table,omitempty"`
+	// inferred by the field names, omitted if unknown
+	JoinFields []RelatedJoinField `json:"join_fields,omitempty"`
+}
+
+type RelatedJoinField struct {
+	From string `json:"from"` // the source object field
+	To   string `json:"to"`   // the target object field
 }
 
 type RelatedGraph struct {
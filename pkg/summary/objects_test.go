@@ -2,10 +2,12 @@ package summary
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/hugr-lab/mcp/pkg/pool"
+	metainfo "github.com/hugr-lab/query-engine/pkg/data-sources/sources/runtime/meta-info"
 )
 
 func TestObjects(t *testing.T) {
@@ -55,3 +57,84 @@ func TestObjects(t *testing.T) {
 
 	t.Logf("Table Summary: (%d) %s", len(b)/1024, string(b))
 }
+
+func testRelatedSchema() *metainfo.SchemaInfo {
+	return &metainfo.SchemaInfo{
+		RootModule: metainfo.ModuleInfo{
+			SubModules: []metainfo.ModuleInfo{{
+				Name: "shop",
+				Tables: []metainfo.DataObjectInfo{
+					{
+						Name:   "customers",
+						Module: "shop",
+						Type:   "table",
+						Columns: []metainfo.FieldInfo{
+							{Name: "id", Type: "Int", IsPrimaryKey: true},
+							{Name: "name", Type: "String"},
+						},
+						References: []metainfo.SubqueryInfo{{
+							Name: "orders", Type: metainfo.ReferenceTypeOneToMany, Module: "shop", DataObject: "orders",
+							FieldDataQuery: "orders", FieldAggQuery: "orders_aggregation",
+						}},
+					},
+					{
+						Name:   "orders",
+						Module: "shop",
+						Type:   "table",
+						Columns: []metainfo.FieldInfo{
+							{Name: "id", Type: "Int", IsPrimaryKey: true},
+							{Name: "customer_id", Type: "Int"},
+						},
+						References: []metainfo.SubqueryInfo{{
+							Name: "customer", Type: metainfo.ReferenceTypeManyToOne, Module: "shop", DataObject: "customers",
+							FieldDataQuery: "customer",
+						}},
+						FunctionCalls: []metainfo.FunctionCallInfo{{Name: "order_weather", FieldName: "weather", ReturnsArray: true}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestRelatedGraph(t *testing.T) {
+	schema := testRelatedSchema()
+	g, err := NewRelatedGraph(schema, 1, schema.Table("shop.orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes) != 3 || g.Nodes[0].Name != "orders" || g.Nodes[1].Name != "customers" || g.Nodes[2].Name != "fc:order_weather" {
+		t.Fatalf("unexpected nodes %+v", g.Nodes)
+	}
+	expected := []RelatedEdge{
+		{
+			Name: "orders:customer", From: "orders", To: "customers", Kind: RelatedEdgeKindManyToOne,
+			Cardinality: RelatedCardinalityOne, Field: "customer",
+			JoinFields: []RelatedJoinField{{From: "customer_id", To: "id"}},
+		},
+		{
+			Name: "orders->fc:weather", From: "orders", To: "fc:order_weather", Kind: RelatedEdgeKindFunctionCall,
+			Cardinality: RelatedCardinalityMany, Field: "weather",
+		},
+	}
+	if !reflect.DeepEqual(g.Edges, expected) {
+		t.Errorf("unexpected edges %+v", g.Edges)
+	}
+
+	// the one-to-many join fields are taken from the back reference
+	g, err = NewRelatedGraph(schema, 1, schema.Table("shop.customers"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Edges) != 1 || g.Edges[0].Cardinality != RelatedCardinalityMany || g.Edges[0].AggregationField != "orders_aggregation" ||
+		!reflect.DeepEqual(g.Edges[0].JoinFields, []RelatedJoinField{{From: "id", To: "customer_id"}}) {
+		t.Errorf("unexpected edges %+v", g.Edges)
+	}
+
+	// the join fields are not inferred if the field names do not match
+	schema.RootModule.SubModules[0].Tables[1].Columns[1].Name = "client"
+	g, _ = NewRelatedGraph(schema, 1, schema.Table("shop.orders"))
+	if g.Edges[0].JoinFields != nil {
+		t.Errorf("unexpected join fields %+v", g.Edges[0].JoinFields)
+	}
+}